@@ -0,0 +1,18 @@
+package refind
+
+import (
+	"time"
+)
+
+type Album struct {
+	ID          string
+	Name        string
+	ReleaseDate time.Time
+	Images      []Image
+}
+
+type Image struct {
+	URL    string
+	Width  int
+	Height int
+}
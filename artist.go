@@ -8,8 +8,15 @@ import (
 var errArtistSeed = errors.New("cannot create artist seed with missing id")
 
 type Artist struct {
-	ID   string
-	Name string
+	ID         string
+	Name       string
+	Genres     []string
+	Images     []Image
+	Followers  int
+	Popularity int
+	URI        string
+	URLs       map[string]string
+	Rankings   []Ranking
 }
 
 func (a Artist) Seed() (Seed, error) {
@@ -19,3 +26,28 @@ func (a Artist) Seed() (Seed, error) {
 
 	return Seed{Category: ArtistSeed, ID: a.ID}, nil
 }
+
+// Rank returns the artist's position within the given time range, starting
+// from 1, and whether the artist appeared in that range at all.
+func (a Artist) Rank(r TimeRange) (int, bool) {
+	for _, rk := range a.Rankings {
+		if rk.Range == r {
+			return rk.Rank, true
+		}
+	}
+
+	return 0, false
+}
+
+// Weight scores the artist by summing the weight of every time range it
+// appeared in, divided by its rank in that range.
+func (a Artist) Weight(w RangeWeights) float64 {
+	var sum float64
+	for _, rk := range a.Rankings {
+		if rk.Rank > 0 {
+			sum += w[rk.Range] / float64(rk.Rank)
+		}
+	}
+
+	return sum
+}
@@ -0,0 +1,85 @@
+package refind
+
+import (
+	"testing"
+)
+
+func TestArtist_Rank(t *testing.T) {
+	a := Artist{
+		ID:   "0",
+		Name: "foo",
+		Rankings: []Ranking{
+			{Range: ShortTerm, Rank: 3},
+			{Range: LongTerm, Rank: 1},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		r        TimeRange
+		wantRank int
+		wantOK   bool
+	}{
+		{"Short term", ShortTerm, 3, true},
+		{"Medium term", MediumTerm, 0, false},
+		{"Long term", LongTerm, 1, true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			rank, ok := a.Rank(test.r)
+			if rank != test.wantRank || ok != test.wantOK {
+				t.Errorf("got: <%v, %v>, want: <%v, %v>", rank, ok, test.wantRank, test.wantOK)
+			}
+		})
+	}
+}
+
+func TestArtist_Weight(t *testing.T) {
+	tests := []struct {
+		name string
+		a    Artist
+		w    RangeWeights
+		want float64
+	}{
+		{
+			"No rankings",
+			Artist{ID: "0", Name: "foo"},
+			CurrentFavorites,
+			0,
+		},
+		{
+			"Current favorite weighted for current favorites",
+			Artist{ID: "0", Name: "foo", Rankings: []Ranking{{Range: ShortTerm, Rank: 1}}},
+			CurrentFavorites,
+			3,
+		},
+		{
+			"Current favorite weighted for long term favorites",
+			Artist{ID: "0", Name: "foo", Rankings: []Ranking{{Range: ShortTerm, Rank: 1}}},
+			LongTermFavorites,
+			1,
+		},
+		{
+			"Multiple rankings",
+			Artist{
+				ID:   "0",
+				Name: "foo",
+				Rankings: []Ranking{
+					{Range: ShortTerm, Rank: 2},
+					{Range: MediumTerm, Rank: 4},
+					{Range: LongTerm, Rank: 1},
+				},
+			},
+			CurrentFavorites,
+			3,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := test.a.Weight(test.w)
+			if got != test.want {
+				t.Errorf("got: <%v>, want: <%v>", got, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,352 @@
+package refind
+
+import (
+	"github.com/pkg/errors"
+	"math"
+	"sort"
+)
+
+// blendSeeds is the number of seeds shared between the participants of a
+// blend when the generator has no seed limit of its own.
+const blendSeeds int = 25
+
+var (
+	errBlendTooFew    = errors.New("blend needs at least one other participant")
+	errNilParticipant = errors.New("cannot blend with a nil participant")
+	errWeightsInvalid = errors.New("blend weights must be positive, one per participant")
+)
+
+// BlendFilter decides which artists are too familiar for a blend.
+type BlendFilter int
+
+const (
+	// KnownToAny removes artists that any participant already listens to.
+	KnownToAny BlendFilter = iota
+	// KnownToAll removes only artists that every participant listens to.
+	KnownToAll
+)
+
+// Blend configures a shared tracklist for the generator's user and Others.
+// Weights gives each participant's share of the seeds, the generator's user
+// first, and defaults to equal shares.
+type Blend struct {
+	Others  []MusicService
+	Weights []float64
+	Filter  BlendFilter
+}
+
+func (b Blend) weights() ([]float64, error) {
+	n := len(b.Others) + 1
+	if len(b.Weights) == 0 {
+		w := make([]float64, n)
+		for i := range w {
+			w[i] = 1
+		}
+		return w, nil
+	}
+
+	if len(b.Weights) != n {
+		return nil, errWeightsInvalid
+	}
+
+	for _, w := range b.Weights {
+		if w <= 0 || math.IsNaN(w) || math.IsInf(w, 0) {
+			return nil, errWeightsInvalid
+		}
+	}
+
+	return b.Weights, nil
+}
+
+// Participation describes one participant's part in a blend. Share is the
+// fraction of the final tracks credited to the participant's seeds and
+// Target is the fraction their weight entitles them to.
+type Participation struct {
+	TopArtists int     `json:"top_artists"`
+	Seeds      int     `json:"seeds"`
+	Share      float64 `json:"share"`
+	Target     float64 `json:"target"`
+}
+
+// BlendReport extends a Report with how fairly the blend treated each
+// participant. Fairness is the lowest ratio of share to target, capped at 1,
+// so a blend where everyone got at least their due scores 1.
+type BlendReport struct {
+	Report
+	Participants []Participation `json:"participants"`
+	Fairness     float64         `json:"fairness"`
+}
+
+// BlendTracklist returns a shared discovery tracklist seeded from the top
+// artists of every participant in proportion to their weights.
+func (g generator) BlendTracklist(n int, b Blend) ([]Track, error) {
+	list, _, err := g.BlendTracklistReport(n, b)
+	return list, err
+}
+
+// BlendTracklistReport is like BlendTracklist but also returns a BlendReport.
+// Its embedded Report counts the same stages as the Report of
+// TracklistReport, with the seeds pooled from every participant and no
+// selector applied. Participants and Fairness are only set when the blend
+// succeeds.
+func (g generator) BlendTracklistReport(n int, b Blend) ([]Track, BlendReport, error) {
+	var rep BlendReport
+	if len(b.Others) == 0 {
+		return nil, rep, errBlendTooFew
+	}
+
+	for _, o := range b.Others {
+		if o == nil {
+			return nil, rep, errNilParticipant
+		}
+	}
+
+	weights, err := b.weights()
+	if err != nil {
+		return nil, rep, err
+	}
+
+	servs := append([]MusicService{g.serv}, b.Others...)
+	tops := make([][]Artist, len(servs))
+	owners := make(map[string]int)
+
+	budget := g.seeds
+	if budget == 0 {
+		budget = blendSeeds
+	}
+	// The blend picks its own seeds, so a selector would undo the balance.
+	g.sel = nil
+
+	seeder := func() ([]Seed, error) {
+		for i, s := range servs {
+			top, err := s.TopArtists()
+			if err != nil {
+				return nil, errors.Wrapf(err, "cannot fetch top artists of participant %d", i)
+			}
+			tops[i] = top
+		}
+
+		sds := blendSeedList(tops, quotas(budget, weights), weights)
+		for _, sd := range sds {
+			owners[sd.ID] = sd.owner
+		}
+
+		return artistSeedsOf(sds)
+	}
+
+	known := func() ([]Artist, error) {
+		return familiar(tops, b.Filter), nil
+	}
+
+	g, run := g.begin("BlendTracklist")
+	list, base, err := run.end(g.generate(n, seeder, known))
+	rep.Report = base
+	if err != nil {
+		return nil, rep, err
+	}
+
+	rep.Participants, rep.Fairness = fairness(list, tops, owners, weights)
+
+	return list, rep, nil
+}
+
+type ownedSeed struct {
+	Artist
+	owner int
+}
+
+// quotas splits the seed budget between participants in proportion to their
+// weights, handing out the remainders to the largest fractions.
+func quotas(budget int, weights []float64) []int {
+	var sum float64
+	for _, w := range weights {
+		sum += w
+	}
+
+	q := make([]int, len(weights))
+	rem := make([]float64, len(weights))
+	given := 0
+	for i, w := range weights {
+		exact := float64(budget) * w / sum
+		q[i] = int(math.Floor(exact))
+		rem[i] = exact - float64(q[i])
+		given += q[i]
+	}
+
+	idx := make([]int, len(weights))
+	for i := range idx {
+		idx[i] = i
+	}
+	sort.SliceStable(idx, func(a, b int) bool {
+		return rem[idx[a]] > rem[idx[b]]
+	})
+
+	for i := 0; given < budget; i++ {
+		q[idx[i%len(idx)]]++
+		given++
+	}
+
+	return q
+}
+
+// blendSeedList takes each participant's quota of top artists, skipping
+// artists already taken by another participant, and interleaves them so that
+// every seed group mixes the participants' tastes. Seats a participant cannot
+// fill go to the participants with top artists to spare, in proportion to
+// their weights.
+func blendSeedList(tops [][]Artist, q []int, weights []float64) []ownedSeed {
+	taken := make(map[string]bool)
+	picks := make([][]ownedSeed, len(tops))
+	next := make([]int, len(tops))
+
+	// take picks up to k more untaken artists of participant i and returns
+	// how many it picked.
+	take := func(i int, k int) int {
+		n := 0
+		for n < k && next[i] < len(tops[i]) {
+			a := tops[i][next[i]]
+			next[i]++
+			if taken[a.ID] {
+				continue
+			}
+			taken[a.ID] = true
+			picks[i] = append(picks[i], ownedSeed{Artist: a, owner: i})
+			n++
+		}
+
+		return n
+	}
+
+	left := 0
+	for i := range tops {
+		left += q[i] - take(i, q[i])
+	}
+
+	for left > 0 {
+		var open []int
+		var w []float64
+		for i := range tops {
+			if next[i] < len(tops[i]) {
+				open = append(open, i)
+				w = append(w, weights[i])
+			}
+		}
+
+		if len(open) == 0 {
+			break
+		}
+
+		extra := quotas(left, w)
+		for j, i := range open {
+			left -= take(i, extra[j])
+		}
+	}
+
+	var out []ownedSeed
+	for round := 0; ; round++ {
+		added := false
+		for i := range picks {
+			if round < len(picks[i]) {
+				out = append(out, picks[i][round])
+				added = true
+			}
+		}
+
+		if !added {
+			return out
+		}
+	}
+}
+
+func artistSeedsOf(sds []ownedSeed) ([]Seed, error) {
+	arts := make([]Artist, len(sds))
+	for i, sd := range sds {
+		arts[i] = sd.Artist
+	}
+
+	return artistSeeds(arts)
+}
+
+// familiar returns the artists known to any or to all participants.
+func familiar(tops [][]Artist, f BlendFilter) []Artist {
+	count := make(map[string]int)
+	var order []Artist
+	for _, top := range tops {
+		seen := make(map[string]bool)
+		for _, a := range top {
+			if seen[a.ID] {
+				continue
+			}
+			seen[a.ID] = true
+
+			if count[a.ID] == 0 {
+				order = append(order, a)
+			}
+			count[a.ID]++
+		}
+	}
+
+	if f == KnownToAny {
+		return order
+	}
+
+	var all []Artist
+	for _, a := range order {
+		if count[a.ID] == len(tops) {
+			all = append(all, a)
+		}
+	}
+
+	return all
+}
+
+// fairness credits every final track to the participants whose seeds were
+// in the track's seed group, split evenly between those seeds.
+func fairness(list []Track, tops [][]Artist, owners map[string]int, weights []float64) ([]Participation, float64) {
+	parts := make([]Participation, len(tops))
+	var sum float64
+	for i, w := range weights {
+		sum += w
+		parts[i].TopArtists = len(tops[i])
+	}
+
+	for _, o := range owners {
+		parts[o].Seeds++
+	}
+
+	var credited float64
+	credit := make([]float64, len(tops))
+	for _, t := range list {
+		if t.Provenance == nil {
+			continue
+		}
+
+		var own []int
+		for _, id := range t.Provenance.Artists {
+			if o, ok := owners[id]; ok {
+				own = append(own, o)
+			}
+		}
+
+		for _, o := range own {
+			credit[o] += 1 / float64(len(own))
+		}
+		if len(own) > 0 {
+			credited++
+		}
+	}
+
+	fair := 1.0
+	for i := range parts {
+		parts[i].Target = weights[i] / sum
+		if credited > 0 {
+			parts[i].Share = credit[i] / credited
+		}
+
+		if r := parts[i].Share / parts[i].Target; r < fair {
+			fair = r
+		}
+	}
+
+	return parts, fair
+}
@@ -0,0 +1,243 @@
+package refind
+
+import (
+	"github.com/pkg/errors"
+	"math"
+	"reflect"
+	"testing"
+)
+
+// fakeSeedRecommender recommends one track by a new artist for every seed,
+// crediting it to that seed, followed by its extra tracks.
+type fakeSeedRecommender struct {
+	extra []Track
+}
+
+func (f fakeSeedRecommender) Recommendations(n int, sds []Seed) ([]Track, error) {
+	var list []Track
+	for _, sd := range sds {
+		list = append(list, testBlendTrack(sd.ID))
+	}
+
+	return append(list, f.extra...), nil
+}
+
+func testBlendTrack(id string) Track {
+	return Track{
+		ID:         "t" + id,
+		Artist:     Artist{ID: "r" + id, Name: "r" + id},
+		Provenance: &Provenance{Group: 0, Artists: []string{id}},
+	}
+}
+
+func TestGenerator_BlendTracklist(t *testing.T) {
+	// A track by alpha, whom only the first participant listens to.
+	familiarTrack := Track{ID: "ta2", Artist: testArtistA, Provenance: &Provenance{Group: 1, Artists: []string{"c"}}}
+	rec := fakeSeedRecommender{extra: []Track{familiarTrack}}
+	first := fakeMusicService{artists: []Artist{testArtistA, testArtistB}}
+	second := fakeMusicService{artists: []Artist{testArtistC, testArtistD}}
+
+	tests := []struct {
+		name    string
+		seeds   int
+		blend   Blend
+		want    []Track
+		wantErr error
+	}{
+		{
+			name:    "Known to any participant",
+			blend:   Blend{Others: []MusicService{second}},
+			want:    []Track{testBlendTrack("a"), testBlendTrack("c"), testBlendTrack("b"), testBlendTrack("d")},
+			wantErr: nil,
+		},
+		{
+			name:    "Known to all participants",
+			blend:   Blend{Others: []MusicService{second}, Filter: KnownToAll},
+			want:    []Track{testBlendTrack("a"), testBlendTrack("c"), testBlendTrack("b"), testBlendTrack("d"), familiarTrack},
+			wantErr: nil,
+		},
+		{
+			name:    "Weighted seeds",
+			seeds:   4,
+			blend:   Blend{Others: []MusicService{second}, Weights: []float64{3, 1}},
+			want:    []Track{testBlendTrack("a"), testBlendTrack("c"), testBlendTrack("b"), testBlendTrack("d")},
+			wantErr: nil,
+		},
+		{
+			name:    "No other participants",
+			blend:   Blend{},
+			want:    nil,
+			wantErr: errBlendTooFew,
+		},
+		{
+			name:    "Nil participant",
+			blend:   Blend{Others: []MusicService{nil}},
+			want:    nil,
+			wantErr: errNilParticipant,
+		},
+		{
+			name:    "Weight per participant missing",
+			blend:   Blend{Others: []MusicService{second}, Weights: []float64{1}},
+			want:    nil,
+			wantErr: errWeightsInvalid,
+		},
+		{
+			name:    "Participant error",
+			blend:   Blend{Others: []MusicService{fakeMusicService{artistErr: testErrFetchArtists}}},
+			want:    nil,
+			wantErr: testErrFetchArtists,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			g := &generator{serv: first, rec: rec}
+			if test.seeds > 0 {
+				g.SetSelector(test.seeds, RecencyWeighted())
+			}
+
+			got, err := g.BlendTracklist(10, test.blend)
+			if errors.Cause(err) != test.wantErr {
+				t.Errorf("got: <%v>, want: <%v>", errors.Cause(err), test.wantErr)
+			}
+
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("got: <%v>, want: <%v>", got, test.want)
+			}
+		})
+	}
+}
+
+func TestGenerator_BlendTracklistReport(t *testing.T) {
+	first := fakeMusicService{artists: []Artist{testArtistA, testArtistB}}
+	second := fakeMusicService{artists: []Artist{testArtistC, testArtistD}}
+
+	tests := []struct {
+		name         string
+		seeds        int
+		weights      []float64
+		wantParts    []Participation
+		wantFairness float64
+	}{
+		{
+			name:  "Equal weights",
+			seeds: 4,
+			wantParts: []Participation{
+				{TopArtists: 2, Seeds: 2, Share: 0.5, Target: 0.5},
+				{TopArtists: 2, Seeds: 2, Share: 0.5, Target: 0.5},
+			},
+			wantFairness: 1,
+		},
+		{
+			name:    "Weighted beyond what a participant can seed",
+			seeds:   4,
+			weights: []float64{3, 1},
+			wantParts: []Participation{
+				{TopArtists: 2, Seeds: 2, Share: 0.5, Target: 0.75},
+				{TopArtists: 2, Seeds: 2, Share: 0.5, Target: 0.25},
+			},
+			wantFairness: 0.5 / 0.75,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			g := &generator{serv: first, rec: fakeSeedRecommender{}}
+			g.SetSelector(test.seeds, RecencyWeighted())
+
+			_, rep, err := g.BlendTracklistReport(10, Blend{Others: []MusicService{second}, Weights: test.weights})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !reflect.DeepEqual(rep.Participants, test.wantParts) {
+				t.Errorf("got: <%v>, want: <%v>", rep.Participants, test.wantParts)
+			}
+
+			if math.Abs(rep.Fairness-test.wantFairness) > 1e-9 {
+				t.Errorf("got: <%v>, want: <%v>", rep.Fairness, test.wantFairness)
+			}
+		})
+	}
+}
+
+func TestQuotas(t *testing.T) {
+	tests := []struct {
+		name    string
+		budget  int
+		weights []float64
+		want    []int
+	}{
+		{"Even split", 10, []float64{1, 1}, []int{5, 5}},
+		{"Remainder to first", 25, []float64{1, 1}, []int{13, 12}},
+		{"Weighted", 20, []float64{3, 1}, []int{15, 5}},
+		{"Largest remainder", 10, []float64{1, 1, 1}, []int{4, 3, 3}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := quotas(test.budget, test.weights)
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("got: <%v>, want: <%v>", got, test.want)
+			}
+		})
+	}
+}
+
+func TestBlendSeedList(t *testing.T) {
+	e := Artist{ID: "e", Name: "echo"}
+	f := Artist{ID: "f", Name: "foxtrot"}
+
+	tests := []struct {
+		name    string
+		tops    [][]Artist
+		quotas  []int
+		weights []float64
+		want    []string
+	}{
+		{
+			name:    "Quotas filled",
+			tops:    [][]Artist{{testArtistA, testArtistB}, {testArtistC, testArtistD}},
+			quotas:  []int{1, 1},
+			weights: []float64{1, 1},
+			want:    []string{"a", "c"},
+		},
+		{
+			name:    "Unused seats reassigned",
+			tops:    [][]Artist{{testArtistA}, {testArtistC, testArtistD, e}},
+			quotas:  []int{2, 1},
+			weights: []float64{1, 1},
+			want:    []string{"a", "c", "d"},
+		},
+		{
+			name:    "Shared artists leave seats to reassign",
+			tops:    [][]Artist{{testArtistA, testArtistB}, {testArtistA, testArtistB, testArtistC}},
+			quotas:  []int{2, 2},
+			weights: []float64{1, 1},
+			want:    []string{"a", "c", "b"},
+		},
+		{
+			name:    "Unused seats reassigned by weight",
+			tops:    [][]Artist{{testArtistA}, {testArtistB, testArtistC, testArtistD}, {e, f}},
+			quotas:  []int{4, 1, 1},
+			weights: []float64{1, 2, 1},
+			want:    []string{"a", "b", "e", "c", "f", "d"},
+		},
+		{
+			name:    "Too few artists for the seats",
+			tops:    [][]Artist{{testArtistA}, {testArtistB}},
+			quotas:  []int{3, 3},
+			weights: []float64{1, 1},
+			want:    []string{"a", "b"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var got []string
+			for _, sd := range blendSeedList(test.tops, test.quotas, test.weights) {
+				got = append(got, sd.ID)
+			}
+
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("got: <%v>, want: <%v>", got, test.want)
+			}
+		})
+	}
+}
@@ -5,12 +5,19 @@ import (
 	"github.com/pkg/errors"
 )
 
-var errNilBuf = errors.New("cannot initialize new buffer using nil interface")
+var (
+	errNilBuf     = errors.New("cannot initialize new buffer using nil interface")
+	errNilMetrics = errors.New("cannot use nil metrics")
+)
 
+// buffer caches the responses of a single user's MusicService. It is not
+// safe for concurrent use.
 type buffer struct {
-	serv refind.MusicService
+	serv    refind.MusicService
 	artists []refind.Artist
-	tracks []refind.Track
+	top     []refind.Track
+	tracks  []refind.Track
+	met     refind.Metrics
 }
 
 func New(serv refind.MusicService) (*buffer, error) {
@@ -20,7 +27,31 @@ func New(serv refind.MusicService) (*buffer, error) {
 	return &buffer{serv: serv}, nil
 }
 
-func (b buffer) TopArtists() ([]refind.Artist, error) {
+// SetMetrics makes the buffer count its hits and misses so that its hit
+// ratio can be monitored.
+func (b *buffer) SetMetrics(m refind.Metrics) error {
+	if m == nil {
+		return errNilMetrics
+	}
+
+	b.met = m
+	return nil
+}
+
+func (b *buffer) count(method string, hit bool) {
+	if b.met == nil {
+		return
+	}
+
+	result := "miss"
+	if hit {
+		result = "hit"
+	}
+	b.met.Inc(refind.MetricBufferRequests, "method", method, "result", result)
+}
+
+func (b *buffer) TopArtists() ([]refind.Artist, error) {
+	b.count("TopArtists", len(b.artists) > 0)
 	if len(b.artists) > 0 {
 		return b.artists, nil
 	}
@@ -29,11 +60,28 @@ func (b buffer) TopArtists() ([]refind.Artist, error) {
 	if err != nil {
 		return nil, err
 	}
+	b.artists = top
 
 	return top, nil
 }
 
-func (b buffer) RecentTracks() ([]refind.Track, error) {
+func (b *buffer) TopTracks() ([]refind.Track, error) {
+	b.count("TopTracks", len(b.top) > 0)
+	if len(b.top) > 0 {
+		return b.top, nil
+	}
+
+	top, err := b.serv.TopTracks()
+	if err != nil {
+		return nil, err
+	}
+	b.top = top
+
+	return top, nil
+}
+
+func (b *buffer) RecentTracks() ([]refind.Track, error) {
+	b.count("RecentTracks", len(b.tracks) > 0)
 	if len(b.tracks) > 0 {
 		return b.tracks, nil
 	}
@@ -42,6 +90,41 @@ func (b buffer) RecentTracks() ([]refind.Track, error) {
 	if err != nil {
 		return nil, err
 	}
+	b.tracks = rec
 
 	return rec, nil
 }
+
+// Reset empties the buffer so that the next calls fetch fresh responses.
+func (b *buffer) Reset() {
+	b.artists = nil
+	b.top = nil
+	b.tracks = nil
+}
+
+func (b *buffer) TopArtistsRange(r refind.TimeRange, limit int) ([]refind.Artist, error) {
+	rs, ok := b.serv.(refind.RangedMusicService)
+	if !ok {
+		return nil, refind.ErrNoRanges
+	}
+
+	return rs.TopArtistsRange(r, limit)
+}
+
+func (b *buffer) TopTracksRange(r refind.TimeRange, limit int) ([]refind.Track, error) {
+	rs, ok := b.serv.(refind.RangedMusicService)
+	if !ok {
+		return nil, refind.ErrNoRanges
+	}
+
+	return rs.TopTracksRange(r, limit)
+}
+
+func (b *buffer) RelatedArtists(id string) ([]refind.Artist, error) {
+	rs, ok := b.serv.(refind.RelatedArtistService)
+	if !ok {
+		return nil, refind.ErrNoRelated
+	}
+
+	return rs.RelatedArtists(id)
+}
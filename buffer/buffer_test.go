@@ -4,6 +4,7 @@ import (
 	"github.com/Henry-Sarabia/refind"
 	"github.com/pkg/errors"
 	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -27,6 +28,8 @@ var testTracks = []refind.Track{
 type fakeMusicService struct {
 	artists []refind.Artist
 	artistErr error
+	top []refind.Track
+	topErr error
 	tracks []refind.Track
 	trackErr error
 }
@@ -35,6 +38,10 @@ func (f fakeMusicService) TopArtists() ([]refind.Artist, error) {
 	return f.artists, f.artistErr
 }
 
+func (f fakeMusicService) TopTracks() ([]refind.Track, error) {
+	return f.top, f.topErr
+}
+
 func (f fakeMusicService) RecentTracks() ([]refind.Track, error) {
 	return f.tracks, f.trackErr
 }
@@ -191,6 +198,76 @@ func TestBuffer_TopArtists(t *testing.T) {
 	}
 }
 
+func TestBuffer_TopTracks(t *testing.T) {
+	tests := []struct {
+		name string
+		buf buffer
+		wantTracks []refind.Track
+		wantErr error
+	}{
+		{
+			name: "Valid response, nil buffer",
+			buf: buffer{
+				serv: fakeMusicService{
+					top: testTracks,
+					topErr: nil,
+				},
+				top: nil,
+			},
+			wantTracks: testTracks,
+			wantErr: nil,
+		},
+		{
+			name: "Valid response, multiple track buffer",
+			buf: buffer{
+				serv: fakeMusicService{
+					top: testTracks,
+					topErr: nil,
+				},
+				top: testTracks[:1],
+			},
+			wantTracks: testTracks[:1],
+			wantErr: nil,
+		},
+		{
+			name: "Invalid response, nil buffer",
+			buf: buffer{
+				serv: fakeMusicService{
+					top: nil,
+					topErr: testErrTracks,
+				},
+				top: nil,
+			},
+			wantTracks: nil,
+			wantErr: testErrTracks,
+		},
+		{
+			name: "Invalid response, multiple track buffer",
+			buf: buffer{
+				serv: fakeMusicService{
+					top: nil,
+					topErr: testErrTracks,
+				},
+				top: testTracks,
+			},
+			wantTracks: testTracks,
+			wantErr: nil,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := test.buf.TopTracks()
+			if err != test.wantErr {
+				t.Errorf("got: <%v>, want: <%v>", err, test.wantErr)
+			}
+
+			if !reflect.DeepEqual(got, test.wantTracks) {
+				t.Errorf("got: <%v>, want: <%v>", got, test.wantTracks)
+			}
+		})
+	}
+}
+
 func TestBuffer_RecentTracks(t *testing.T) {
 	tests := []struct {
 		name string
@@ -308,3 +385,110 @@ func TestBuffer_RecentTracks(t *testing.T) {
 		})
 	}
 }
+
+type countingMusicService struct {
+	fakeMusicService
+	calls *int
+}
+
+func (c countingMusicService) TopArtists() ([]refind.Artist, error) {
+	*c.calls++
+	return c.fakeMusicService.TopArtists()
+}
+
+func TestBuffer_Caching(t *testing.T) {
+	var calls int
+	buf, err := New(countingMusicService{fakeMusicService: fakeMusicService{artists: testArtists}, calls: &calls})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 3; i++ {
+		if _, err := buf.TopArtists(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if calls != 1 {
+		t.Errorf("got: <%v>, want: <%v>", calls, 1)
+	}
+
+	buf.Reset()
+	got, err := buf.TopArtists()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if calls != 2 {
+		t.Errorf("got: <%v>, want: <%v>", calls, 2)
+	}
+
+	if !reflect.DeepEqual(got, testArtists) {
+		t.Errorf("got: <%v>, want: <%v>", got, testArtists)
+	}
+}
+
+type countingMetrics struct {
+	counts map[string]int
+}
+
+func (c countingMetrics) Inc(name string, labels ...string) {
+	c.counts[name+strings.Join(labels, ",")]++
+}
+
+func (c countingMetrics) Observe(string, float64, ...string) {}
+
+func TestBuffer_Metrics(t *testing.T) {
+	buf, err := New(fakeMusicService{artists: testArtists})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := buf.SetMetrics(nil); err != errNilMetrics {
+		t.Errorf("got: <%v>, want: <%v>", err, errNilMetrics)
+	}
+
+	met := countingMetrics{counts: make(map[string]int)}
+	if err := buf.SetMetrics(met); err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 3; i++ {
+		if _, err := buf.TopArtists(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	want := map[string]int{
+		refind.MetricBufferRequests + "method,TopArtists,result,miss": 1,
+		refind.MetricBufferRequests + "method,TopArtists,result,hit":  2,
+	}
+	if !reflect.DeepEqual(met.counts, want) {
+		t.Errorf("got: <%v>, want: <%v>", met.counts, want)
+	}
+}
+
+func TestBuffer_Errors(t *testing.T) {
+	expired := &refind.APIError{Endpoint: "GET /me/top/artists", Status: 401, Message: "The access token expired"}
+	serv := fakeMusicService{
+		artistErr: errors.Wrap(expired, "cannot fetch top artists"),
+		trackErr:  refind.ErrNoHistory,
+	}
+
+	buf, err := New(serv)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := buf.TopArtists(); !errors.Is(err, refind.ErrUnauthorized) {
+		t.Errorf("got: <%v>, want: <%v>", err, refind.ErrUnauthorized)
+	}
+
+	if _, err := buf.RecentTracks(); !errors.Is(err, refind.ErrNoHistory) {
+		t.Errorf("got: <%v>, want: <%v>", err, refind.ErrNoHistory)
+	}
+
+	if _, err := buf.TopArtistsRange(refind.LongTerm, 10); !errors.Is(err, refind.ErrNoRanges) {
+		t.Errorf("got: <%v>, want: <%v>", err, refind.ErrNoRanges)
+	}
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"github.com/Henry-Sarabia/refind/spotify"
+	"github.com/pkg/errors"
+	api "github.com/zmb3/spotify"
+	"net/http"
+	"net/url"
+)
+
+const stateBytes int = 16
+
+// login runs the OAuth flow by serving the redirect URI locally until the
+// user authorizes the application in their browser.
+func login(redirect string) (*api.Client, error) {
+	auth, err := spotify.Authenticator(redirect)
+	if err != nil {
+		return nil, err
+	}
+
+	u, err := url.Parse(redirect)
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot parse redirect URI")
+	}
+
+	state, err := randomState()
+	if err != nil {
+		return nil, err
+	}
+
+	clients := make(chan *api.Client, 1)
+	errs := make(chan error, 1)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc(u.Path, func(w http.ResponseWriter, r *http.Request) {
+		tok, err := auth.Token(state, r)
+		if err != nil {
+			http.Error(w, "cannot get token", http.StatusForbidden)
+			errs <- errors.Wrap(err, "cannot get token")
+			return
+		}
+
+		fmt.Fprintln(w, "Login complete, you may close this window.")
+		c := auth.NewClient(tok)
+		clients <- &c
+	})
+
+	srv := &http.Server{Addr: u.Host, Handler: mux}
+	go func() {
+		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			errs <- errors.Wrap(err, "cannot serve redirect URI")
+		}
+	}()
+	defer srv.Close()
+
+	fmt.Println("Log in to Spotify by visiting:", auth.AuthURL(state))
+
+	select {
+	case c := <-clients:
+		return c, nil
+	case err := <-errs:
+		return nil, err
+	}
+}
+
+func randomState() (string, error) {
+	b := make([]byte, stateBytes)
+	if _, err := rand.Read(b); err != nil {
+		return "", errors.Wrap(err, "cannot generate state")
+	}
+
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"flag"
+	"github.com/Henry-Sarabia/refind"
+	"github.com/Henry-Sarabia/refind/config"
+	"github.com/Henry-Sarabia/refind/spotify"
+	"os"
+	"strings"
+	"time"
+)
+
+// loadConfig registers the -config flag on fs and loads the configuration it
+// names, or the one named by REFIND_CONFIG, before the other flags are
+// parsed so that the configuration supplies their defaults.
+func loadConfig(fs *flag.FlagSet, args []string) (config.Config, error) {
+	fs.String("config", "", "JSON configuration file whose values become the flag defaults (default $REFIND_CONFIG)")
+
+	name := os.Getenv(config.EnvPrefix + "CONFIG")
+	for i, a := range args {
+		a = strings.TrimLeft(a, "-")
+		switch {
+		case a == "config" && i+1 < len(args):
+			name = args[i+1]
+		case strings.HasPrefix(a, "config="):
+			name = strings.TrimPrefix(a, "config=")
+		}
+	}
+
+	return config.Load(name)
+}
+
+// spotifyOptions returns the options of a Spotify service that apply the
+// configured parameters and retry policy and log to l.
+func spotifyOptions(cfg config.Spotify, l refind.Logger) ([]spotify.Option, error) {
+	par, err := cfg.Params()
+	if err != nil {
+		return nil, err
+	}
+
+	return []spotify.Option{
+		spotify.WithParams(par),
+		spotify.WithRetry(cfg.Retry()),
+		spotify.WithRand(time.Now().UnixNano()),
+		spotify.WithLogger(l),
+	}, nil
+}
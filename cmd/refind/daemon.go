@@ -0,0 +1,134 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"github.com/Henry-Sarabia/refind"
+	"github.com/Henry-Sarabia/refind/daemon"
+	"github.com/Henry-Sarabia/refind/server"
+	"github.com/Henry-Sarabia/refind/spotify"
+	"github.com/pkg/errors"
+	api "github.com/zmb3/spotify"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+var errScheduleMissing = errors.New("daemon needs a -schedule and a -tokens file")
+
+// playlistRunner regenerates a job's playlist with the token stored under the
+// job's user.
+type playlistRunner struct {
+	auth  *api.Authenticator
+	store server.TokenStore
+	opts  []spotify.Option
+	gen   []refind.Option
+}
+
+func (p playlistRunner) Run(ctx context.Context, j daemon.Job) error {
+	tok, err := p.store.Token(j.User)
+	if err != nil {
+		return errors.Wrapf(err, "cannot load token of %s", j.User)
+	}
+
+	c := p.auth.NewClient(tok)
+	opts := append([]spotify.Option{spotify.WithCancel(ctx)}, p.opts...)
+	s, err := spotify.New(&c, opts...)
+	if err != nil {
+		return err
+	}
+
+	list, _, err := j.Settings.Tracklist(s, s, p.gen...)
+	if err != nil {
+		return err
+	}
+
+	if err := s.ReplacePlaylist(j.Playlist, list); err != nil {
+		return err
+	}
+
+	// Keep a refreshed token for the next run.
+	if t, err := c.Token(); err == nil && t.AccessToken != tok.AccessToken {
+		return p.store.SetToken(j.User, t)
+	}
+
+	return nil
+}
+
+func runDaemon(args []string) error {
+	fs := flag.NewFlagSet("daemon", flag.ExitOnError)
+	cfg, err := loadConfig(fs, args)
+	if err != nil {
+		return err
+	}
+	schedule := fs.String("schedule", cfg.Daemon.Schedule, "JSON file of jobs, each with a name, cron, user, playlist ID and optional settings")
+	tokens := fs.String("tokens", cfg.Daemon.Tokens, "JSON token file written by serve, keyed by the user of each job; serve forgets tokens of sessions idle past its -session-ttl")
+	state := fs.String("state", cfg.Daemon.State, "file to keep job state in between restarts")
+	jitter := fs.Duration("jitter", time.Duration(cfg.Daemon.Jitter), "maximum random delay before each job")
+	concurrency := fs.Int("concurrency", cfg.Daemon.Concurrency, "maximum number of jobs running at once")
+	redirect := fs.String("redirect", cfg.Spotify.Redirect, "OAuth redirect URI of the application")
+	logLevel := fs.String("log-level", cfg.LogLevel, "log records of at least this level to standard error: debug, info, warn or error")
+	fs.Parse(args)
+
+	logger, err := newLogger(*logLevel)
+	if err != nil {
+		return err
+	}
+
+	if *schedule == "" || *tokens == "" {
+		return errScheduleMissing
+	}
+
+	f, err := os.Open(*schedule)
+	if err != nil {
+		return errors.Wrap(err, "cannot open schedule")
+	}
+	sched, err := daemon.LoadSchedule(f)
+	f.Close()
+	if err != nil {
+		return err
+	}
+
+	store, err := server.NewFileStore(*tokens)
+	if err != nil {
+		return err
+	}
+
+	auth, err := spotify.Authenticator(*redirect)
+	if err != nil {
+		return err
+	}
+
+	opts, err := spotifyOptions(cfg.Spotify, logger)
+	if err != nil {
+		return err
+	}
+
+	d, err := daemon.New(sched, playlistRunner{auth: auth, store: store, opts: opts, gen: []refind.Option{refind.WithLogger(logger)}}, *state)
+	if err != nil {
+		return err
+	}
+
+	if err := d.SetLogger(logger); err != nil {
+		return err
+	}
+
+	if err := d.SetJitter(*jitter); err != nil {
+		return err
+	}
+
+	if err := d.SetConcurrency(*concurrency); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-sig
+		cancel()
+	}()
+
+	return d.Run(ctx)
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"github.com/Henry-Sarabia/refind"
+	"io"
+	"os"
+	"sort"
+	"strings"
+)
+
+func explain(args []string) error {
+	var opt options
+	fs := flag.NewFlagSet("explain", flag.ExitOnError)
+	cfg, err := loadConfig(fs, args)
+	if err != nil {
+		return err
+	}
+	opt.register(fs, cfg)
+	fs.Parse(args)
+
+	r, err := newRunner(opt)
+	if err != nil {
+		return err
+	}
+
+	list, _, err := r.tracklist()
+	if err != nil {
+		return err
+	}
+
+	printExplanations(os.Stdout, refind.Explain(list))
+	return nil
+}
+
+func printExplanations(w io.Writer, exps []refind.Explanation) {
+	for _, e := range exps {
+		p := e.Provenance
+		if p == nil {
+			fmt.Fprintln(w, "Unattributed")
+		} else {
+			fmt.Fprintf(w, "Seed group %d\n", p.Group+1)
+			printField(w, "tracks", p.Tracks)
+			printField(w, "artists", p.Artists)
+			printField(w, "genres", p.Genres)
+			printField(w, "tuning", attributes(p.Attributes))
+		}
+
+		for _, t := range e.Tracks {
+			fmt.Fprintf(w, "    %s - %s\n", t.Artist.Name, t.Name)
+		}
+		fmt.Fprintln(w)
+	}
+}
+
+func printField(w io.Writer, name string, vals []string) {
+	if len(vals) == 0 {
+		return
+	}
+
+	fmt.Fprintf(w, "  %-8s %s\n", name+":", strings.Join(vals, ", "))
+}
+
+func attributes(attr map[string]float64) []string {
+	var vals []string
+	for k, v := range attr {
+		vals = append(vals, fmt.Sprintf("%s=%g", k, v))
+	}
+	sort.Strings(vals)
+
+	return vals
+}
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"github.com/Henry-Sarabia/refind"
+	"github.com/Henry-Sarabia/refind/config"
+	"github.com/pkg/errors"
+	"io"
+	"os"
+	"strings"
+)
+
+const artistSpacing int = 2
+
+var (
+	errModeInvalid  = errors.New("mode must be one of full, limited, top or radar")
+	errOrderInvalid = errors.New("order must be one of energy, harmonic, tempo or shuffle")
+)
+
+type options struct {
+	n        int
+	mode     string
+	redirect string
+	seeds    int
+	rand     int64
+	record   string
+	replay   string
+	order    string
+	preset   string
+	presets  string
+	novelty  float64
+
+	recommender string
+	depth       int
+	walk        bool
+	graphCache  string
+
+	weeks int
+	types string
+
+	logLevel string
+	cfg      config.Config
+}
+
+func (o *options) register(fs *flag.FlagSet, cfg config.Config) {
+	o.cfg = cfg
+	gen := cfg.Generate
+	novelty := -1.0
+	if gen.Novelty != nil {
+		novelty = *gen.Novelty
+	}
+
+	fs.IntVar(&o.n, "n", gen.Total, "number of tracks to generate")
+	fs.StringVar(&o.mode, "mode", gen.Mode, "seed source: full, limited, top or radar (new releases by related artists)")
+	fs.StringVar(&o.redirect, "redirect", cfg.Spotify.Redirect, "OAuth redirect URI")
+	fs.IntVar(&o.seeds, "seeds", gen.Seeds, "randomly sample at most this many seeds (0 uses every seed)")
+	fs.Int64Var(&o.rand, "rand", 0, "random seed for sampling (0 picks one from the clock)")
+	fs.StringVar(&o.record, "record", "", "record every service response of the run to this file")
+	fs.StringVar(&o.replay, "replay", "", "replay service responses from a recorded file instead of calling Spotify")
+	fs.StringVar(&o.preset, "preset", gen.Preset, "mood or activity preset: focus, workout, chill, party or one from -presets")
+	fs.StringVar(&o.presets, "presets", gen.Presets, "JSON file of additional or replacement presets")
+	fs.Float64Var(&o.novelty, "novelty", novelty, "how far from known taste to go, from 0 to 1 (default keeps the original popularity bounds)")
+	fs.StringVar(&o.recommender, "recommender", gen.Recommender, "recommendation source: spotify or graph (related artists, best with -mode limited)")
+	fs.IntVar(&o.depth, "depth", gen.Depth, "maximum related artist hops for the graph recommender")
+	fs.BoolVar(&o.walk, "walk", false, "explore the related artists graph with random walks instead of breadth first")
+	fs.StringVar(&o.graphCache, "graph-cache", gen.GraphCache, "file to cache the related artists graph in between runs")
+	fs.IntVar(&o.weeks, "weeks", gen.Weeks, "how many weeks back the radar mode looks for releases")
+	fs.StringVar(&o.types, "types", strings.Join(gen.Types, ","), "comma separated release types for the radar mode: album, single or compilation (default all)")
+	fs.StringVar(&o.order, "order", gen.Order, "track order: energy, harmonic, tempo or shuffle (default keeps recommendation order)")
+	fs.StringVar(&o.logLevel, "log-level", cfg.LogLevel, "log records of at least this level to standard error: debug, info, warn or error")
+}
+
+type tracklister interface {
+	TracklistReport(int) ([]refind.Track, refind.Report, error)
+	LimitedTracklistReport(int) ([]refind.Track, refind.Report, error)
+	TopTracklistReport(int) ([]refind.Track, refind.Report, error)
+	RadarTracklistReport(int, refind.Radar) ([]refind.Track, refind.Report, error)
+}
+
+func tracklist(gen tracklister, opt options) ([]refind.Track, refind.Report, error) {
+	switch opt.mode {
+	case "full":
+		return gen.TracklistReport(opt.n)
+	case "limited":
+		return gen.LimitedTracklistReport(opt.n)
+	case "top":
+		return gen.TopTracklistReport(opt.n)
+	case "radar":
+		types, err := config.ReleaseTypes(config.SplitList(opt.types))
+		if err != nil {
+			return nil, refind.Report{}, err
+		}
+		return gen.RadarTracklistReport(opt.n, refind.Radar{Weeks: opt.weeks, Types: types})
+	default:
+		return nil, refind.Report{}, errModeInvalid
+	}
+}
+
+func orderer(name string, seed int64) (refind.Orderer, bool, error) {
+	switch name {
+	case "energy":
+		return refind.EnergyArc(), true, nil
+	case "harmonic":
+		return refind.Harmonic(), true, nil
+	case "tempo":
+		return refind.TempoSmooth(), true, nil
+	case "shuffle":
+		return refind.Shuffle(seed, artistSpacing), false, nil
+	default:
+		return nil, false, errOrderInvalid
+	}
+}
+
+func generate(args []string) error {
+	var opt options
+	fs := flag.NewFlagSet("generate", flag.ExitOnError)
+	cfg, err := loadConfig(fs, args)
+	if err != nil {
+		return err
+	}
+	opt.register(fs, cfg)
+	name := fs.String("playlist", "", "save the tracklist as a playlist with this name")
+	fs.Parse(args)
+
+	if *name != "" && opt.replay != "" {
+		return errReplayPlaylist
+	}
+
+	r, err := newRunner(opt)
+	if err != nil {
+		return err
+	}
+
+	list, _, err := r.tracklist()
+	if err != nil {
+		return err
+	}
+
+	printTracks(os.Stdout, list)
+
+	if *name == "" {
+		return nil
+	}
+
+	pl, err := r.play.Playlist(*name, cfg.Spotify.PlaylistInfo, list)
+	if err != nil {
+		return err
+	}
+
+	fmt.Println("Saved playlist:", pl.ExternalURLs["spotify"])
+	return nil
+}
+
+func printTracks(w io.Writer, list []refind.Track) {
+	for _, t := range list {
+		fmt.Fprintf(w, "%s - %s\n", t.Artist.Name, t.Name)
+	}
+}
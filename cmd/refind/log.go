@@ -0,0 +1,20 @@
+package main
+
+import (
+	"github.com/pkg/errors"
+	"log/slog"
+	"os"
+)
+
+var errLogLevel = errors.New("log level must be one of debug, info, warn or error")
+
+// newLogger returns a logger that writes records of at least the given level
+// to standard error.
+func newLogger(level string) (*slog.Logger, error) {
+	var l slog.Level
+	if err := l.UnmarshalText([]byte(level)); err != nil {
+		return nil, errors.Wrap(errLogLevel, level)
+	}
+
+	return slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: l})), nil
+}
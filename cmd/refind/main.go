@@ -0,0 +1,49 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+)
+
+const usage = `usage: refind <command> [flags]
+
+commands:
+  generate  generate a tracklist and optionally save it as a playlist
+  explain   generate a tracklist grouped by the seeds that produced it
+  report    generate a tracklist and print it with stage statistics as JSON
+  serve     serve tracklist generation over HTTP for any user who logs in
+  daemon    regenerate playlists on a cron schedule
+
+Run "refind <command> -h" for the flags of a command.
+`
+
+func main() {
+	log.SetFlags(0)
+
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	var err error
+	switch os.Args[1] {
+	case "generate":
+		err = generate(os.Args[2:])
+	case "explain":
+		err = explain(os.Args[2:])
+	case "report":
+		err = report(os.Args[2:])
+	case "serve":
+		err = serve(os.Args[2:])
+	case "daemon":
+		err = runDaemon(os.Args[2:])
+	default:
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	if err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"github.com/Henry-Sarabia/refind"
+	"os"
+)
+
+type reportOutput struct {
+	Report refind.Report `json:"report"`
+	Tracks []reportTrack `json:"tracks"`
+}
+
+type reportTrack struct {
+	ID     string `json:"id"`
+	Name   string `json:"name"`
+	Artist string `json:"artist"`
+	URI    string `json:"uri,omitempty"`
+}
+
+func report(args []string) error {
+	var opt options
+	fs := flag.NewFlagSet("report", flag.ExitOnError)
+	cfg, err := loadConfig(fs, args)
+	if err != nil {
+		return err
+	}
+	opt.register(fs, cfg)
+	fs.Parse(args)
+
+	r, err := newRunner(opt)
+	if err != nil {
+		return err
+	}
+
+	list, rep, err := r.tracklist()
+	if err != nil {
+		return err
+	}
+
+	out := reportOutput{Report: rep, Tracks: []reportTrack{}}
+	for _, t := range list {
+		out.Tracks = append(out.Tracks, reportTrack{ID: t.ID, Name: t.Name, Artist: t.Artist.Name, URI: t.URI})
+	}
+
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(out)
+}
@@ -0,0 +1,264 @@
+package main
+
+import (
+	"github.com/Henry-Sarabia/refind"
+	"github.com/Henry-Sarabia/refind/buffer"
+	"github.com/Henry-Sarabia/refind/graph"
+	"github.com/Henry-Sarabia/refind/replay"
+	"github.com/Henry-Sarabia/refind/spotify"
+	"github.com/pkg/errors"
+	api "github.com/zmb3/spotify"
+	"os"
+	"time"
+)
+
+var (
+	errReplayPlaylist     = errors.New("cannot save a playlist while replaying a recorded session")
+	errRecommenderInvalid = errors.New("recommender must be one of spotify or graph")
+)
+
+type playlister interface {
+	Playlist(string, string, []refind.Track) (*api.FullPlaylist, error)
+}
+
+// runner holds everything a command needs to generate a tracklist. play is
+// nil when the tracklist is replayed from a recorded session.
+type runner struct {
+	gen  tracklister
+	play playlister
+	rec  recorder
+	opt  options
+	done []func() error
+}
+
+type recorder interface {
+	Session() replay.Session
+}
+
+type player interface {
+	refind.MusicService
+	refind.Recommender
+	Seed() int64
+}
+
+// newRunner builds a generator from the options, either backed by Spotify
+// or by a recorded session, and wraps it in a recorder when requested.
+func newRunner(opt options) (*runner, error) {
+	logger, err := newLogger(opt.logLevel)
+	if err != nil {
+		return nil, err
+	}
+
+	var serv refind.MusicService
+	var rec refind.Recommender
+	var play playlister
+	var src graph.Source
+	seed := opt.rand
+
+	if opt.replay != "" {
+		p, err := loadPlayer(opt.replay)
+		if err != nil {
+			return nil, err
+		}
+		serv, rec = p, p
+		seed = p.Seed()
+	} else {
+		c, err := login(opt.redirect)
+		if err != nil {
+			return nil, err
+		}
+
+		opts, err := spotifyOptions(opt.cfg.Spotify, logger)
+		if err != nil {
+			return nil, err
+		}
+
+		s, err := spotify.New(c, opts...)
+		if err != nil {
+			return nil, err
+		}
+		serv, rec, play, src = s, s, s, s
+	}
+
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+
+	r := &runner{play: play, opt: opt}
+	switch {
+	case opt.recommender == "graph" && src != nil:
+		buf, err := buffer.New(serv)
+		if err != nil {
+			return nil, err
+		}
+		serv = buf
+
+		g, err := r.graph(src, buf, seed)
+		if err != nil {
+			return nil, err
+		}
+		rec = g
+	case opt.recommender != "spotify" && opt.recommender != "graph":
+		return nil, errRecommenderInvalid
+	}
+	if opt.record != "" {
+		rc, err := replay.NewRecorder(serv, rec, seed)
+		if err != nil {
+			return nil, err
+		}
+		serv, rec, r.rec = rc, rc, rc
+	}
+
+	gen, err := refind.New(serv, rec, refind.WithLogger(logger))
+	if err != nil {
+		return nil, err
+	}
+
+	if opt.seeds > 0 {
+		if err := gen.SetSelector(opt.seeds, refind.RandomSampling(seed)); err != nil {
+			return nil, err
+		}
+	}
+	if opt.preset != "" {
+		t, err := preset(opt.presets, opt.preset)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := gen.SetTuning(t); err != nil {
+			return nil, err
+		}
+	}
+
+	if opt.novelty >= 0 {
+		if err := gen.SetNovelty(opt.novelty); err != nil {
+			return nil, err
+		}
+	}
+
+	if opt.order != "" {
+		ord, feats, err := orderer(opt.order, seed)
+		if err != nil {
+			return nil, err
+		}
+
+		var fs refind.FeatureService
+		if feats {
+			fs, _ = serv.(refind.FeatureService)
+		}
+
+		if err := gen.SetOrderer(ord, fs); err != nil {
+			return nil, err
+		}
+	}
+	r.gen = gen
+
+	return r, nil
+}
+
+// graph builds a related artists graph recommender on top of src, caching
+// the graph in a file when requested. The walk skips the top artists of
+// serv and starts from the artists of track seeds when src can look them up.
+func (r *runner) graph(src graph.Source, serv refind.MusicService, seed int64) (refind.Recommender, error) {
+	ts, _ := src.(graph.TrackSource)
+	if r.opt.graphCache != "" {
+		c, err := graph.LoadCacheFile(r.opt.graphCache)
+		if err != nil {
+			return nil, err
+		}
+		src = graph.Cached(src, c)
+
+		name := r.opt.graphCache
+		r.done = append(r.done, func() error {
+			return c.SaveFile(name)
+		})
+	}
+
+	g, err := graph.New(src, r.opt.depth)
+	if err != nil {
+		return nil, err
+	}
+
+	if r.opt.walk {
+		g.SetRandomWalk(seed)
+	}
+
+	if ts != nil {
+		g.SetTrackSource(ts)
+	}
+
+	top, err := serv.TopArtists()
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot fetch known artists")
+	}
+	g.SetKnown(top)
+
+	return g, nil
+}
+
+func (r *runner) tracklist() ([]refind.Track, refind.Report, error) {
+	list, rep, err := tracklist(r.gen, r.opt)
+	if r.rec != nil {
+		name := r.opt.record
+		sess := r.rec.Session()
+		r.done = append(r.done, func() error {
+			return saveSession(name, sess)
+		})
+	}
+
+	for _, fn := range r.done {
+		if derr := fn(); derr != nil && err == nil {
+			err = derr
+		}
+	}
+
+	return list, rep, err
+}
+
+func preset(file string, name string) (refind.Tuning, error) {
+	if file == "" {
+		return refind.Preset(refind.Presets, name)
+	}
+
+	f, err := os.Open(file)
+	if err != nil {
+		return refind.Tuning{}, errors.Wrap(err, "cannot open presets file")
+	}
+	defer f.Close()
+
+	presets, err := refind.LoadPresets(f)
+	if err != nil {
+		return refind.Tuning{}, err
+	}
+
+	return refind.Preset(presets, name)
+}
+
+func loadPlayer(name string) (player, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot open session file")
+	}
+	defer f.Close()
+
+	sess, err := replay.Load(f)
+	if err != nil {
+		return nil, err
+	}
+
+	return replay.NewPlayer(sess)
+}
+
+func saveSession(name string, sess replay.Session) error {
+	f, err := os.Create(name)
+	if err != nil {
+		return errors.Wrap(err, "cannot create session file")
+	}
+
+	if err := replay.Save(f, sess); err != nil {
+		f.Close()
+		return err
+	}
+
+	return f.Close()
+}
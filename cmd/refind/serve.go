@@ -0,0 +1,166 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"github.com/Henry-Sarabia/refind"
+	"github.com/Henry-Sarabia/refind/metrics"
+	"github.com/Henry-Sarabia/refind/server"
+	"github.com/Henry-Sarabia/refind/spotify"
+	"github.com/Henry-Sarabia/refind/tracing"
+	"github.com/Henry-Sarabia/refind/user"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"go.opentelemetry.io/otel"
+	"golang.org/x/oauth2"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+)
+
+var errCallbackPath = errors.New("redirect URI path must be /callback")
+
+func serve(args []string) error {
+	fs := flag.NewFlagSet("serve", flag.ExitOnError)
+	cfg, err := loadConfig(fs, args)
+	if err != nil {
+		return err
+	}
+	addr := fs.String("addr", cfg.Server.Addr, "address to listen on")
+	redirect := fs.String("redirect", cfg.Spotify.Redirect, "OAuth redirect URI, which must point to /callback on this server")
+	tokens := fs.String("tokens", cfg.Server.Tokens, "JSON file to keep session tokens in between restarts (default keeps them in memory)")
+	ttl := fs.Duration("session-ttl", time.Duration(cfg.Server.SessionTTL), "log out sessions idle for longer than this and forget their tokens")
+	presets := fs.String("presets", cfg.Generate.Presets, "JSON file of additional or replacement presets for the preset parameter")
+	quota := fs.Int("quota", cfg.Server.Quota, "tracklists each user may generate per -quota-window (0 is unlimited)")
+	window := fs.Duration("quota-window", time.Duration(cfg.Server.QuotaWindow), "sliding window of the -quota")
+	bufferTTL := fs.Duration("buffer-ttl", time.Duration(cfg.Server.BufferTTL), "fetch a user's listening data again once it is older than this (0 keeps it until the user refreshes)")
+	serveMetrics := fs.Bool("metrics", cfg.Server.Metrics, "serve Prometheus metrics of requests, buffers and generations at /metrics")
+	trace := fs.Bool("trace", cfg.Server.Tracing, "emit OpenTelemetry spans of every generation to the global tracer provider")
+	logLevel := fs.String("log-level", cfg.LogLevel, "log records of at least this level to standard error: debug, info, warn or error")
+	fs.Parse(args)
+
+	logger, err := newLogger(*logLevel)
+	if err != nil {
+		return err
+	}
+
+	u, err := url.Parse(*redirect)
+	if err != nil {
+		return errors.Wrap(err, "cannot parse redirect URI")
+	}
+
+	if u.Path != "/callback" {
+		return errCallbackPath
+	}
+
+	auth, err := spotify.Authenticator(*redirect)
+	if err != nil {
+		return err
+	}
+
+	var store server.TokenStore = server.NewMemoryStore()
+	if *tokens != "" {
+		store, err = server.NewFileStore(*tokens)
+		if err != nil {
+			return err
+		}
+	}
+
+	opts, err := spotifyOptions(cfg.Spotify, logger)
+	if err != nil {
+		return err
+	}
+	gen := []refind.Option{refind.WithLogger(logger)}
+
+	var met refind.Metrics
+	if *serveMetrics {
+		met, err = metrics.New(prometheus.DefaultRegisterer)
+		if err != nil {
+			return err
+		}
+		opts = append(opts, spotify.WithMetrics(met))
+		gen = append(gen, refind.WithMetrics(met))
+	}
+
+	if *trace {
+		tr, err := tracing.New(otel.Tracer("refind"))
+		if err != nil {
+			return err
+		}
+		opts = append(opts, spotify.WithTracer(tr))
+		gen = append(gen, refind.WithTracer(tr))
+	}
+
+	conn := func(tok *oauth2.Token) (server.Client, error) {
+		c := auth.NewClient(tok)
+		return spotify.New(&c, opts...)
+	}
+
+	srv, err := server.New(auth, conn, store)
+	if err != nil {
+		return err
+	}
+
+	if err := srv.SetLogger(logger); err != nil {
+		return err
+	}
+
+	if err := srv.SetSessionTTL(*ttl); err != nil {
+		return err
+	}
+
+	reg, err := user.New(user.Quota{Max: *quota, Window: *window}, user.NewMemoryHistory())
+	if err != nil {
+		return err
+	}
+
+	if err := reg.SetBufferTTL(*bufferTTL); err != nil {
+		return err
+	}
+
+	if err := reg.SetOptions(gen...); err != nil {
+		return err
+	}
+
+	if met != nil {
+		if err := reg.SetMetrics(met); err != nil {
+			return err
+		}
+	}
+
+	if *presets != "" {
+		f, err := os.Open(*presets)
+		if err != nil {
+			return errors.Wrap(err, "cannot open presets file")
+		}
+		defer f.Close()
+
+		all, err := refind.LoadPresets(f)
+		if err != nil {
+			return err
+		}
+
+		if err := reg.SetPresets(all); err != nil {
+			return err
+		}
+	}
+
+	if err := reg.SetDefaults(cfg.Generate.Settings()); err != nil {
+		return errors.Wrap(err, "cannot start users with the generate configuration")
+	}
+
+	if err := srv.SetRegistry(reg); err != nil {
+		return err
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/", srv)
+	if *serveMetrics {
+		mux.Handle("/metrics", promhttp.Handler())
+	}
+
+	fmt.Println("Serving refind on", *addr)
+	return http.ListenAndServe(*addr, mux)
+}
@@ -0,0 +1,518 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/Henry-Sarabia/refind"
+	"github.com/Henry-Sarabia/refind/spotify"
+	"github.com/Henry-Sarabia/refind/user"
+	"github.com/pkg/errors"
+	"io"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// EnvPrefix starts the name of every environment variable that overrides a
+// configuration value, such as REFIND_SPOTIFY_FETCH for spotify.fetch.
+const EnvPrefix string = "REFIND_"
+
+// fetchMax is the most items the Spotify API returns for a single request.
+const fetchMax int = 50
+
+var errInvalid = errors.New("invalid configuration value")
+
+// Config holds every tunable parameter of refind. Fields left out of a
+// configuration file keep their defaults. LogLevel is one of debug, info,
+// warn or error.
+type Config struct {
+	LogLevel string   `json:"log_level"`
+	Spotify  Spotify  `json:"spotify"`
+	Generate Generate `json:"generate"`
+	Server   Server   `json:"server"`
+	Daemon   Daemon   `json:"daemon"`
+}
+
+// Spotify configures the Spotify API client. Fetch is how many items each
+// top or recently played request asks for and TimeRanges are the ranges of
+// the user's top lists that are merged, out of short, medium and long.
+// Requests that fail because of rate limiting or a server error are made up
+// to RetryAttempts times, waiting RetryBackoff at first and twice as long
+// after every attempt, up to RetryMaxBackoff.
+type Spotify struct {
+	Redirect         string   `json:"redirect"`
+	Fetch            int      `json:"fetch"`
+	PopularityTarget int      `json:"popularity_target"`
+	PopularityMax    int      `json:"popularity_max"`
+	TimeRanges       []string `json:"time_ranges"`
+	PublicPlaylists  bool     `json:"public_playlists"`
+	PlaylistInfo     string   `json:"playlist_info"`
+	RetryAttempts    int      `json:"retry_attempts"`
+	RetryBackoff     Duration `json:"retry_backoff"`
+	RetryMaxBackoff  Duration `json:"retry_max_backoff"`
+}
+
+// Generate configures tracklist generation. A nil Novelty keeps the default
+// popularity bounds.
+type Generate struct {
+	Total       int      `json:"n"`
+	Mode        string   `json:"mode"`
+	Seeds       int      `json:"seeds"`
+	Order       string   `json:"order"`
+	Preset      string   `json:"preset"`
+	Presets     string   `json:"presets"`
+	Novelty     *float64 `json:"novelty"`
+	Recommender string   `json:"recommender"`
+	Depth       int      `json:"depth"`
+	GraphCache  string   `json:"graph_cache"`
+	Weeks       int      `json:"weeks"`
+	Types       []string `json:"types"`
+}
+
+// Server configures the HTTP API server. Sessions idle for longer than
+// SessionTTL are logged out. Each user may generate Quota tracklists per
+// QuotaWindow, where a zero Quota is unlimited, and their listening data is
+// fetched again once it is older than BufferTTL. Metrics serves Prometheus
+// metrics at /metrics and Tracing emits OpenTelemetry spans.
+type Server struct {
+	Addr        string   `json:"addr"`
+	Tokens      string   `json:"tokens"`
+	SessionTTL  Duration `json:"session_ttl"`
+	Quota       int      `json:"quota"`
+	QuotaWindow Duration `json:"quota_window"`
+	BufferTTL   Duration `json:"buffer_ttl"`
+	Metrics     bool     `json:"metrics"`
+	Tracing     bool     `json:"tracing"`
+}
+
+// Daemon configures the playlist refresh daemon.
+type Daemon struct {
+	Schedule    string   `json:"schedule"`
+	Tokens      string   `json:"tokens"`
+	State       string   `json:"state"`
+	Jitter      Duration `json:"jitter"`
+	Concurrency int      `json:"concurrency"`
+}
+
+// Duration is a time.Duration written as a string such as "90s" or "5m".
+type Duration time.Duration
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+func (d *Duration) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return errors.Wrap(err, "duration must be a string such as \"90s\"")
+	}
+
+	v, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+
+	*d = Duration(v)
+	return nil
+}
+
+// Default returns the configuration refind uses when nothing is configured.
+func Default() Config {
+	par := spotify.DefaultParams()
+
+	return Config{
+		LogLevel: "warn",
+		Spotify: Spotify{
+			Redirect:         "http://localhost:8080/callback",
+			Fetch:            par.Fetch,
+			PopularityTarget: par.PopTarget,
+			PopularityMax:    par.PopMax,
+			TimeRanges:       []string{"short", "medium", "long"},
+			PublicPlaylists:  par.Public,
+			PlaylistInfo:     "Generated by refind",
+			RetryAttempts:    3,
+			RetryBackoff:     Duration(time.Second),
+			RetryMaxBackoff:  Duration(30 * time.Second),
+		},
+		Generate: Generate{
+			Total:       30,
+			Mode:        "full",
+			Recommender: "spotify",
+			Depth:       2,
+			Weeks:       4,
+		},
+		Server: Server{
+			Addr:        ":8080",
+			SessionTTL:  Duration(30 * 24 * time.Hour),
+			QuotaWindow: Duration(time.Hour),
+			BufferTTL:   Duration(time.Hour),
+		},
+		Daemon: Daemon{
+			State:       "refind-daemon.json",
+			Jitter:      Duration(time.Minute),
+			Concurrency: 4,
+		},
+	}
+}
+
+// Load reads the named JSON configuration file, applies the environment
+// overrides and validates the result. An empty name loads the defaults.
+func Load(name string) (Config, error) {
+	c := Default()
+	if name != "" {
+		f, err := os.Open(name)
+		if err != nil {
+			return Config{}, errors.Wrap(err, "cannot open configuration file")
+		}
+		defer f.Close()
+
+		if c, err = Decode(f); err != nil {
+			return Config{}, err
+		}
+	}
+
+	if err := c.Override(os.LookupEnv); err != nil {
+		return Config{}, err
+	}
+
+	if err := c.Validate(); err != nil {
+		return Config{}, err
+	}
+
+	return c, nil
+}
+
+// Decode reads a JSON configuration over the defaults. Unknown fields are an
+// error so that misspelled settings do not go unnoticed.
+func Decode(r io.Reader) (Config, error) {
+	c := Default()
+	dec := json.NewDecoder(r)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&c); err != nil {
+		return Config{}, errors.Wrap(err, "cannot decode configuration")
+	}
+
+	return c, nil
+}
+
+// vars maps the name of every environment variable, without EnvPrefix, to
+// the value it overrides.
+func (c *Config) vars() map[string]interface{} {
+	return map[string]interface{}{
+		"LOG_LEVEL":                 &c.LogLevel,
+		"SPOTIFY_REDIRECT":          &c.Spotify.Redirect,
+		"SPOTIFY_FETCH":             &c.Spotify.Fetch,
+		"SPOTIFY_POPULARITY_TARGET": &c.Spotify.PopularityTarget,
+		"SPOTIFY_POPULARITY_MAX":    &c.Spotify.PopularityMax,
+		"SPOTIFY_TIME_RANGES":       &c.Spotify.TimeRanges,
+		"SPOTIFY_PUBLIC_PLAYLISTS":  &c.Spotify.PublicPlaylists,
+		"SPOTIFY_PLAYLIST_INFO":     &c.Spotify.PlaylistInfo,
+		"SPOTIFY_RETRY_ATTEMPTS":    &c.Spotify.RetryAttempts,
+		"SPOTIFY_RETRY_BACKOFF":     &c.Spotify.RetryBackoff,
+		"SPOTIFY_RETRY_MAX_BACKOFF": &c.Spotify.RetryMaxBackoff,
+		"GENERATE_N":                &c.Generate.Total,
+		"GENERATE_MODE":             &c.Generate.Mode,
+		"GENERATE_SEEDS":            &c.Generate.Seeds,
+		"GENERATE_ORDER":            &c.Generate.Order,
+		"GENERATE_PRESET":           &c.Generate.Preset,
+		"GENERATE_PRESETS":          &c.Generate.Presets,
+		"GENERATE_NOVELTY":          &c.Generate.Novelty,
+		"GENERATE_RECOMMENDER":      &c.Generate.Recommender,
+		"GENERATE_DEPTH":            &c.Generate.Depth,
+		"GENERATE_GRAPH_CACHE":      &c.Generate.GraphCache,
+		"GENERATE_WEEKS":            &c.Generate.Weeks,
+		"GENERATE_TYPES":            &c.Generate.Types,
+		"SERVER_ADDR":               &c.Server.Addr,
+		"SERVER_TOKENS":             &c.Server.Tokens,
+		"SERVER_SESSION_TTL":        &c.Server.SessionTTL,
+		"SERVER_QUOTA":              &c.Server.Quota,
+		"SERVER_QUOTA_WINDOW":       &c.Server.QuotaWindow,
+		"SERVER_BUFFER_TTL":         &c.Server.BufferTTL,
+		"SERVER_METRICS":            &c.Server.Metrics,
+		"SERVER_TRACING":            &c.Server.Tracing,
+		"DAEMON_SCHEDULE":           &c.Daemon.Schedule,
+		"DAEMON_TOKENS":             &c.Daemon.Tokens,
+		"DAEMON_STATE":              &c.Daemon.State,
+		"DAEMON_JITTER":             &c.Daemon.Jitter,
+		"DAEMON_CONCURRENCY":        &c.Daemon.Concurrency,
+	}
+}
+
+// Override replaces every value whose environment variable is set according
+// to lookup, which is usually os.LookupEnv. Lists are comma separated.
+func (c *Config) Override(lookup func(string) (string, bool)) error {
+	vars := c.vars()
+	names := make([]string, 0, len(vars))
+	for name := range vars {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		s, ok := lookup(EnvPrefix + name)
+		if !ok {
+			continue
+		}
+
+		if err := set(vars[name], s); err != nil {
+			return errors.Wrapf(err, "cannot parse %s%s", EnvPrefix, name)
+		}
+	}
+
+	return nil
+}
+
+func set(v interface{}, s string) error {
+	switch v := v.(type) {
+	case *string:
+		*v = s
+	case *int:
+		n, err := strconv.Atoi(s)
+		if err != nil {
+			return err
+		}
+		*v = n
+	case *bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		*v = b
+	case **float64:
+		if s == "" {
+			*v = nil
+			return nil
+		}
+		f, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return err
+		}
+		*v = &f
+	case *[]string:
+		*v = SplitList(s)
+	case *Duration:
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return err
+		}
+		*v = Duration(d)
+	default:
+		return fmt.Errorf("unsupported type %T", v)
+	}
+
+	return nil
+}
+
+// SplitList splits a comma separated list, dropping blank items.
+func SplitList(s string) []string {
+	var list []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+
+	return list
+}
+
+// Validate reports the first value that is out of range, naming its field.
+func (c Config) Validate() error {
+	switch c.LogLevel {
+	case "debug", "info", "warn", "error":
+	default:
+		return errors.Wrapf(errInvalid, "log_level must be one of debug, info, warn or error, got %q", c.LogLevel)
+	}
+
+	if err := c.Spotify.validate(); err != nil {
+		return err
+	}
+
+	if err := c.Generate.validate(); err != nil {
+		return err
+	}
+
+	if c.Server.Addr == "" {
+		return errors.Wrap(errInvalid, "server.addr must not be empty")
+	}
+
+	if c.Server.SessionTTL <= 0 {
+		return errors.Wrapf(errInvalid, "server.session_ttl must be positive, got %v", time.Duration(c.Server.SessionTTL))
+	}
+
+	if c.Server.Quota < 0 || (c.Server.Quota > 0 && c.Server.QuotaWindow <= 0) {
+		return errors.Wrapf(errInvalid, "server.quota must not be negative and needs a positive server.quota_window, got %d per %v", c.Server.Quota, time.Duration(c.Server.QuotaWindow))
+	}
+
+	if c.Server.BufferTTL < 0 {
+		return errors.Wrapf(errInvalid, "server.buffer_ttl must not be negative, got %v", time.Duration(c.Server.BufferTTL))
+	}
+
+	if c.Daemon.Jitter < 0 {
+		return errors.Wrapf(errInvalid, "daemon.jitter must not be negative, got %v", time.Duration(c.Daemon.Jitter))
+	}
+
+	if c.Daemon.Concurrency <= 0 {
+		return errors.Wrapf(errInvalid, "daemon.concurrency must be positive, got %d", c.Daemon.Concurrency)
+	}
+
+	return nil
+}
+
+func (s Spotify) validate() error {
+	u, err := url.Parse(s.Redirect)
+	if err != nil || !u.IsAbs() {
+		return errors.Wrapf(errInvalid, "spotify.redirect must be an absolute URL, got %q", s.Redirect)
+	}
+
+	if s.Fetch <= 0 || s.Fetch > fetchMax {
+		return errors.Wrapf(errInvalid, "spotify.fetch must be between 1 and %d, got %d", fetchMax, s.Fetch)
+	}
+
+	if s.PopularityTarget < 0 || s.PopularityMax > 100 || s.PopularityTarget > s.PopularityMax {
+		return errors.Wrapf(errInvalid, "spotify popularity must satisfy 0 <= popularity_target <= popularity_max <= 100, got %d and %d", s.PopularityTarget, s.PopularityMax)
+	}
+
+	if s.RetryAttempts <= 0 {
+		return errors.Wrapf(errInvalid, "spotify.retry_attempts must be positive, got %d", s.RetryAttempts)
+	}
+
+	if s.RetryBackoff < 0 || s.RetryMaxBackoff < 0 {
+		return errors.Wrapf(errInvalid, "spotify.retry_backoff and spotify.retry_max_backoff must not be negative, got %v and %v", time.Duration(s.RetryBackoff), time.Duration(s.RetryMaxBackoff))
+	}
+
+	par, err := s.Params()
+	if err != nil {
+		return err
+	}
+
+	return par.Validate()
+}
+
+// Retry returns the retry policy of a Spotify service.
+func (s Spotify) Retry() spotify.RetryPolicy {
+	return spotify.RetryPolicy{
+		Attempts:   s.RetryAttempts,
+		Backoff:    time.Duration(s.RetryBackoff),
+		MaxBackoff: time.Duration(s.RetryMaxBackoff),
+	}
+}
+
+// Params returns the parameters of a Spotify service.
+func (s Spotify) Params() (spotify.Params, error) {
+	if len(s.TimeRanges) == 0 {
+		return spotify.Params{}, errors.Wrap(errInvalid, "spotify.time_ranges must not be empty")
+	}
+
+	par := spotify.Params{
+		Fetch:     s.Fetch,
+		PopTarget: s.PopularityTarget,
+		PopMax:    s.PopularityMax,
+		Public:    s.PublicPlaylists,
+	}
+
+	seen := make(map[string]bool)
+	for _, name := range s.TimeRanges {
+		r, err := spotify.ParseTimeRange(name)
+		if err != nil || seen[name] {
+			return spotify.Params{}, errors.Wrapf(errInvalid, "spotify.time_ranges must list short, medium or long at most once each, got %q", name)
+		}
+		seen[name] = true
+		par.Ranges = append(par.Ranges, r)
+	}
+
+	return par, nil
+}
+
+func (g Generate) validate() error {
+	if g.Total <= 0 {
+		return errors.Wrapf(errInvalid, "generate.n must be positive, got %d", g.Total)
+	}
+
+	switch g.Mode {
+	case "full", "limited", "top", "radar":
+	default:
+		return errors.Wrapf(errInvalid, "generate.mode must be one of full, limited, top or radar, got %q", g.Mode)
+	}
+
+	if g.Seeds < 0 {
+		return errors.Wrapf(errInvalid, "generate.seeds must not be negative, got %d", g.Seeds)
+	}
+
+	switch g.Order {
+	case "", "energy", "harmonic", "tempo", "shuffle":
+	default:
+		return errors.Wrapf(errInvalid, "generate.order must be one of energy, harmonic, tempo or shuffle, got %q", g.Order)
+	}
+
+	// Presets from a file can only be checked once the file is read.
+	if g.Preset != "" && g.Presets == "" {
+		if _, err := refind.Preset(refind.Presets, g.Preset); err != nil {
+			return errors.Wrapf(errInvalid, "generate.preset %q is not a built in preset", g.Preset)
+		}
+	}
+
+	if g.Novelty != nil {
+		if _, err := refind.Dial(*g.Novelty); err != nil {
+			return errors.Wrapf(errInvalid, "generate.novelty must be between 0 and 1, got %v", *g.Novelty)
+		}
+	}
+
+	switch g.Recommender {
+	case "spotify", "graph":
+	default:
+		return errors.Wrapf(errInvalid, "generate.recommender must be one of spotify or graph, got %q", g.Recommender)
+	}
+
+	if g.Depth <= 0 {
+		return errors.Wrapf(errInvalid, "generate.depth must be positive, got %d", g.Depth)
+	}
+
+	if g.Weeks <= 0 {
+		return errors.Wrapf(errInvalid, "generate.weeks must be positive, got %d", g.Weeks)
+	}
+
+	if _, err := g.ReleaseTypes(); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// ReleaseTypes returns the release types of the radar mode. None means all.
+func (g Generate) ReleaseTypes() ([]refind.ReleaseType, error) {
+	return ReleaseTypes(g.Types)
+}
+
+// ReleaseTypes parses a list of album, single or compilation release types.
+func ReleaseTypes(names []string) ([]refind.ReleaseType, error) {
+	var types []refind.ReleaseType
+	for _, t := range names {
+		switch t {
+		case "album":
+			types = append(types, refind.AlbumRelease)
+		case "single":
+			types = append(types, refind.SingleRelease)
+		case "compilation":
+			types = append(types, refind.CompilationRelease)
+		default:
+			return nil, errors.Wrapf(errInvalid, "generate.types must list album, single or compilation, got %q", t)
+		}
+	}
+
+	return types, nil
+}
+
+// Settings returns the settings new users of the server start with. The
+// server has no radar mode, so a radar configuration starts users in full
+// mode.
+func (g Generate) Settings() user.Settings {
+	s := user.Settings{Mode: g.Mode, Total: g.Total, Preset: g.Preset, Novelty: g.Novelty}
+	if s.Mode == "radar" {
+		s.Mode = user.DefaultSettings.Mode
+	}
+
+	return s
+}
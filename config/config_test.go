@@ -0,0 +1,249 @@
+package config
+
+import (
+	"github.com/Henry-Sarabia/refind"
+	"github.com/Henry-Sarabia/refind/spotify"
+	"github.com/Henry-Sarabia/refind/user"
+	"github.com/pkg/errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDefault(t *testing.T) {
+	c := Default()
+	if err := c.Validate(); err != nil {
+		t.Fatalf("got: <%v>, want: <%v>", err, nil)
+	}
+
+	par, err := c.Spotify.Params()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := spotify.DefaultParams(); !reflect.DeepEqual(par, want) {
+		t.Errorf("got: <%v>, want: <%v>", par, want)
+	}
+
+	if err := c.Spotify.Retry().Validate(); err != nil {
+		t.Errorf("got: <%v>, want: <%v>", err, nil)
+	}
+}
+
+func TestDecode(t *testing.T) {
+	novelty := 0.7
+
+	tests := []struct {
+		name    string
+		in      string
+		want    func(*Config)
+		wantErr bool
+	}{
+		{
+			name: "Empty object keeps defaults",
+			in:   `{}`,
+			want: func(c *Config) {},
+		},
+		{
+			name: "Partial sections",
+			in:   `{"spotify": {"fetch": 20, "public_playlists": false}, "generate": {"mode": "top", "novelty": 0.7}, "daemon": {"jitter": "30s"}}`,
+			want: func(c *Config) {
+				c.Spotify.Fetch = 20
+				c.Spotify.PublicPlaylists = false
+				c.Generate.Mode = "top"
+				c.Generate.Novelty = &novelty
+				c.Daemon.Jitter = Duration(30 * time.Second)
+			},
+		},
+		{
+			name:    "Unknown field",
+			in:      `{"spotify": {"fetchmax": 20}}`,
+			wantErr: true,
+		},
+		{
+			name:    "Invalid duration",
+			in:      `{"daemon": {"jitter": "soon"}}`,
+			wantErr: true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := Decode(strings.NewReader(test.in))
+			if (err != nil) != test.wantErr {
+				t.Fatalf("got: <%v>, want error: <%v>", err, test.wantErr)
+			}
+
+			if test.wantErr {
+				return
+			}
+
+			want := Default()
+			test.want(&want)
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("got: <%v>, want: <%v>", got, want)
+			}
+		})
+	}
+}
+
+func TestConfig_Override(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     map[string]string
+		want    func(*Config)
+		wantErr bool
+	}{
+		{
+			name: "No variables",
+			env:  map[string]string{},
+			want: func(c *Config) {},
+		},
+		{
+			name: "Every kind of value",
+			env: map[string]string{
+				"REFIND_SPOTIFY_REDIRECT":         "http://example.com/callback",
+				"REFIND_SPOTIFY_FETCH":            "10",
+				"REFIND_SPOTIFY_PUBLIC_PLAYLISTS": "false",
+				"REFIND_SPOTIFY_TIME_RANGES":      "long, short",
+				"REFIND_SPOTIFY_RETRY_ATTEMPTS":   "5",
+				"REFIND_SPOTIFY_RETRY_BACKOFF":    "500ms",
+				"REFIND_GENERATE_NOVELTY":         "0.25",
+				"REFIND_DAEMON_JITTER":            "2m",
+			},
+			want: func(c *Config) {
+				novelty := 0.25
+				c.Spotify.Redirect = "http://example.com/callback"
+				c.Spotify.Fetch = 10
+				c.Spotify.PublicPlaylists = false
+				c.Spotify.TimeRanges = []string{"long", "short"}
+				c.Spotify.RetryAttempts = 5
+				c.Spotify.RetryBackoff = Duration(500 * time.Millisecond)
+				c.Generate.Novelty = &novelty
+				c.Daemon.Jitter = Duration(2 * time.Minute)
+			},
+		},
+		{
+			name:    "Unparsable integer",
+			env:     map[string]string{"REFIND_GENERATE_N": "many"},
+			wantErr: true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			lookup := func(name string) (string, bool) {
+				v, ok := test.env[name]
+				return v, ok
+			}
+
+			got := Default()
+			err := got.Override(lookup)
+			if (err != nil) != test.wantErr {
+				t.Fatalf("got: <%v>, want error: <%v>", err, test.wantErr)
+			}
+
+			if test.wantErr {
+				return
+			}
+
+			want := Default()
+			test.want(&want)
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("got: <%v>, want: <%v>", got, want)
+			}
+		})
+	}
+}
+
+func TestConfig_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		change  func(*Config)
+		wantErr error
+		wantMsg string
+	}{
+		{"Defaults", func(c *Config) {}, nil, ""},
+		{"Unknown log level", func(c *Config) { c.LogLevel = "trace" }, errInvalid, "log_level"},
+		{"Relative redirect", func(c *Config) { c.Spotify.Redirect = "/callback" }, errInvalid, "spotify.redirect"},
+		{"Fetch too large", func(c *Config) { c.Spotify.Fetch = 51 }, errInvalid, "spotify.fetch"},
+		{"Popularity target above max", func(c *Config) { c.Spotify.PopularityTarget = 60 }, errInvalid, "popularity_target"},
+		{"Unknown time range", func(c *Config) { c.Spotify.TimeRanges = []string{"week"} }, errInvalid, "spotify.time_ranges"},
+		{"Repeated time range", func(c *Config) { c.Spotify.TimeRanges = []string{"long", "long"} }, errInvalid, "spotify.time_ranges"},
+		{"No time ranges", func(c *Config) { c.Spotify.TimeRanges = nil }, errInvalid, "spotify.time_ranges"},
+		{"Zero retry attempts", func(c *Config) { c.Spotify.RetryAttempts = 0 }, errInvalid, "spotify.retry_attempts"},
+		{"Negative retry backoff", func(c *Config) { c.Spotify.RetryBackoff = -1 }, errInvalid, "spotify.retry_backoff"},
+		{"Negative retry max backoff", func(c *Config) { c.Spotify.RetryMaxBackoff = -1 }, errInvalid, "spotify.retry_max_backoff"},
+		{"Zero tracks", func(c *Config) { c.Generate.Total = 0 }, errInvalid, "generate.n"},
+		{"Unknown mode", func(c *Config) { c.Generate.Mode = "blend" }, errInvalid, "generate.mode"},
+		{"Unknown order", func(c *Config) { c.Generate.Order = "alphabetical" }, errInvalid, "generate.order"},
+		{"Unknown preset", func(c *Config) { c.Generate.Preset = "sleep" }, errInvalid, "generate.preset"},
+		{"Preset from file", func(c *Config) { c.Generate.Preset, c.Generate.Presets = "sleep", "presets.json" }, nil, ""},
+		{"Novelty too high", func(c *Config) { n := 1.5; c.Generate.Novelty = &n }, errInvalid, "generate.novelty"},
+		{"Unknown recommender", func(c *Config) { c.Generate.Recommender = "radio" }, errInvalid, "generate.recommender"},
+		{"Unknown release type", func(c *Config) { c.Generate.Types = []string{"ep"} }, errInvalid, "generate.types"},
+		{"Empty address", func(c *Config) { c.Server.Addr = "" }, errInvalid, "server.addr"},
+		{"Zero session TTL", func(c *Config) { c.Server.SessionTTL = 0 }, errInvalid, "server.session_ttl"},
+		{"Negative quota", func(c *Config) { c.Server.Quota = -1 }, errInvalid, "server.quota"},
+		{"Quota without window", func(c *Config) { c.Server.Quota, c.Server.QuotaWindow = 5, 0 }, errInvalid, "server.quota"},
+		{"Negative buffer TTL", func(c *Config) { c.Server.BufferTTL = -1 }, errInvalid, "server.buffer_ttl"},
+		{"Negative jitter", func(c *Config) { c.Daemon.Jitter = -1 }, errInvalid, "daemon.jitter"},
+		{"Zero concurrency", func(c *Config) { c.Daemon.Concurrency = 0 }, errInvalid, "daemon.concurrency"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c := Default()
+			test.change(&c)
+
+			err := c.Validate()
+			if errors.Cause(err) != test.wantErr {
+				t.Fatalf("got: <%v>, want: <%v>", errors.Cause(err), test.wantErr)
+			}
+
+			if err != nil && !strings.Contains(err.Error(), test.wantMsg) {
+				t.Errorf("got: <%v>, want message containing: <%v>", err, test.wantMsg)
+			}
+		})
+	}
+}
+
+func TestGenerate_ReleaseTypes(t *testing.T) {
+	g := Generate{Types: []string{"single", "album"}}
+	got, err := g.ReleaseTypes()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []refind.ReleaseType{refind.SingleRelease, refind.AlbumRelease}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got: <%v>, want: <%v>", got, want)
+	}
+}
+
+func TestGenerate_Settings(t *testing.T) {
+	novelty := 0.5
+	tests := []struct {
+		name string
+		gen  Generate
+		want user.Settings
+	}{
+		{"Default", Default().Generate, user.DefaultSettings},
+		{"Custom", Generate{Mode: "top", Total: 10, Preset: "focus", Novelty: &novelty}, user.Settings{Mode: "top", Total: 10, Preset: "focus", Novelty: &novelty}},
+		{"Radar", Generate{Mode: "radar", Total: 10}, user.Settings{Mode: "full", Total: 10}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := test.gen.Settings()
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("got: <%v>, want: <%v>", got, test.want)
+			}
+		})
+	}
+}
+
+func TestSplitList(t *testing.T) {
+	got := SplitList(" album,, single ,")
+	want := []string{"album", "single"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got: <%v>, want: <%v>", got, want)
+	}
+}
@@ -0,0 +1,226 @@
+package content
+
+import (
+	"github.com/Henry-Sarabia/refind"
+	"github.com/pkg/errors"
+	"math"
+	"sort"
+)
+
+const (
+	tempoMax    float64 = 250
+	loudnessMin float64 = -60
+	oversample  int     = 3
+)
+
+var (
+	errCatalogEmpty = errors.New("cannot initialize recommender using empty catalog")
+	errNilRerank    = errors.New("cannot initialize reranker using nil interface")
+	errSeedsUnknown = errors.New("no seed has features in the catalog")
+)
+
+// Candidate is a catalog entry that pairs a track with its audio features.
+type Candidate struct {
+	Track    refind.Track
+	Features refind.Features
+}
+
+type recommender struct {
+	catalog []Candidate
+	index   map[string]int
+}
+
+// New returns a Recommender that ranks the given catalog by distance from
+// the centroid of the seeds' audio features without any network access.
+func New(catalog []Candidate) (*recommender, error) {
+	if len(catalog) == 0 {
+		return nil, errCatalogEmpty
+	}
+
+	idx := make(map[string]int)
+	for i, c := range catalog {
+		idx[c.Track.ID] = i
+	}
+
+	return &recommender{catalog: catalog, index: idx}, nil
+}
+
+func (r *recommender) Recommendations(n int, seeds []refind.Seed) ([]refind.Track, error) {
+	if n <= 0 {
+		return nil, refind.ErrRangeInvalid
+	}
+
+	c, err := r.centroid(seeds)
+	if err != nil {
+		return nil, err
+	}
+
+	used := make(map[string]bool)
+	for _, sd := range seeds {
+		if sd.Category == refind.TrackSeed {
+			used[sd.ID] = true
+		}
+	}
+
+	var cands []Candidate
+	for _, cand := range r.catalog {
+		if !used[cand.Track.ID] {
+			cands = append(cands, cand)
+		}
+	}
+
+	return nearest(n, c, cands), nil
+}
+
+// Rank orders the given tracks by distance from the seeds' centroid. Tracks
+// missing from the catalog keep their relative order after the ranked ones.
+func (r *recommender) Rank(seeds []refind.Seed, tracks []refind.Track) ([]refind.Track, error) {
+	c, err := r.centroid(seeds)
+	if err != nil {
+		return nil, err
+	}
+
+	var known []Candidate
+	var unknown []refind.Track
+	for _, t := range tracks {
+		i, ok := r.index[t.ID]
+		if !ok {
+			unknown = append(unknown, t)
+			continue
+		}
+		known = append(known, Candidate{Track: t, Features: r.catalog[i].Features})
+	}
+
+	return append(nearest(len(known), c, known), unknown...), nil
+}
+
+func (r *recommender) centroid(seeds []refind.Seed) ([]float64, error) {
+	if len(seeds) <= 0 {
+		return nil, refind.ErrSeedsMissing
+	}
+
+	var vecs [][]float64
+	for _, sd := range seeds {
+		switch sd.Category {
+		case refind.TrackSeed:
+			if i, ok := r.index[sd.ID]; ok {
+				vecs = append(vecs, vector(r.catalog[i].Features))
+			}
+		case refind.ArtistSeed:
+			for _, cand := range r.catalog {
+				if cand.Track.Artist.ID == sd.ID {
+					vecs = append(vecs, vector(cand.Features))
+				}
+			}
+		}
+	}
+
+	if len(vecs) == 0 {
+		return nil, errSeedsUnknown
+	}
+
+	c := make([]float64, len(vecs[0]))
+	for _, v := range vecs {
+		for i := range v {
+			c[i] += v[i] / float64(len(vecs))
+		}
+	}
+
+	return c, nil
+}
+
+type reranker struct {
+	rec   refind.Recommender
+	local *recommender
+}
+
+// NewReranker returns a Recommender that oversamples rec and keeps the
+// results closest to the seeds according to the local catalog.
+func NewReranker(rec refind.Recommender, local *recommender) (*reranker, error) {
+	if rec == nil || local == nil {
+		return nil, errNilRerank
+	}
+
+	return &reranker{rec: rec, local: local}, nil
+}
+
+func (r *reranker) Recommendations(n int, seeds []refind.Seed) ([]refind.Track, error) {
+	if n <= 0 {
+		return nil, refind.ErrRangeInvalid
+	}
+
+	recs, err := r.rec.Recommendations(n*oversample, seeds)
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot fetch recommendations to rerank")
+	}
+
+	ranked, err := r.local.Rank(seeds, recs)
+	if err != nil {
+		if errors.Cause(err) != errSeedsUnknown {
+			return nil, err
+		}
+		ranked = recs
+	}
+
+	if len(ranked) > n {
+		ranked = ranked[:n]
+	}
+
+	return ranked, nil
+}
+
+func nearest(n int, c []float64, cands []Candidate) []refind.Track {
+	if len(cands) == 0 {
+		return nil
+	}
+
+	dist := make([]float64, len(cands))
+	order := make([]int, len(cands))
+	for i, cand := range cands {
+		dist[i] = distance(c, vector(cand.Features))
+		order[i] = i
+	}
+
+	sort.SliceStable(order, func(i, j int) bool {
+		return dist[order[i]] < dist[order[j]]
+	})
+
+	if n > len(order) {
+		n = len(order)
+	}
+
+	var curr []refind.Track
+	for _, o := range order[:n] {
+		curr = append(curr, cands[o].Track)
+	}
+
+	return curr
+}
+
+func vector(f refind.Features) []float64 {
+	return []float64{
+		f.Acousticness,
+		f.Danceability,
+		f.Energy,
+		f.Instrumentalness,
+		f.Liveness,
+		clamp((f.Loudness - loudnessMin) / -loudnessMin),
+		f.Speechiness,
+		clamp(f.Tempo / tempoMax),
+		f.Valence,
+	}
+}
+
+func distance(a, b []float64) float64 {
+	var sum float64
+	for i := range a {
+		d := a[i] - b[i]
+		sum += d * d
+	}
+
+	return math.Sqrt(sum)
+}
+
+func clamp(x float64) float64 {
+	return math.Max(0, math.Min(1, x))
+}
@@ -0,0 +1,218 @@
+package content
+
+import (
+	"github.com/Henry-Sarabia/refind"
+	"github.com/pkg/errors"
+	"reflect"
+	"testing"
+)
+
+var testErrFetchRecommendations = errors.New("cannot fetch recommendation tracks")
+
+var testCatalog = []Candidate{
+	{
+		Track:    refind.Track{ID: "0", Name: "foo", Artist: refind.Artist{ID: "10", Name: "corge"}},
+		Features: refind.Features{Energy: 0.9, Valence: 0.8, Tempo: 150},
+	},
+	{
+		Track:    refind.Track{ID: "1", Name: "bar", Artist: refind.Artist{ID: "11", Name: "grault"}},
+		Features: refind.Features{Energy: 0.1, Valence: 0.2, Tempo: 70},
+	},
+	{
+		Track:    refind.Track{ID: "2", Name: "baz", Artist: refind.Artist{ID: "12", Name: "garply"}},
+		Features: refind.Features{Energy: 0.8, Valence: 0.7, Tempo: 140},
+	},
+	{
+		Track:    refind.Track{ID: "3", Name: "qux", Artist: refind.Artist{ID: "11", Name: "grault"}},
+		Features: refind.Features{Energy: 0.2, Valence: 0.3, Tempo: 80},
+	},
+}
+
+type fakeRecommender struct {
+	tracks []refind.Track
+	err    error
+}
+
+func (f fakeRecommender) Recommendations(int, []refind.Seed) ([]refind.Track, error) {
+	return f.tracks, f.err
+}
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name    string
+		catalog []Candidate
+		wantErr error
+	}{
+		{"Nil catalog", nil, errCatalogEmpty},
+		{"Empty catalog", []Candidate{}, errCatalogEmpty},
+		{"Valid catalog", testCatalog, nil},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := New(test.catalog)
+			if err != test.wantErr {
+				t.Errorf("got: <%v>, want: <%v>", err, test.wantErr)
+			}
+		})
+	}
+}
+
+func TestRecommender_Recommendations(t *testing.T) {
+	tests := []struct {
+		name       string
+		total      int
+		sds        []refind.Seed
+		wantTracks []refind.Track
+		wantErr    error
+	}{
+		{
+			name:  "Track seed",
+			total: 2,
+			sds: []refind.Seed{
+				{Category: refind.TrackSeed, ID: "0"},
+			},
+			wantTracks: []refind.Track{
+				testCatalog[2].Track,
+				testCatalog[3].Track,
+			},
+			wantErr: nil,
+		},
+		{
+			name:  "Artist seed",
+			total: 2,
+			sds: []refind.Seed{
+				{Category: refind.ArtistSeed, ID: "10"},
+			},
+			wantTracks: []refind.Track{
+				testCatalog[0].Track,
+				testCatalog[2].Track,
+			},
+			wantErr: nil,
+		},
+		{
+			name:  "Total larger than catalog",
+			total: 10,
+			sds: []refind.Seed{
+				{Category: refind.TrackSeed, ID: "1"},
+			},
+			wantTracks: []refind.Track{
+				testCatalog[3].Track,
+				testCatalog[2].Track,
+				testCatalog[0].Track,
+			},
+			wantErr: nil,
+		},
+		{
+			name:       "Unknown seeds",
+			total:      2,
+			sds:        []refind.Seed{{Category: refind.GenreSeed, ID: "classical"}},
+			wantTracks: nil,
+			wantErr:    errSeedsUnknown,
+		},
+		{
+			name:       "No seeds",
+			total:      2,
+			sds:        nil,
+			wantTracks: nil,
+			wantErr:    refind.ErrSeedsMissing,
+		},
+		{
+			name:       "Total out of range",
+			total:      0,
+			sds:        []refind.Seed{{Category: refind.TrackSeed, ID: "0"}},
+			wantTracks: nil,
+			wantErr:    refind.ErrRangeInvalid,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			r, err := New(testCatalog)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := r.Recommendations(test.total, test.sds)
+			if errors.Cause(err) != test.wantErr {
+				t.Errorf("got: <%v>, want: <%v>", errors.Cause(err), test.wantErr)
+			}
+
+			if !reflect.DeepEqual(got, test.wantTracks) {
+				t.Errorf("got: <%v>, want: <%v>", got, test.wantTracks)
+			}
+		})
+	}
+}
+
+func TestReranker_Recommendations(t *testing.T) {
+	tests := []struct {
+		name       string
+		rec        fakeRecommender
+		total      int
+		sds        []refind.Seed
+		wantTracks []refind.Track
+		wantErr    error
+	}{
+		{
+			name: "Known and unknown tracks",
+			rec: fakeRecommender{
+				tracks: []refind.Track{
+					{ID: "99", Name: "waldo"},
+					testCatalog[1].Track,
+					testCatalog[2].Track,
+				},
+			},
+			total: 2,
+			sds:   []refind.Seed{{Category: refind.TrackSeed, ID: "0"}},
+			wantTracks: []refind.Track{
+				testCatalog[2].Track,
+				testCatalog[1].Track,
+			},
+			wantErr: nil,
+		},
+		{
+			name: "Unknown seeds keep remote order",
+			rec: fakeRecommender{
+				tracks: []refind.Track{
+					testCatalog[1].Track,
+					testCatalog[2].Track,
+				},
+			},
+			total: 1,
+			sds:   []refind.Seed{{Category: refind.TrackSeed, ID: "99"}},
+			wantTracks: []refind.Track{
+				testCatalog[1].Track,
+			},
+			wantErr: nil,
+		},
+		{
+			name:       "Remote error",
+			rec:        fakeRecommender{err: testErrFetchRecommendations},
+			total:      2,
+			sds:        []refind.Seed{{Category: refind.TrackSeed, ID: "0"}},
+			wantTracks: nil,
+			wantErr:    testErrFetchRecommendations,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			local, err := New(testCatalog)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			r, err := NewReranker(test.rec, local)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := r.Recommendations(test.total, test.sds)
+			if errors.Cause(err) != test.wantErr {
+				t.Errorf("got: <%v>, want: <%v>", errors.Cause(err), test.wantErr)
+			}
+
+			if !reflect.DeepEqual(got, test.wantTracks) {
+				t.Errorf("got: <%v>, want: <%v>", got, test.wantTracks)
+			}
+		})
+	}
+}
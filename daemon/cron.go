@@ -0,0 +1,172 @@
+package daemon
+
+import (
+	"github.com/pkg/errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// searchYears bounds how far ahead Next looks for a matching time so that
+// expressions that can never match, such as February 30th, terminate.
+const searchYears int = 5
+
+var errCronInvalid = errors.New("invalid cron expression")
+
+var macros = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+type field struct {
+	min, max int
+}
+
+var fields = []field{
+	{0, 59}, // minute
+	{0, 23}, // hour
+	{1, 31}, // day of month
+	{1, 12}, // month
+	{0, 7},  // day of week, 0 and 7 are Sunday
+}
+
+// Cron is a parsed five field cron expression: minute, hour, day of month,
+// month and day of week. Each field accepts *, numbers, ranges such as 1-5,
+// steps such as */15 or 1-30/2, and comma separated lists of these. As in
+// Vixie cron, when both day fields are restricted a time matches either.
+type Cron struct {
+	minute, hour, dom, month, dow uint64
+	domAny, dowAny                bool
+}
+
+// ParseCron parses a cron expression or one of the @yearly, @monthly,
+// @weekly, @daily and @hourly macros.
+func ParseCron(expr string) (*Cron, error) {
+	if m, ok := macros[strings.TrimSpace(expr)]; ok {
+		expr = m
+	}
+
+	parts := strings.Fields(expr)
+	if len(parts) != len(fields) {
+		return nil, errors.Wrapf(errCronInvalid, "%q must have %d fields", expr, len(fields))
+	}
+
+	var sets [5]uint64
+	for i, p := range parts {
+		s, err := parseField(p, fields[i])
+		if err != nil {
+			return nil, errors.Wrapf(err, "%q", expr)
+		}
+		sets[i] = s
+	}
+
+	c := &Cron{
+		minute: sets[0],
+		hour:   sets[1],
+		dom:    sets[2],
+		month:  sets[3],
+		dow:    sets[4],
+		domAny: parts[2] == "*",
+		dowAny: parts[4] == "*",
+	}
+
+	if c.dow&(1<<7) != 0 {
+		c.dow |= 1
+	}
+
+	return c, nil
+}
+
+func parseField(s string, f field) (uint64, error) {
+	var set uint64
+	for _, part := range strings.Split(s, ",") {
+		step := 1
+		if i := strings.Index(part, "/"); i >= 0 {
+			n, err := strconv.Atoi(part[i+1:])
+			if err != nil || n <= 0 {
+				return 0, errors.Wrapf(errCronInvalid, "bad step in %q", part)
+			}
+			step = n
+			part = part[:i]
+		}
+
+		lo, hi := f.min, f.max
+		switch {
+		case part == "*":
+		case strings.Contains(part, "-"):
+			i := strings.Index(part, "-")
+			var err error
+			if lo, err = strconv.Atoi(part[:i]); err != nil {
+				return 0, errors.Wrapf(errCronInvalid, "bad range in %q", part)
+			}
+			if hi, err = strconv.Atoi(part[i+1:]); err != nil {
+				return 0, errors.Wrapf(errCronInvalid, "bad range in %q", part)
+			}
+		default:
+			n, err := strconv.Atoi(part)
+			if err != nil {
+				return 0, errors.Wrapf(errCronInvalid, "bad value %q", part)
+			}
+			lo, hi = n, n
+			if step > 1 {
+				hi = f.max
+			}
+		}
+
+		if lo < f.min || hi > f.max || lo > hi {
+			return 0, errors.Wrapf(errCronInvalid, "%q is out of range %d-%d", part, f.min, f.max)
+		}
+
+		for v := lo; v <= hi; v += step {
+			set |= 1 << uint(v)
+		}
+	}
+
+	return set, nil
+}
+
+func (c *Cron) day(t time.Time) bool {
+	dom := c.dom&(1<<uint(t.Day())) != 0
+	dow := c.dow&(1<<uint(t.Weekday())) != 0
+
+	switch {
+	case c.domAny && c.dowAny:
+		return true
+	case c.domAny:
+		return dow
+	case c.dowAny:
+		return dom
+	default:
+		return dom || dow
+	}
+}
+
+// Next returns the first time after t that matches the expression, in t's
+// location, or the zero time if there is none within the next few years.
+func (c *Cron) Next(t time.Time) time.Time {
+	loc := t.Location()
+	t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute()+1, 0, 0, loc)
+	end := t.AddDate(searchYears, 0, 0)
+
+	for t.Before(end) {
+		switch {
+		case c.month&(1<<uint(t.Month())) == 0:
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, loc)
+		case !c.day(t):
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, loc)
+		case c.hour&(1<<uint(t.Hour())) == 0:
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, loc)
+		case c.minute&(1<<uint(t.Minute())) == 0:
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute()+1, 0, 0, loc)
+		default:
+			return t
+		}
+	}
+
+	return time.Time{}
+}
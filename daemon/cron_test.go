@@ -0,0 +1,70 @@
+package daemon
+
+import (
+	"github.com/pkg/errors"
+	"testing"
+	"time"
+)
+
+func TestParseCron(t *testing.T) {
+	tests := []struct {
+		name    string
+		expr    string
+		wantErr error
+	}{
+		{"Every minute", "* * * * *", nil},
+		{"Lists, ranges and steps", "0,30 9-17/2 1-15 */3 1-5", nil},
+		{"Sunday as seven", "0 0 * * 7", nil},
+		{"Macro", "@weekly", nil},
+		{"Too few fields", "0 0 * *", errCronInvalid},
+		{"Minute out of range", "60 * * * *", errCronInvalid},
+		{"Day out of range", "0 0 0 * *", errCronInvalid},
+		{"Reversed range", "0 17-9 * * *", errCronInvalid},
+		{"Zero step", "*/0 * * * *", errCronInvalid},
+		{"Not a number", "0 noon * * *", errCronInvalid},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := ParseCron(test.expr)
+			if errors.Cause(err) != test.wantErr {
+				t.Errorf("got: <%v>, want: <%v>", errors.Cause(err), test.wantErr)
+			}
+		})
+	}
+}
+
+func TestCron_Next(t *testing.T) {
+	// A Monday.
+	from := time.Date(2020, 6, 15, 10, 7, 30, 0, time.UTC)
+
+	tests := []struct {
+		name string
+		expr string
+		want time.Time
+	}{
+		{"Every minute", "* * * * *", time.Date(2020, 6, 15, 10, 8, 0, 0, time.UTC)},
+		{"Every quarter hour", "*/15 * * * *", time.Date(2020, 6, 15, 10, 15, 0, 0, time.UTC)},
+		{"Later today", "30 18 * * *", time.Date(2020, 6, 15, 18, 30, 0, 0, time.UTC)},
+		{"Tomorrow", "0 6 * * *", time.Date(2020, 6, 16, 6, 0, 0, 0, time.UTC)},
+		{"Next Sunday", "0 9 * * 0", time.Date(2020, 6, 21, 9, 0, 0, 0, time.UTC)},
+		{"Sunday as seven", "0 9 * * 7", time.Date(2020, 6, 21, 9, 0, 0, 0, time.UTC)},
+		{"Weekdays", "0 9 * * 1-5", time.Date(2020, 6, 16, 9, 0, 0, 0, time.UTC)},
+		{"Next month", "0 0 1 * *", time.Date(2020, 7, 1, 0, 0, 0, 0, time.UTC)},
+		{"Either day field", "0 0 1 * 5", time.Date(2020, 6, 19, 0, 0, 0, 0, time.UTC)},
+		{"Leap day", "0 0 29 2 *", time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC)},
+		{"Never", "0 0 30 2 *", time.Time{}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c, err := ParseCron(test.expr)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got := c.Next(from)
+			if !got.Equal(test.want) {
+				t.Errorf("got: <%v>, want: <%v>", got, test.want)
+			}
+		})
+	}
+}
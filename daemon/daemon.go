@@ -0,0 +1,421 @@
+package daemon
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/Henry-Sarabia/blank"
+	"github.com/Henry-Sarabia/refind"
+	"github.com/Henry-Sarabia/refind/user"
+	"github.com/pkg/errors"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"os"
+	"sync"
+	"time"
+)
+
+var (
+	errNilRunner     = errors.New("cannot initialize daemon using nil runner")
+	errScheduleEmpty = errors.New("schedule has no jobs")
+	errJobName       = errors.New("job name is missing, blank or repeated")
+	errJobTarget     = errors.New("job user or playlist is missing or blank")
+	errRangeInvalid  = errors.New("integer parameter is out of range")
+	errCronNever     = errors.New("cron expression never matches")
+	errNilLogger     = errors.New("cannot use nil logger")
+)
+
+// Job regenerates a user's playlist whenever its cron expression matches.
+// Zero Settings use the user package defaults.
+type Job struct {
+	Name     string        `json:"name"`
+	Cron     string        `json:"cron"`
+	User     string        `json:"user"`
+	Playlist string        `json:"playlist"`
+	Settings user.Settings `json:"settings"`
+}
+
+// Schedule is the list of jobs the daemon runs.
+type Schedule struct {
+	Jobs []Job `json:"jobs"`
+}
+
+// LoadSchedule decodes and validates a JSON schedule.
+func LoadSchedule(r io.Reader) (Schedule, error) {
+	var s Schedule
+	if err := json.NewDecoder(r).Decode(&s); err != nil {
+		return Schedule{}, errors.Wrap(err, "cannot decode schedule")
+	}
+
+	if len(s.Jobs) == 0 {
+		return Schedule{}, errScheduleEmpty
+	}
+
+	names := make(map[string]bool)
+	for i, j := range s.Jobs {
+		if blank.Is(j.Name) || names[j.Name] {
+			return Schedule{}, errors.Wrapf(errJobName, "job %d", i)
+		}
+		names[j.Name] = true
+
+		if blank.Is(j.User) || blank.Is(j.Playlist) {
+			return Schedule{}, errors.Wrap(errJobTarget, j.Name)
+		}
+
+		if _, err := parseJobCron(j, time.Now()); err != nil {
+			return Schedule{}, err
+		}
+
+		if j.Settings == (user.Settings{}) {
+			s.Jobs[i].Settings = user.DefaultSettings
+		}
+
+		if err := s.Jobs[i].Settings.Validate(); err != nil {
+			return Schedule{}, errors.Wrapf(err, "invalid settings for %s", j.Name)
+		}
+	}
+
+	return s, nil
+}
+
+// parseJobCron parses the cron expression of j and checks that it matches
+// after now, since a job that is never due would otherwise have no next run.
+func parseJobCron(j Job, now time.Time) (*Cron, error) {
+	c, err := ParseCron(j.Cron)
+	if err != nil {
+		return nil, errors.Wrap(err, j.Name)
+	}
+
+	if c.Next(now).IsZero() {
+		return nil, errors.Wrapf(errCronNever, "%s: %q", j.Name, j.Cron)
+	}
+
+	return c, nil
+}
+
+// Runner regenerates the playlist of a job.
+type Runner interface {
+	Run(ctx context.Context, j Job) error
+}
+
+// State is what the daemon remembers about a job between restarts. Cron is
+// the expression NextRun was computed from.
+type State struct {
+	Cron      string    `json:"cron,omitempty"`
+	LastRun   time.Time `json:"last_run,omitempty"`
+	NextRun   time.Time `json:"next_run"`
+	LastError string    `json:"last_error,omitempty"`
+	Runs      int       `json:"runs"`
+	Failures  int       `json:"failures"`
+}
+
+type job struct {
+	Job
+	cron    *Cron
+	running bool
+}
+
+type daemon struct {
+	run    Runner
+	jobs   []*job
+	file   string
+	jitter time.Duration
+	slots  chan struct{}
+	log    refind.Logger
+	now    func() time.Time
+	sleep  func(context.Context, time.Duration)
+	rnd    *rand.Rand
+
+	mu    sync.Mutex
+	state map[string]State
+	wg    sync.WaitGroup
+}
+
+// New returns a daemon that runs the scheduled jobs with run and keeps their
+// state in the named JSON file. Jobs whose run was missed while the daemon
+// was stopped run once as soon as it starts again, unless their cron
+// expression changed in the meantime. The state of jobs that are no longer
+// scheduled is dropped.
+func New(s Schedule, run Runner, file string) (*daemon, error) {
+	if run == nil {
+		return nil, errNilRunner
+	}
+
+	if len(s.Jobs) == 0 {
+		return nil, errScheduleEmpty
+	}
+
+	d := &daemon{
+		run:   run,
+		file:  file,
+		slots: make(chan struct{}, 1),
+		log:   refind.NopLogger(),
+		now:   time.Now,
+		sleep: sleep,
+		rnd:   rand.New(rand.NewSource(time.Now().UnixNano())),
+		state: make(map[string]State),
+	}
+
+	names := make(map[string]*job)
+	for _, j := range s.Jobs {
+		c, err := parseJobCron(j, d.now())
+		if err != nil {
+			return nil, err
+		}
+		names[j.Name] = &job{Job: j, cron: c}
+		d.jobs = append(d.jobs, names[j.Name])
+	}
+
+	if err := d.load(); err != nil {
+		return nil, err
+	}
+
+	for name, st := range d.state {
+		j, ok := names[name]
+		if !ok {
+			delete(d.state, name)
+			continue
+		}
+
+		// Older state files do not record the cron expression.
+		if st.Cron != "" && st.Cron != j.Cron {
+			st.NextRun = time.Time{}
+		}
+		st.Cron = j.Cron
+		d.state[name] = st
+	}
+
+	return d, nil
+}
+
+// SetJitter delays each run by a random duration of up to max so that jobs
+// scheduled for the same minute do not all call the music service at once.
+func (d *daemon) SetJitter(max time.Duration) error {
+	if max < 0 {
+		return errRangeInvalid
+	}
+
+	d.jitter = max
+	return nil
+}
+
+// SetConcurrency caps how many jobs may run at the same time.
+func (d *daemon) SetConcurrency(n int) error {
+	if n <= 0 {
+		return errRangeInvalid
+	}
+
+	d.slots = make(chan struct{}, n)
+	return nil
+}
+
+// SetLogger logs the outcome of every run to l, such as a *slog.Logger.
+func (d *daemon) SetLogger(l refind.Logger) error {
+	if l == nil {
+		return errNilLogger
+	}
+
+	d.log = l
+	return nil
+}
+
+// State returns the state of every job by name.
+func (d *daemon) State() map[string]State {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	out := make(map[string]State)
+	for name, st := range d.state {
+		out[name] = st
+	}
+
+	return out
+}
+
+// Run starts due jobs every minute until ctx is done and then waits for the
+// running jobs to finish.
+func (d *daemon) Run(ctx context.Context) error {
+	for {
+		d.tick(ctx)
+
+		now := d.now()
+		wait := now.Truncate(time.Minute).Add(time.Minute).Sub(now)
+		select {
+		case <-ctx.Done():
+			d.wg.Wait()
+			return nil
+		case <-time.After(wait):
+		}
+	}
+}
+
+// tick starts every job that is due and not already running.
+func (d *daemon) tick(ctx context.Context) {
+	now := d.now()
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	changed := false
+	for _, j := range d.jobs {
+		st, ok := d.state[j.Name]
+		if !ok || st.NextRun.IsZero() {
+			st.Cron = j.Cron
+			st.NextRun = j.cron.Next(now)
+			d.state[j.Name] = st
+			changed = true
+		}
+
+		// A zero NextRun means the expression stopped matching, which New
+		// rules out for any time soon, so the job is never due.
+		if j.running || st.NextRun.IsZero() || st.NextRun.After(now) {
+			continue
+		}
+
+		j.running = true
+		var delay time.Duration
+		if d.jitter > 0 {
+			delay = time.Duration(d.rnd.Int63n(int64(d.jitter)))
+		}
+
+		d.wg.Add(1)
+		go d.start(ctx, j, delay)
+	}
+
+	if !changed {
+		return
+	}
+
+	if err := d.save(); err != nil {
+		d.log.Error("cannot save job state", "error", refind.Redact(err.Error()))
+	}
+}
+
+func (d *daemon) start(ctx context.Context, j *job, delay time.Duration) {
+	defer d.wg.Done()
+
+	d.sleep(ctx, delay)
+	if ctx.Err() != nil {
+		d.abort(j)
+		return
+	}
+
+	select {
+	case d.slots <- struct{}{}:
+	case <-ctx.Done():
+		d.abort(j)
+		return
+	}
+
+	// The select picks either case at random when both are ready.
+	if ctx.Err() != nil {
+		<-d.slots
+		d.abort(j)
+		return
+	}
+
+	err := d.run.Run(ctx, j.Job)
+	<-d.slots
+
+	if err != nil && ctx.Err() != nil {
+		d.abort(j)
+		return
+	}
+
+	d.finish(j, err)
+}
+
+// abort leaves the job due so that it runs when the daemon restarts.
+func (d *daemon) abort(j *job) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	j.running = false
+}
+
+func (d *daemon) finish(j *job, err error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	now := d.now()
+	st := d.state[j.Name]
+	st.LastRun = now
+	st.NextRun = j.cron.Next(now)
+	st.Runs++
+	st.LastError = ""
+	if err != nil {
+		st.Failures++
+		st.LastError = refind.Redact(err.Error())
+		d.log.Error("job failed", "job", j.Name, "error", st.LastError)
+	} else {
+		d.log.Info("job updated playlist", "job", j.Name, "playlist", j.Playlist)
+	}
+	if st.NextRun.IsZero() {
+		d.log.Warn("job cron expression no longer matches", "job", j.Name, "cron", j.Cron)
+	}
+	d.state[j.Name] = st
+	j.running = false
+
+	if err := d.save(); err != nil {
+		d.log.Error("cannot save job state", "error", refind.Redact(err.Error()))
+	}
+}
+
+// load reads the state file, ignoring it when it does not exist yet.
+func (d *daemon) load() error {
+	if d.file == "" {
+		return nil
+	}
+
+	b, err := ioutil.ReadFile(d.file)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return errors.Wrap(err, "cannot read state file")
+	}
+
+	if err := json.Unmarshal(b, &d.state); err != nil {
+		return errors.Wrap(err, "cannot decode state file")
+	}
+
+	if d.state == nil {
+		d.state = make(map[string]State)
+	}
+
+	return nil
+}
+
+// save writes the state file. The caller must hold mu.
+func (d *daemon) save() error {
+	if d.file == "" {
+		return nil
+	}
+
+	b, err := json.MarshalIndent(d.state, "", "  ")
+	if err != nil {
+		return errors.Wrap(err, "cannot encode state")
+	}
+
+	tmp := d.file + ".tmp"
+	if err := ioutil.WriteFile(tmp, b, 0644); err != nil {
+		return errors.Wrap(err, "cannot write state file")
+	}
+
+	if err := os.Rename(tmp, d.file); err != nil {
+		return errors.Wrap(err, "cannot replace state file")
+	}
+
+	return nil
+}
+
+func sleep(ctx context.Context, d time.Duration) {
+	if d <= 0 {
+		return
+	}
+
+	select {
+	case <-ctx.Done():
+	case <-time.After(d):
+	}
+}
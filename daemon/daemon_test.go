@@ -0,0 +1,295 @@
+package daemon
+
+import (
+	"context"
+	"github.com/Henry-Sarabia/refind/user"
+	"github.com/pkg/errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+var testErrRun = errors.New("cannot update playlist")
+
+// A Monday at nine.
+var testNow = time.Date(2020, 6, 15, 9, 0, 0, 0, time.UTC)
+
+type fakeRunner struct {
+	mu      sync.Mutex
+	ran     []string
+	running int
+	peak    int
+	hold    time.Duration
+	err     error
+}
+
+func (f *fakeRunner) Run(ctx context.Context, j Job) error {
+	f.mu.Lock()
+	f.ran = append(f.ran, j.Name)
+	f.running++
+	if f.running > f.peak {
+		f.peak = f.running
+	}
+	f.mu.Unlock()
+
+	time.Sleep(f.hold)
+
+	f.mu.Lock()
+	f.running--
+	f.mu.Unlock()
+
+	return f.err
+}
+
+func testSchedule(names ...string) Schedule {
+	var s Schedule
+	for _, n := range names {
+		s.Jobs = append(s.Jobs, Job{Name: n, Cron: "0 9 * * 1", User: "foo", Playlist: "bar", Settings: user.DefaultSettings})
+	}
+
+	return s
+}
+
+func newTestDaemon(t *testing.T, s Schedule, run Runner, file string) *daemon {
+	d, err := New(s, run, file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	d.sleep = func(context.Context, time.Duration) {}
+
+	return d
+}
+
+func TestLoadSchedule(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		want    user.Settings
+		wantErr error
+	}{
+		{"Default settings", `{"jobs": [{"name": "a", "cron": "@daily", "user": "foo", "playlist": "bar"}]}`, user.DefaultSettings, nil},
+		{"Custom settings", `{"jobs": [{"name": "a", "cron": "@daily", "user": "foo", "playlist": "bar", "settings": {"mode": "top", "n": 10}}]}`, user.Settings{Mode: "top", Total: 10}, nil},
+		{"No jobs", `{"jobs": []}`, user.Settings{}, errScheduleEmpty},
+		{"Repeated name", `{"jobs": [{"name": "a", "cron": "@daily", "user": "foo", "playlist": "bar"}, {"name": "a", "cron": "@daily", "user": "foo", "playlist": "bar"}]}`, user.Settings{}, errJobName},
+		{"Missing playlist", `{"jobs": [{"name": "a", "cron": "@daily", "user": "foo"}]}`, user.Settings{}, errJobTarget},
+		{"Invalid cron", `{"jobs": [{"name": "a", "cron": "daily", "user": "foo", "playlist": "bar"}]}`, user.Settings{}, errCronInvalid},
+		{"Cron never matches", `{"jobs": [{"name": "a", "cron": "0 0 30 2 *", "user": "foo", "playlist": "bar"}]}`, user.Settings{}, errCronNever},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			s, err := LoadSchedule(strings.NewReader(test.file))
+			if errors.Cause(err) != test.wantErr {
+				t.Fatalf("got: <%v>, want: <%v>", errors.Cause(err), test.wantErr)
+			}
+
+			if err != nil {
+				return
+			}
+
+			if s.Jobs[0].Settings != test.want {
+				t.Errorf("got: <%v>, want: <%v>", s.Jobs[0].Settings, test.want)
+			}
+		})
+	}
+}
+
+func TestDaemon_Concurrency(t *testing.T) {
+	run := &fakeRunner{hold: 20 * time.Millisecond}
+	d := newTestDaemon(t, testSchedule("a", "b", "c", "d", "e"), run, "")
+	if err := d.SetConcurrency(2); err != nil {
+		t.Fatal(err)
+	}
+
+	// The first tick only schedules the jobs, the second runs them.
+	d.now = func() time.Time { return testNow.Add(-time.Minute) }
+	d.tick(context.Background())
+	d.now = func() time.Time { return testNow }
+	d.tick(context.Background())
+	d.wg.Wait()
+
+	if len(run.ran) != 5 {
+		t.Errorf("got: <%v>, want: <%v>", len(run.ran), 5)
+	}
+
+	if run.peak > 2 {
+		t.Errorf("got: <%v>, want: <%v>", run.peak, "at most 2")
+	}
+
+	for name, st := range d.State() {
+		want := State{Cron: "0 9 * * 1", LastRun: testNow, NextRun: testNow.AddDate(0, 0, 7), Runs: 1}
+		if st != want {
+			t.Errorf("%s got: <%v>, want: <%v>", name, st, want)
+		}
+	}
+}
+
+func TestDaemon_Jitter(t *testing.T) {
+	run := &fakeRunner{}
+	d := newTestDaemon(t, testSchedule("a", "b", "c"), run, "")
+	if err := d.SetJitter(time.Minute); err != nil {
+		t.Fatal(err)
+	}
+
+	var mu sync.Mutex
+	var delays []time.Duration
+	d.sleep = func(ctx context.Context, delay time.Duration) {
+		mu.Lock()
+		delays = append(delays, delay)
+		mu.Unlock()
+	}
+
+	d.now = func() time.Time { return testNow.Add(-time.Minute) }
+	d.tick(context.Background())
+	d.now = func() time.Time { return testNow }
+	d.tick(context.Background())
+	d.wg.Wait()
+
+	if len(delays) != 3 {
+		t.Fatalf("got: <%v>, want: <%v>", len(delays), 3)
+	}
+
+	for _, delay := range delays {
+		if delay < 0 || delay >= time.Minute {
+			t.Errorf("got: <%v>, want: <%v>", delay, "between 0 and 1m")
+		}
+	}
+
+	if err := d.SetJitter(-time.Second); err != errRangeInvalid {
+		t.Errorf("got: <%v>, want: <%v>", err, errRangeInvalid)
+	}
+}
+
+func TestDaemon_Restart(t *testing.T) {
+	dir, err := ioutil.TempDir("", "refind")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "state.json")
+
+	run := &fakeRunner{err: testErrRun}
+	d := newTestDaemon(t, testSchedule("a"), run, file)
+	d.now = func() time.Time { return testNow.Add(-time.Hour) }
+	d.tick(context.Background())
+	d.wg.Wait()
+
+	if len(run.ran) != 0 {
+		t.Fatalf("got: <%v>, want: <%v>", len(run.ran), 0)
+	}
+
+	// The daemon was stopped over the scheduled time, so the job is run as
+	// soon as it starts again.
+	d = newTestDaemon(t, testSchedule("a"), run, file)
+	d.now = func() time.Time { return testNow.Add(3 * time.Hour) }
+	d.tick(context.Background())
+	d.wg.Wait()
+
+	if len(run.ran) != 1 {
+		t.Fatalf("got: <%v>, want: <%v>", len(run.ran), 1)
+	}
+
+	d = newTestDaemon(t, testSchedule("a"), run, file)
+	want := State{
+		LastRun:   testNow.Add(3 * time.Hour),
+		NextRun:   testNow.AddDate(0, 0, 7),
+		LastError: testErrRun.Error(),
+		Runs:      1,
+		Failures:  1,
+	}
+	if got := d.State()["a"]; !got.LastRun.Equal(want.LastRun) || !got.NextRun.Equal(want.NextRun) || got.LastError != want.LastError || got.Runs != want.Runs || got.Failures != want.Failures {
+		t.Errorf("got: <%v>, want: <%v>", got, want)
+	}
+}
+
+func TestDaemon_Cancelled(t *testing.T) {
+	tests := []struct {
+		name   string
+		before bool
+	}{
+		{"Cancelled before start", true},
+		{"Cancelled during jitter", false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			run := &fakeRunner{}
+			d := newTestDaemon(t, testSchedule("a"), run, "")
+
+			ctx, cancel := context.WithCancel(context.Background())
+			if test.before {
+				cancel()
+			}
+			d.sleep = func(context.Context, time.Duration) { cancel() }
+
+			d.now = func() time.Time { return testNow.Add(-time.Minute) }
+			d.tick(ctx)
+			d.now = func() time.Time { return testNow }
+			d.tick(ctx)
+			d.wg.Wait()
+
+			if len(run.ran) != 0 {
+				t.Errorf("got: <%v>, want: <%v>", len(run.ran), 0)
+			}
+
+			want := State{Cron: "0 9 * * 1", NextRun: testNow}
+			if got := d.State()["a"]; got != want {
+				t.Errorf("got: <%v>, want: <%v>", got, want)
+			}
+
+			if d.jobs[0].running {
+				t.Errorf("got: <%v>, want: <%v>", d.jobs[0].running, false)
+			}
+		})
+	}
+}
+
+func TestNew_CronNever(t *testing.T) {
+	s := testSchedule("a")
+	s.Jobs[0].Cron = "0 0 30 2 *"
+
+	_, err := New(s, &fakeRunner{}, "")
+	if errors.Cause(err) != errCronNever {
+		t.Errorf("got: <%v>, want: <%v>", errors.Cause(err), errCronNever)
+	}
+}
+
+func TestDaemon_ScheduleChange(t *testing.T) {
+	dir, err := ioutil.TempDir("", "refind")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "state.json")
+
+	run := &fakeRunner{}
+	d := newTestDaemon(t, testSchedule("a", "b"), run, file)
+	d.now = func() time.Time { return testNow.Add(-time.Hour) }
+	d.tick(context.Background())
+	d.wg.Wait()
+
+	// Job a moves to Tuesdays and job b is dropped while the daemon is
+	// stopped over a's old scheduled time.
+	s := testSchedule("a")
+	s.Jobs[0].Cron = "0 9 * * 2"
+	d = newTestDaemon(t, s, run, file)
+	d.now = func() time.Time { return testNow.Add(3 * time.Hour) }
+	d.tick(context.Background())
+	d.wg.Wait()
+
+	if len(run.ran) != 0 {
+		t.Errorf("got: <%v>, want: <%v>", run.ran, []string{})
+	}
+
+	got := d.State()
+	if _, ok := got["b"]; ok || len(got) != 1 {
+		t.Errorf("got: <%v>, want: <%v>", got, "only job a")
+	}
+
+	want := State{Cron: "0 9 * * 2", NextRun: testNow.AddDate(0, 0, 1)}
+	if !got["a"].NextRun.Equal(want.NextRun) || got["a"].Cron != want.Cron {
+		t.Errorf("got: <%v>, want: <%v>", got["a"], want)
+	}
+}
@@ -0,0 +1,304 @@
+package refind
+
+import (
+	"github.com/pkg/errors"
+	"math"
+)
+
+var errConstraintInvalid = errors.New("diversity constraint is out of range")
+
+// Constraints limit how much of a tracklist a single artist, album or genre
+// may take up and how the tracks are spread out. Zero values disable the
+// corresponding rule.
+type Constraints struct {
+	MaxPerArtist     int
+	MaxPerAlbum      int
+	MaxGenreShare    float64
+	MinArtistSpacing int
+	MinYearSpread    int
+}
+
+// OneTrackPerArtist matches the generator's default duplicate artist filter.
+var OneTrackPerArtist = Constraints{MaxPerArtist: 1}
+
+func (c Constraints) Validate() error {
+	if c.MaxPerArtist < 0 || c.MaxPerAlbum < 0 || c.MinArtistSpacing < 0 || c.MinYearSpread < 0 {
+		return errConstraintInvalid
+	}
+
+	if c.MaxGenreShare < 0 || c.MaxGenreShare > 1 {
+		return errConstraintInvalid
+	}
+
+	return nil
+}
+
+// solveBudget bounds how many partial tracklists Solve explores for each
+// size, so that large pools keep the best tracklist found within it.
+const solveBudget int = 100000
+
+// Solve picks at most n tracks from the pool that meet the constraints. It
+// keeps as many tracks as possible and, among tracklists of that size,
+// prefers those that reach the minimum release year spread, or come closest
+// to it, and then those with the highest score. A track scores higher the
+// earlier it is in the pool, so recommendation order is kept where the
+// constraints allow it. The genre share is measured against the number of
+// tracks picked. The result is arranged to honor the minimum artist spacing
+// while keeping the pool order where possible.
+func (c Constraints) Solve(n int, pool []Track) []Track {
+	if n <= 0 || len(pool) == 0 {
+		return nil
+	}
+
+	size := n
+	if size > len(pool) {
+		size = len(pool)
+	}
+
+	// A shorter tracklist allows fewer tracks per genre, so each size is
+	// searched with its own genre limit.
+	for ; size > 0; size-- {
+		picked := c.search(size, pool)
+		if picked == nil {
+			continue
+		}
+
+		var out []Track
+		for _, i := range picked {
+			out = append(out, pool[i])
+		}
+
+		return space(out, c.MinArtistSpacing)
+	}
+
+	return nil
+}
+
+// catalog reports whether the constraints depend on album, release or genre
+// data that recommendations usually leave out.
+func (c Constraints) catalog() bool {
+	return c.MaxPerAlbum > 0 || c.MaxGenreShare > 0 || c.MinYearSpread > 0
+}
+
+// solution ranks a tracklist: whether it reaches the minimum year spread,
+// its spread up to that minimum and its score.
+type solution struct {
+	met    bool
+	spread int
+	score  int
+}
+
+func (s solution) beats(o solution) bool {
+	if s.met != o.met {
+		return s.met
+	}
+
+	if s.spread != o.spread {
+		return s.spread > o.spread
+	}
+
+	return s.score > o.score
+}
+
+// solver is a branch and bound search for the best tracklist of a single
+// size. Tracks are tried in pool order, included before excluded, so the
+// first tracklist found is the greedy one.
+type solver struct {
+	cons   Constraints
+	pool   []Track
+	size   int
+	t      *tally
+	picked []int
+	score  int
+	best   []int
+	top    solution
+	nodes  int
+	// lo and hi are the earliest and latest release years from each index
+	// of the pool onwards, or 0 when there are none.
+	lo []int
+	hi []int
+}
+
+// search returns the indexes of the best tracklist of exactly size tracks,
+// or nil when none was found within the budget.
+func (c Constraints) search(size int, pool []Track) []int {
+	s := &solver{
+		cons: c,
+		pool: pool,
+		size: size,
+		t:    newTally(c, size),
+		lo:   make([]int, len(pool)+1),
+		hi:   make([]int, len(pool)+1),
+	}
+
+	for i := len(pool) - 1; i >= 0; i-- {
+		s.lo[i], s.hi[i] = widen(s.lo[i+1], s.hi[i+1], year(pool[i]))
+	}
+
+	s.walk(0)
+	return s.best
+}
+
+// rank is the score of the track at index i of the pool.
+func (s *solver) rank(i int) int {
+	return len(s.pool) - i
+}
+
+func (s *solver) walk(i int) {
+	if len(s.picked) == s.size {
+		sol := s.solution(s.years())
+		if s.best == nil || sol.beats(s.top) {
+			s.best = append([]int(nil), s.picked...)
+			s.top = sol
+		}
+		return
+	}
+
+	need := s.size - len(s.picked)
+	if len(s.pool)-i < need || s.nodes >= solveBudget {
+		return
+	}
+	s.nodes++
+
+	if s.best != nil {
+		bound := solution{score: s.score}
+		for j := 0; j < need; j++ {
+			bound.score += s.rank(i + j)
+		}
+
+		lo, hi := s.years()
+		lo, hi = widen(lo, hi, s.lo[i])
+		lo, hi = widen(lo, hi, s.hi[i])
+		bound.met, bound.spread = s.spread(lo, hi)
+
+		if !bound.beats(s.top) {
+			return
+		}
+	}
+
+	p := s.pool[i]
+	if s.t.allows(p) {
+		s.t.add(p, 1)
+		s.picked = append(s.picked, i)
+		s.score += s.rank(i)
+
+		s.walk(i + 1)
+
+		s.score -= s.rank(i)
+		s.picked = s.picked[:len(s.picked)-1]
+		s.t.add(p, -1)
+	}
+
+	s.walk(i + 1)
+}
+
+func (s *solver) years() (int, int) {
+	lo, hi := 0, 0
+	for _, i := range s.picked {
+		lo, hi = widen(lo, hi, year(s.pool[i]))
+	}
+
+	return lo, hi
+}
+
+func (s *solver) solution(lo, hi int) solution {
+	sol := solution{score: s.score}
+	sol.met, sol.spread = s.spread(lo, hi)
+	return sol
+}
+
+// spread reports whether the years reach the minimum spread and the spread
+// up to that minimum.
+func (s *solver) spread(lo, hi int) (bool, int) {
+	want := s.cons.MinYearSpread
+	if want == 0 {
+		return true, 0
+	}
+
+	d := hi - lo
+	if d > want {
+		d = want
+	}
+
+	return d >= want, d
+}
+
+// widen extends the year range lo to hi with y, where 0 marks an empty
+// range or a missing year.
+func widen(lo, hi, y int) (int, int) {
+	if y == 0 {
+		return lo, hi
+	}
+
+	if lo == 0 || y < lo {
+		lo = y
+	}
+	if y > hi {
+		hi = y
+	}
+
+	return lo, hi
+}
+
+// year returns the release year of t, or 0 when it has no release date.
+func year(t Track) int {
+	if t.Album.ReleaseDate.IsZero() {
+		return 0
+	}
+
+	return t.Album.ReleaseDate.Year()
+}
+
+type tally struct {
+	cons     Constraints
+	genreMax int
+	artists  map[string]int
+	albums   map[string]int
+	genres   map[string]int
+}
+
+func newTally(c Constraints, n int) *tally {
+	t := &tally{
+		cons:    c,
+		artists: make(map[string]int),
+		albums:  make(map[string]int),
+		genres:  make(map[string]int),
+	}
+
+	if c.MaxGenreShare > 0 {
+		t.genreMax = int(math.Max(1, math.Floor(c.MaxGenreShare*float64(n))))
+	}
+
+	return t
+}
+
+func (t *tally) allows(tr Track) bool {
+	if t.cons.MaxPerArtist > 0 && t.artists[tr.Artist.ID] >= t.cons.MaxPerArtist {
+		return false
+	}
+
+	if t.cons.MaxPerAlbum > 0 && tr.Album.ID != "" && t.albums[tr.Album.ID] >= t.cons.MaxPerAlbum {
+		return false
+	}
+
+	if t.genreMax > 0 {
+		for _, g := range tr.Artist.Genres {
+			if t.genres[g] >= t.genreMax {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+func (t *tally) add(tr Track, d int) {
+	t.artists[tr.Artist.ID] += d
+	if tr.Album.ID != "" {
+		t.albums[tr.Album.ID] += d
+	}
+
+	for _, g := range tr.Artist.Genres {
+		t.genres[g] += d
+	}
+}
@@ -0,0 +1,223 @@
+package refind
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func testPoolTrack(id string, artist string, album string, year int, genre string) Track {
+	return Track{
+		ID:     id,
+		Artist: Artist{ID: artist, Name: artist, Genres: []string{genre}},
+		Album:  Album{ID: album, ReleaseDate: time.Date(year, 1, 1, 0, 0, 0, 0, time.UTC)},
+	}
+}
+
+var testPool = []Track{
+	testPoolTrack("p0", "a", "x", 2020, "rock"),
+	testPoolTrack("p1", "a", "x", 2020, "rock"),
+	testPoolTrack("p2", "b", "y", 2019, "rock"),
+	testPoolTrack("p3", "c", "z", 2018, "pop"),
+	testPoolTrack("p4", "d", "w", 1990, "pop"),
+}
+
+func TestConstraints_Solve(t *testing.T) {
+	tests := []struct {
+		name string
+		cons Constraints
+		n    int
+		pool []Track
+		want []Track
+	}{
+		{
+			"Nil pool",
+			OneTrackPerArtist,
+			10,
+			nil,
+			nil,
+		},
+		{
+			"n out of range",
+			OneTrackPerArtist,
+			0,
+			testPool,
+			nil,
+		},
+		{
+			"No constraints",
+			Constraints{},
+			3,
+			testPool,
+			[]Track{testPool[0], testPool[1], testPool[2]},
+		},
+		{
+			"Max per artist",
+			OneTrackPerArtist,
+			10,
+			testPool,
+			[]Track{testPool[0], testPool[2], testPool[3], testPool[4]},
+		},
+		{
+			"Max per album",
+			Constraints{MaxPerAlbum: 1},
+			10,
+			testPool,
+			[]Track{testPool[0], testPool[2], testPool[3], testPool[4]},
+		},
+		{
+			"Max genre share",
+			Constraints{MaxGenreShare: 0.5},
+			4,
+			testPool,
+			[]Track{testPool[0], testPool[1], testPool[3], testPool[4]},
+		},
+		{
+			"Genre share of a shorter tracklist",
+			Constraints{MaxGenreShare: 0.5},
+			10,
+			testPool,
+			[]Track{testPool[0], testPool[1], testPool[3], testPool[4]},
+		},
+		{
+			"Min artist spacing",
+			Constraints{MinArtistSpacing: 1},
+			3,
+			testPool,
+			[]Track{testPool[0], testPool[2], testPool[1]},
+		},
+		{
+			"Min year spread",
+			Constraints{MaxPerArtist: 1, MinYearSpread: 20},
+			3,
+			testPool,
+			[]Track{testPool[0], testPool[2], testPool[4]},
+		},
+		{
+			"Unreachable year spread",
+			Constraints{MaxPerArtist: 1, MinYearSpread: 50},
+			3,
+			testPool,
+			[]Track{testPool[0], testPool[2], testPool[4]},
+		},
+		{
+			// Greedy keeps q0 and then has to skip q1 for its artist and q2
+			// for its album, while q1 and q2 together fill the tracklist.
+			"Full tracklist greedy misses",
+			Constraints{MaxPerArtist: 1, MaxPerAlbum: 1},
+			2,
+			[]Track{testPoolTrack("q0", "a", "x", 2020, "rock"), testPoolTrack("q1", "a", "y", 2020, "rock"), testPoolTrack("q2", "b", "x", 2020, "rock")},
+			[]Track{testPoolTrack("q1", "a", "y", 2020, "rock"), testPoolTrack("q2", "b", "x", 2020, "rock")},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := test.cons.Solve(test.n, test.pool)
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("got: <%v>, want: <%v>", got, test.want)
+			}
+		})
+	}
+}
+
+func TestConstraints_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		cons    Constraints
+		wantErr error
+	}{
+		{"Zero constraints", Constraints{}, nil},
+		{"Valid constraints", Constraints{MaxPerArtist: 2, MaxGenreShare: 0.3, MinArtistSpacing: 3}, nil},
+		{"Negative count", Constraints{MaxPerAlbum: -1}, errConstraintInvalid},
+		{"Genre share above one", Constraints{MaxGenreShare: 1.5}, errConstraintInvalid},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := test.cons.Validate()
+			if err != test.wantErr {
+				t.Errorf("got: <%v>, want: <%v>", err, test.wantErr)
+			}
+		})
+	}
+}
+
+type fakeCatalogService struct {
+	fakeMusicService
+	tracks  []Track
+	artists []Artist
+}
+
+func (f fakeCatalogService) Tracks(ids []string) ([]Track, error) {
+	return f.tracks, nil
+}
+
+func (f fakeCatalogService) Artists(ids []string) ([]Artist, error) {
+	return f.artists, nil
+}
+
+func TestGenerator_ConstraintsCatalog(t *testing.T) {
+	// Recommendations only carry track and artist IDs.
+	var bare []Track
+	for _, p := range testPool {
+		bare = append(bare, Track{ID: p.ID, Artist: Artist{ID: p.Artist.ID}, Provenance: &Provenance{Group: 1}})
+	}
+
+	serv := fakeCatalogService{
+		fakeMusicService: fakeMusicService{tracks: []Track{{ID: "10", Artist: Artist{ID: "9"}}}},
+		tracks:           testPool,
+		artists:          []Artist{testPool[0].Artist, testPool[2].Artist, testPool[3].Artist, testPool[4].Artist},
+	}
+
+	g, err := New(serv, fakeRecommender{tracks: bare}, WithConstraints(Constraints{MaxPerAlbum: 1, MaxGenreShare: 0.5}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	list, err := g.Tracklist(10)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var ids []string
+	for _, tr := range list {
+		ids = append(ids, tr.ID)
+		if tr.Album.ID == "" || len(tr.Artist.Genres) == 0 || tr.Provenance == nil {
+			t.Errorf("got: <%v>, want catalog data and provenance", tr)
+		}
+	}
+
+	want := []string{"p0", "p2", "p3", "p4"}
+	if !reflect.DeepEqual(ids, want) {
+		t.Errorf("got: <%v>, want: <%v>", ids, want)
+	}
+}
+
+func TestGenerator_ConstraintsSpacingAfterOrder(t *testing.T) {
+	pool := []Track{
+		testPoolTrack("p0", "a", "x", 2020, "rock"),
+		testPoolTrack("p1", "a", "y", 2020, "rock"),
+		testPoolTrack("p2", "a", "z", 2020, "rock"),
+		testPoolTrack("p3", "b", "w", 2020, "rock"),
+		testPoolTrack("p4", "c", "v", 2020, "rock"),
+		testPoolTrack("p5", "d", "u", 2020, "rock"),
+	}
+
+	for seed := int64(0); seed < 10; seed++ {
+		g, err := New(fakeMusicService{tracks: []Track{{ID: "10", Artist: Artist{ID: "9"}}}}, fakeRecommender{tracks: pool},
+			WithConstraints(Constraints{MaxPerArtist: 3, MinArtistSpacing: 1}), WithOrderer(Shuffle(seed, 0), nil))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		list, err := g.Tracklist(len(pool))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for i := 1; i < len(list); i++ {
+			if list[i].Artist.ID == list[i-1].Artist.ID {
+				t.Fatalf("got: <%v>, want artist %v spaced out", list, list[i].Artist.ID)
+			}
+		}
+	}
+}
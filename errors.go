@@ -0,0 +1,52 @@
+package refind
+
+import (
+	"fmt"
+	"github.com/pkg/errors"
+	"net/http"
+)
+
+// Errors returned by refind and its music services. Wrapped errors can be
+// matched with errors.Is.
+var (
+	ErrRangeInvalid = errors.New("integer parameter is out of range")
+	ErrSeedsMissing = errors.New("missing seed input")
+	ErrDataInvalid  = errors.New("invalid or empty data returned")
+	ErrNoHistory    = errors.New("user has no listening history to generate from")
+	ErrNoRanges     = errors.New("music service does not support time ranges")
+	ErrNoRelated    = errors.New("music service does not support related artists")
+	ErrNoReleases   = errors.New("music service does not support releases")
+	ErrNoTuning     = errors.New("recommender does not support tuning")
+	ErrUnauthorized = errors.New("music service rejected the credentials")
+	ErrRateLimited  = errors.New("music service rate limit exceeded")
+)
+
+// APIError is an error response from a music service's API. It matches
+// ErrUnauthorized and ErrRateLimited with errors.Is according to its status,
+// and can be extracted from any wrapped error with errors.As.
+type APIError struct {
+	Endpoint string
+	Status   int
+	Message  string
+	// Err is the error reported by the client, if any.
+	Err error
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("%s returned %d: %s", e.Endpoint, e.Status, e.Message)
+}
+
+func (e *APIError) Is(target error) bool {
+	switch target {
+	case ErrUnauthorized:
+		return e.Status == http.StatusUnauthorized
+	case ErrRateLimited:
+		return e.Status == http.StatusTooManyRequests
+	}
+
+	return false
+}
+
+func (e *APIError) Unwrap() error {
+	return e.Err
+}
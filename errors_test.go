@@ -0,0 +1,50 @@
+package refind
+
+import (
+	"github.com/pkg/errors"
+	"testing"
+)
+
+func TestAPIError(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+		wantIs error
+	}{
+		{"Unauthorized", 401, ErrUnauthorized},
+		{"Rate limited", 429, ErrRateLimited},
+		{"Server error", 503, nil},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cause := errors.New("client error")
+			err := errors.Wrap(&APIError{Endpoint: "GET /test", Status: test.status, Err: cause}, "cannot fetch")
+
+			for _, sentinel := range []error{ErrUnauthorized, ErrRateLimited, ErrNoHistory} {
+				if got := errors.Is(err, sentinel); got != (sentinel == test.wantIs) {
+					t.Errorf("got: <%v>, want: <%v>", got, sentinel == test.wantIs)
+				}
+			}
+
+			var apiErr *APIError
+			if !errors.As(err, &apiErr) || apiErr.Status != test.status {
+				t.Errorf("got: <%v>, want status: <%v>", apiErr, test.status)
+			}
+
+			if !errors.Is(err, cause) {
+				t.Errorf("got: <%v>, want: <%v>", err, cause)
+			}
+		})
+	}
+}
+
+func TestGenerator_NoHistory(t *testing.T) {
+	g, err := New(fakeMusicService{}, fakeRecommender{tracks: []Track{testTrackC}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := g.Tracklist(testTotal); !errors.Is(err, ErrNoHistory) {
+		t.Errorf("got: <%v>, want: <%v>", err, ErrNoHistory)
+	}
+}
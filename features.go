@@ -0,0 +1,15 @@
+package refind
+
+type Features struct {
+	Acousticness     float64
+	Danceability     float64
+	Energy           float64
+	Instrumentalness float64
+	Liveness         float64
+	Loudness         float64
+	Speechiness      float64
+	Tempo            float64
+	Valence          float64
+	Key              int
+	Mode             int
+}
@@ -1,97 +1,601 @@
 package refind
 
 import (
+	"context"
 	"github.com/pkg/errors"
+	"time"
 )
 
+const rangeLimit int = 50
+
 var (
-	errNilGen       = errors.New("cannot initialize new generator using nil interface")
-	errRangeInvalid = errors.New("integer parameter is out of range")
+	errNilGen      = errors.New("cannot initialize new generator using nil interface")
+	errNilSelector = errors.New("cannot use nil selector")
+	errNilOrderer  = errors.New("cannot use nil orderer")
+	errNilLogger   = errors.New("cannot use nil logger")
+	errNilMetrics  = errors.New("cannot use nil metrics")
+	errNilTracer   = errors.New("cannot use nil tracer")
 )
 
 type generator struct {
-	serv MusicService
-	rec  Recommender
+	serv  MusicService
+	rec   Recommender
+	sel   Selector
+	seeds int
+	ord   Orderer
+	feat  FeatureService
+	cons  *Constraints
+	tune  *Tuning
+	nov   *Novelty
+	now   func() time.Time
+	log   Logger
+	met   Metrics
+	tr    Tracer
+	ctx   context.Context
 }
 
-func New(serv MusicService, rec Recommender) (*generator, error) {
+// New returns a generator of tracklists for the user of serv with
+// recommendations from rec, configured by the options in order.
+func New(serv MusicService, rec Recommender, opts ...Option) (*generator, error) {
 	if serv == nil || rec == nil {
 		return nil, errNilGen
 	}
 
-	return &generator{serv: serv, rec: rec}, nil
+	g := &generator{serv: serv, rec: rec}
+	for _, opt := range opts {
+		if opt == nil {
+			return nil, errNilOption
+		}
+
+		if err := opt(g); err != nil {
+			return nil, err
+		}
+	}
+
+	return g, nil
 }
 
 type MusicService interface {
 	TopArtists() ([]Artist, error)
+	TopTracks() ([]Track, error)
 	RecentTracks() ([]Track, error)
 }
 
+// RangedMusicService is implemented by music services that can return a
+// user's top artists and tracks for a single time range.
+type RangedMusicService interface {
+	TopArtistsRange(TimeRange, int) ([]Artist, error)
+	TopTracksRange(TimeRange, int) ([]Track, error)
+}
+
 type Recommender interface {
 	Recommendations(int, []Seed) ([]Track, error)
 }
 
+// SetSelector makes the generator use at most n seeds chosen by sel instead
+// of every seed in the order it was collected.
+func (g *generator) SetSelector(n int, sel Selector) error {
+	if n <= 0 {
+		return ErrRangeInvalid
+	}
+
+	if sel == nil {
+		return errNilSelector
+	}
+
+	g.seeds = n
+	g.sel = sel
+	return nil
+}
+
+// SetConstraints replaces the default rule of one track per artist with the
+// given diversity constraints. Album, release and genre data is looked up
+// when the music service is a CatalogService, and the artist spacing is
+// restored after the tracklist is ordered.
+func (g *generator) SetConstraints(c Constraints) error {
+	if err := c.Validate(); err != nil {
+		return err
+	}
+
+	g.cons = &c
+	return nil
+}
+
+// SetTuning narrows every recommendation with t. The generator's Recommender
+// must implement TunedRecommender.
+func (g *generator) SetTuning(t Tuning) error {
+	if _, ok := g.rec.(TunedRecommender); !ok {
+		return ErrNoTuning
+	}
+
+	if err := t.Validate(); err != nil {
+		return err
+	}
+
+	g.tune = &t
+	return nil
+}
+
+// SetPreset tunes recommendations with the built in preset of that name.
+func (g *generator) SetPreset(name string) error {
+	t, err := Preset(Presets, name)
+	if err != nil {
+		return err
+	}
+
+	return g.SetTuning(t)
+}
+
+// SetNovelty controls how far recommendations stray from the user's known
+// taste with a level between 0 and 1. Levels above 0.5 also exclude artists
+// related to known artists, which requires a RelatedArtistService. Popularity
+// bounds set by a tuning take precedence over those of the novelty level.
+func (g *generator) SetNovelty(level float64) error {
+	n, err := Dial(level)
+	if err != nil {
+		return err
+	}
+
+	if _, ok := g.rec.(TunedRecommender); !ok {
+		return ErrNoTuning
+	}
+
+	if _, ok := g.serv.(RelatedArtistService); n.Hops() && !ok {
+		return ErrNoRelated
+	}
+
+	g.nov = &n
+	return nil
+}
+
+// SetOrderer makes the generator arrange the filtered tracklist with ord.
+// The audio features are fetched from feat, which may be nil for orderers
+// that do not use them.
+func (g *generator) SetOrderer(ord Orderer, feat FeatureService) error {
+	if ord == nil {
+		return errNilOrderer
+	}
+
+	g.ord = ord
+	g.feat = feat
+	return nil
+}
+
+// SetLogger makes the generator log the counts of every stage, and the seed
+// IDs at debug level, to l.
+func (g *generator) SetLogger(l Logger) error {
+	if l == nil {
+		return errNilLogger
+	}
+
+	g.log = l
+	return nil
+}
+
 func (g generator) Tracklist(n int) ([]Track, error) {
+	list, _, err := g.TracklistReport(n)
+	return list, err
+}
+
+func (g generator) TracklistReport(n int) ([]Track, Report, error) {
+	g, r := g.begin("Tracklist")
+	seeder := func() ([]Seed, error) {
+		var tracks []Track
+		err := g.span("RecentTracks", func(context.Context) (err error) {
+			tracks, err = g.serv.RecentTracks()
+			return err
+		})
+		if err != nil {
+			return nil, errors.Wrap(err, "cannot fetch recent tracks")
+		}
+
+		return trackSeeds(tracks)
+	}
+
+	return r.end(g.generate(n, seeder, g.topArtists))
+}
+
+func (g generator) LimitedTracklist(n int) ([]Track, error) {
+	list, _, err := g.LimitedTracklistReport(n)
+	return list, err
+}
+
+func (g generator) LimitedTracklistReport(n int) ([]Track, Report, error) {
+	g, r := g.begin("LimitedTracklist")
+	var top []Artist
+	seeder := func() ([]Seed, error) {
+		var err error
+		top, err = g.topArtists()
+		if err != nil {
+			return nil, err
+		}
+
+		return artistSeeds(top)
+	}
+
+	known := func() ([]Artist, error) {
+		return top, nil
+	}
+
+	return r.end(g.generate(n, seeder, known))
+}
+
+// TopTracklist seeds recommendations from the user's top tracks, which are
+// steadier than recent tracks but more specific than top artists.
+func (g generator) TopTracklist(n int) ([]Track, error) {
+	list, _, err := g.TopTracklistReport(n)
+	return list, err
+}
+
+func (g generator) TopTracklistReport(n int) ([]Track, Report, error) {
+	g, r := g.begin("TopTracklist")
+	seeder := func() ([]Seed, error) {
+		var tracks []Track
+		err := g.span("TopTracks", func(context.Context) (err error) {
+			tracks, err = g.serv.TopTracks()
+			return err
+		})
+		if err != nil {
+			return nil, errors.Wrap(err, "cannot fetch top tracks")
+		}
+
+		return trackSeeds(tracks)
+	}
+
+	return r.end(g.generate(n, seeder, g.topArtists))
+}
+
+// RangedTracklist seeds recommendations only from the top artists of the
+// given time range while still filtering out every known top artist.
+func (g generator) RangedTracklist(n int, r TimeRange) ([]Track, error) {
+	list, _, err := g.RangedTracklistReport(n, r)
+	return list, err
+}
+
+func (g generator) RangedTracklistReport(n int, r TimeRange) ([]Track, Report, error) {
+	g, run := g.begin("RangedTracklist")
+	seeder := func() ([]Seed, error) {
+		rs, ok := g.serv.(RangedMusicService)
+		if !ok {
+			return nil, ErrNoRanges
+		}
+
+		var ranged []Artist
+		err := g.span("TopArtistsRange", func(context.Context) (err error) {
+			ranged, err = rs.TopArtistsRange(r, rangeLimit)
+			return err
+		})
+		if err != nil {
+			return nil, errors.Wrap(err, "cannot fetch top artists in time range")
+		}
+
+		return artistSeeds(ranged)
+	}
+
+	return run.end(g.generate(n, seeder, g.topArtists))
+}
+
+// generate runs every stage shared by the tracklist modes: collecting and
+// selecting seeds, fetching recommendations, filtering out known and
+// repeated artists and ordering the result.
+func (g generator) generate(n int, seeder func() ([]Seed, error), known func() ([]Artist, error)) ([]Track, Report, error) {
+	var rep Report
 	if n <= 0 {
-		return nil, errRangeInvalid
+		return nil, rep, ErrRangeInvalid
+	}
+
+	log := g.logger()
+	fail := func(stage string, err error) ([]Track, Report, error) {
+		log.Error("generation failed", "stage", stage, "error", redactErr(err))
+		return nil, rep, err
 	}
 
-	tracks, err := g.serv.RecentTracks()
+	start := time.Now()
+	sds, err := seeder()
 	if err != nil {
-		return nil, errors.Wrap(err, "cannot fetch recent tracks")
+		return fail("seeds", err)
 	}
 
-	var sds []Seed
-	for _, t := range tracks {
-		sd, err := t.Seed()
+	if len(sds) == 0 {
+		return fail("seeds", ErrNoHistory)
+	}
+	rep.Seeds = len(sds)
+	sds = g.selectSeeds(sds)
+	rep.SelectedSeeds = len(sds)
+	rep.Timings.Seeds = time.Since(start)
+	log.Info("seeds selected", "seeds", rep.Seeds, "selected", rep.SelectedSeeds, "duration", rep.Timings.Seeds)
+	log.Debug("seed IDs", "ids", seedIDs(sds))
+
+	mark := time.Now()
+	recs, err := g.recommend(n, sds)
+	if err != nil {
+		return fail("recommendations", errors.Wrap(err, "cannot fetch recommendations"))
+	}
+	rep.Recommendations = len(recs)
+	rep.SeedGroups = g.seedGroups(sds, recs)
+	rep.Timings.Recommendations = time.Since(mark)
+	log.Info("recommendations fetched", "recommendations", rep.Recommendations, "seed_groups", rep.SeedGroups, "duration", rep.Timings.Recommendations)
+
+	mark = time.Now()
+	top, err := known()
+	if err != nil {
+		return fail("filter", err)
+	}
+
+	top, err = g.nearby(top)
+	if err != nil {
+		return fail("filter", err)
+	}
+
+	var f []Track
+	if g.cons == nil {
+		var rmvKnown, rmvDup int
+		f, rmvKnown, rmvDup = filterCount(recs, toMap(top))
+		rep.KnownRemoved = rmvKnown
+		rep.DuplicatesRemoved = rmvDup
+	} else {
+		f = removeKnown(recs, toMap(top))
+		rep.KnownRemoved = len(recs) - len(f)
+		f, err = g.catalog(f)
 		if err != nil {
-			return nil, errors.Wrap(err, "one or more tracks are invalid seeds")
+			return fail("filter", err)
 		}
-		sds = append(sds, sd)
+
+		sol := g.cons.Solve(n, f)
+		rep.ConstraintsRemoved = len(f) - len(sol)
+		f = sol
 	}
+	rep.Final = len(f)
+	rep.Timings.Filter = time.Since(mark)
+	log.Info("tracks filtered", "known_removed", rep.KnownRemoved, "duplicates_removed", rep.DuplicatesRemoved, "constraints_removed", rep.ConstraintsRemoved, "final", rep.Final, "duration", rep.Timings.Filter)
 
-	recs, err := g.rec.Recommendations(n, sds)
+	mark = time.Now()
+	f, err = g.order(f)
 	if err != nil {
-		return nil, errors.Wrap(err, "cannot fetch recommendations")
+		return fail("order", err)
+	}
+
+	// Ordering rearranges the tracks the constraints spaced out.
+	if g.cons != nil && g.ord != nil {
+		f = space(f, g.cons.MinArtistSpacing)
 	}
+	rep.Timings.Order = time.Since(mark)
+	rep.Timings.Total = time.Since(start)
+	log.Info("tracklist generated", "tracks", len(f), "duration", rep.Timings.Total)
 
-	top, err := g.serv.TopArtists()
+	return f, rep, nil
+}
+
+func (g generator) recommend(n int, sds []Seed) ([]Track, error) {
+	var recs []Track
+	err := g.span("Recommendations", func(ctx context.Context) (err error) {
+		recs, err = g.recommendIn(ctx, n, sds)
+		return err
+	})
+
+	return recs, err
+}
+
+// recommendIn fetches recommendations, letting the recommender trace its
+// requests within ctx when the generation is traced.
+func (g generator) recommendIn(ctx context.Context, n int, sds []Seed) ([]Track, error) {
+	rec := g.rec
+	if cr, ok := rec.(ContextRecommender); ok && g.ctx != nil {
+		rec = cr.WithContext(ctx)
+	}
+
+	if g.tune == nil && g.nov == nil {
+		return rec.Recommendations(n, sds)
+	}
+
+	tr, ok := rec.(TunedRecommender)
+	if !ok {
+		return nil, ErrNoTuning
+	}
+
+	return tr.TunedRecommendations(n, sds, g.tuning())
+}
+
+// tuning merges the generator's tuning with the attributes of its novelty
+// level.
+func (g generator) tuning() Tuning {
+	var t Tuning
+	if g.tune != nil {
+		t = *g.tune
+	}
+
+	if g.nov != nil {
+		attrs := g.nov.attributes()
+		for k, v := range t.Attributes {
+			attrs[k] = v
+		}
+		t.Attributes = attrs
+	}
+
+	return t
+}
+
+// seedGroups returns how many seed groups the recommender requested, or the
+// number of groups that produced a recommendation when it cannot tell.
+func (g generator) seedGroups(sds []Seed, recs []Track) int {
+	if gr, ok := g.rec.(GroupedRecommender); ok {
+		return gr.SeedGroups(sds, g.tuning())
+	}
+
+	return countGroups(recs)
+}
+
+func (g generator) nearby(known []Artist) ([]Artist, error) {
+	if g.nov == nil || !g.nov.Hops() {
+		return known, nil
+	}
+
+	rel, ok := g.serv.(RelatedArtistService)
+	if !ok {
+		return nil, ErrNoRelated
+	}
+
+	return g.nov.nearby(rel, known)
+}
+
+// catalog fills in the album, release, popularity and artist genres of the
+// tracks when the constraints depend on them and the music service can look
+// them up. Otherwise the tracks are returned as they are.
+func (g generator) catalog(list []Track) ([]Track, error) {
+	cs, ok := g.serv.(CatalogService)
+	if !ok || !g.cons.catalog() || len(list) == 0 {
+		return list, nil
+	}
+
+	ids := make([]string, len(list))
+	var artIDs []string
+	seen := make(map[string]bool)
+	for i, t := range list {
+		ids[i] = t.ID
+		if !seen[t.Artist.ID] {
+			seen[t.Artist.ID] = true
+			artIDs = append(artIDs, t.Artist.ID)
+		}
+	}
+
+	var full []Track
+	err := g.span("Tracks", func(context.Context) (err error) {
+		full, err = cs.Tracks(ids)
+		return err
+	})
 	if err != nil {
-		return nil, errors.Wrap(err, "cannot fetch top artists")
+		return nil, errors.Wrap(err, "cannot fetch track catalog data")
 	}
 
-	f := filter(recs, toMap(top))
+	var arts []Artist
+	if g.cons.MaxGenreShare > 0 {
+		err = g.span("Artists", func(context.Context) (err error) {
+			arts, err = cs.Artists(artIDs)
+			return err
+		})
+		if err != nil {
+			return nil, errors.Wrap(err, "cannot fetch artist catalog data")
+		}
+	}
+
+	tracks := make(map[string]Track)
+	for _, t := range full {
+		tracks[t.ID] = t
+	}
 
-	return f, nil
+	artists := make(map[string]Artist)
+	for _, a := range arts {
+		artists[a.ID] = a
+	}
+
+	out := make([]Track, len(list))
+	for i, t := range list {
+		if ft, ok := tracks[t.ID]; ok {
+			ft.Provenance = t.Provenance
+			t = ft
+		}
+
+		if a, ok := artists[t.Artist.ID]; ok {
+			t.Artist = a
+		}
+		out[i] = t
+	}
+
+	return out, nil
 }
 
-func (g generator) LimitedTracklist(n int) ([]Track, error) {
-	if n <= 0 {
-		return nil, errRangeInvalid
+func (g generator) order(list []Track) ([]Track, error) {
+	if g.ord == nil || len(list) == 0 {
+		return list, nil
+	}
+
+	var feats map[string]Features
+	if g.feat != nil {
+		ids := make([]string, len(list))
+		for i, t := range list {
+			ids[i] = t.ID
+		}
+
+		var err error
+		feats, err = g.feat.AudioFeatures(ids)
+		if err != nil {
+			return nil, errors.Wrap(err, "cannot fetch audio features")
+		}
+	}
+
+	return g.ord.Order(list, feats), nil
+}
+
+func (g generator) clock() time.Time {
+	if g.now == nil {
+		return time.Now()
+	}
+
+	return g.now()
+}
+
+func (g generator) logger() Logger {
+	if g.log == nil {
+		return nopLogger{}
+	}
+
+	return g.log
+}
+
+func seedIDs(sds []Seed) []string {
+	ids := make([]string, len(sds))
+	for i, sd := range sds {
+		ids[i] = sd.ID
 	}
 
-	top, err := g.serv.TopArtists()
+	return ids
+}
+
+func (g generator) topArtists() ([]Artist, error) {
+	var top []Artist
+	err := g.span("TopArtists", func(context.Context) (err error) {
+		top, err = g.serv.TopArtists()
+		return err
+	})
 	if err != nil {
 		return nil, errors.Wrap(err, "cannot fetch top artists")
 	}
 
+	return top, nil
+}
+
+func trackSeeds(tracks []Track) ([]Seed, error) {
 	var sds []Seed
-	for _, t := range top {
+	for _, t := range tracks {
 		sd, err := t.Seed()
 		if err != nil {
-			return nil, errors.Wrap(err, "one or more artists are invalid seeds")
+			return nil, errors.Wrap(err, "one or more tracks are invalid seeds")
 		}
 		sds = append(sds, sd)
 	}
 
-	recs, err := g.rec.Recommendations(n, sds)
-	if err != nil {
-		return nil, errors.Wrap(err, "cannot fetch recommendations")
+	return sds, nil
+}
+
+func artistSeeds(arts []Artist) ([]Seed, error) {
+	var sds []Seed
+	for _, a := range arts {
+		sd, err := a.Seed()
+		if err != nil {
+			return nil, errors.Wrap(err, "one or more artists are invalid seeds")
+		}
+		sds = append(sds, sd)
 	}
 
-	f := filter(recs, toMap(top))
+	return sds, nil
+}
 
-	return f, nil
+func (g generator) selectSeeds(sds []Seed) []Seed {
+	if g.sel == nil {
+		return sds
+	}
+
+	return g.sel.Select(g.seeds, sds)
 }
 
 func toMap(prev []Artist) map[string]Artist {
@@ -108,21 +612,63 @@ func toMap(prev []Artist) map[string]Artist {
 }
 
 func filter(prev []Track, rmv map[string]Artist) []Track {
+	curr, _, _ := filterCount(prev, rmv)
+	return curr
+}
+
+// filterCount removes tracks by known artists and keeps at most one track
+// per artist. It also returns how many tracks each rule removed.
+func filterCount(prev []Track, rmv map[string]Artist) ([]Track, int, int) {
 	if len(prev) == 0 {
-		return nil
+		return nil, 0, 0
 	}
 
 	if len(rmv) == 0 {
-		return prev
+		return prev, 0, 0
+	}
+
+	known := make(map[string]bool)
+	for name := range rmv {
+		known[name] = true
 	}
 
 	var curr []Track
+	var rmvKnown, rmvDup int
 	for _, p := range prev {
 		if _, ok := rmv[p.Artist.Name]; !ok {
 			curr = append(curr, p)
 			rmv[p.Artist.Name] = p.Artist
+			continue
+		}
+
+		if known[p.Artist.Name] {
+			rmvKnown++
+		} else {
+			rmvDup++
+		}
+	}
+
+	return curr, rmvKnown, rmvDup
+}
+
+func removeKnown(prev []Track, rmv map[string]Artist) []Track {
+	var curr []Track
+	for _, p := range prev {
+		if _, ok := rmv[p.Artist.Name]; !ok {
+			curr = append(curr, p)
 		}
 	}
 
 	return curr
 }
+
+func countGroups(list []Track) int {
+	groups := make(map[int]bool)
+	for _, t := range list {
+		if t.Provenance != nil {
+			groups[t.Provenance.Group] = true
+		}
+	}
+
+	return len(groups)
+}
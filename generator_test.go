@@ -7,31 +7,37 @@ import (
 )
 
 var (
-	testErrFetchArtists = errors.New("cannot fetch artists")
-	testErrFetchTracks = errors.New("cannot fetch tracks")
+	testErrFetchArtists         = errors.New("cannot fetch artists")
+	testErrFetchTracks          = errors.New("cannot fetch tracks")
 	testErrFetchRecommendations = errors.New("cannot fetch recommendation tracks")
 )
 
 const testTotal int = 30
 
 type fakeMusicService struct {
-	artists []Artist
+	artists   []Artist
 	artistErr error
-	tracks []Track
-	trackErr error
+	top       []Track
+	topErr    error
+	tracks    []Track
+	trackErr  error
 }
 
 func (f fakeMusicService) TopArtists() ([]Artist, error) {
 	return f.artists, f.artistErr
 }
 
+func (f fakeMusicService) TopTracks() ([]Track, error) {
+	return f.top, f.topErr
+}
+
 func (f fakeMusicService) RecentTracks() ([]Track, error) {
 	return f.tracks, f.trackErr
 }
 
-type fakeRecommender struct{
+type fakeRecommender struct {
 	tracks []Track
-	err error
+	err    error
 }
 
 func (f fakeRecommender) Recommendations(int, []Seed) ([]Track, error) {
@@ -40,9 +46,9 @@ func (f fakeRecommender) Recommendations(int, []Seed) ([]Track, error) {
 
 func TestNew(t *testing.T) {
 	tests := []struct {
-		name string
-		serv MusicService
-		rec Recommender
+		name    string
+		serv    MusicService
+		rec     Recommender
 		wantGen *generator
 		wantErr error
 	}{
@@ -91,11 +97,11 @@ func TestNew(t *testing.T) {
 
 func TestGenerator_Tracklist(t *testing.T) {
 	tests := []struct {
-		name string
-		gen generator
-		total int
+		name     string
+		gen      generator
+		total    int
 		wantList []Track
-		wantErr error
+		wantErr  error
 	}{
 		{
 			"Valid responses",
@@ -106,13 +112,13 @@ func TestGenerator_Tracklist(t *testing.T) {
 						{ID: "1", Name: "bar"},
 					},
 					artistErr: nil,
-					tracks: []Track {
+					tracks: []Track{
 						{ID: "10", Name: "baz", Artist: Artist{ID: "0", Name: "foo"}},
 					},
 					trackErr: nil,
 				},
 				rec: fakeRecommender{
-					tracks: []Track {
+					tracks: []Track{
 						{ID: "21", Name: "qux"},
 					},
 					err: nil,
@@ -128,16 +134,16 @@ func TestGenerator_Tracklist(t *testing.T) {
 			"Empty top artists response",
 			generator{
 				serv: fakeMusicService{
-					artists: nil,
+					artists:   nil,
 					artistErr: testErrFetchArtists,
-					tracks: []Track {
+					tracks: []Track{
 						{ID: "10", Name: "baz", Artist: Artist{ID: "0", Name: "foo"}},
 					},
 					trackErr: nil,
 				},
 				rec: fakeRecommender{
 					tracks: nil,
-					err: nil,
+					err:    nil,
 				},
 			},
 			testTotal,
@@ -153,12 +159,12 @@ func TestGenerator_Tracklist(t *testing.T) {
 						{ID: "1", Name: "bar"},
 					},
 					artistErr: nil,
-					tracks: nil,
-					trackErr: testErrFetchTracks,
+					tracks:    nil,
+					trackErr:  testErrFetchTracks,
 				},
 				rec: fakeRecommender{
 					tracks: nil,
-					err: nil,
+					err:    nil,
 				},
 			},
 			testTotal,
@@ -174,14 +180,14 @@ func TestGenerator_Tracklist(t *testing.T) {
 						{ID: "1", Name: "bar"},
 					},
 					artistErr: nil,
-					tracks: []Track {
+					tracks: []Track{
 						{ID: "10", Name: "baz", Artist: Artist{ID: "0", Name: "foo"}},
 					},
 					trackErr: nil,
 				},
 				rec: fakeRecommender{
 					tracks: nil,
-					err: testErrFetchRecommendations,
+					err:    testErrFetchRecommendations,
 				},
 			},
 			testTotal,
@@ -197,14 +203,14 @@ func TestGenerator_Tracklist(t *testing.T) {
 						{ID: "1", Name: "bar"},
 					},
 					artistErr: nil,
-					tracks: []Track {
+					tracks: []Track{
 						{ID: "", Name: "baz", Artist: Artist{ID: "0", Name: "foo"}},
 					},
 					trackErr: nil,
 				},
 				rec: fakeRecommender{
 					tracks: nil,
-					err: nil,
+					err:    nil,
 				},
 			},
 			testTotal,
@@ -220,13 +226,13 @@ func TestGenerator_Tracklist(t *testing.T) {
 						{ID: "1", Name: "bar"},
 					},
 					artistErr: nil,
-					tracks: []Track {
+					tracks: []Track{
 						{ID: "10", Name: "baz", Artist: Artist{ID: "0", Name: "foo"}},
 					},
 					trackErr: nil,
 				},
 				rec: fakeRecommender{
-					tracks: []Track {
+					tracks: []Track{
 						{ID: "21", Name: "qux"},
 					},
 					err: nil,
@@ -234,7 +240,7 @@ func TestGenerator_Tracklist(t *testing.T) {
 			},
 			0,
 			nil,
-			errRangeInvalid,
+			ErrRangeInvalid,
 		},
 	}
 	for _, test := range tests {
@@ -253,11 +259,11 @@ func TestGenerator_Tracklist(t *testing.T) {
 
 func TestGenerator_LimitedTracklist(t *testing.T) {
 	tests := []struct {
-		name string
-		gen generator
-		total int
+		name     string
+		gen      generator
+		total    int
 		wantList []Track
-		wantErr error
+		wantErr  error
 	}{
 		{
 			"Valid responses",
@@ -270,7 +276,7 @@ func TestGenerator_LimitedTracklist(t *testing.T) {
 					artistErr: nil,
 				},
 				rec: fakeRecommender{
-					tracks: []Track {
+					tracks: []Track{
 						{ID: "10", Name: "qux"},
 					},
 					err: nil,
@@ -286,12 +292,12 @@ func TestGenerator_LimitedTracklist(t *testing.T) {
 			"Empty top artists response",
 			generator{
 				serv: fakeMusicService{
-					artists: nil,
+					artists:   nil,
 					artistErr: testErrFetchArtists,
 				},
 				rec: fakeRecommender{
 					tracks: nil,
-					err: nil,
+					err:    nil,
 				},
 			},
 			testTotal,
@@ -310,7 +316,7 @@ func TestGenerator_LimitedTracklist(t *testing.T) {
 				},
 				rec: fakeRecommender{
 					tracks: nil,
-					err: testErrFetchRecommendations,
+					err:    testErrFetchRecommendations,
 				},
 			},
 			testTotal,
@@ -329,7 +335,7 @@ func TestGenerator_LimitedTracklist(t *testing.T) {
 				},
 				rec: fakeRecommender{
 					tracks: nil,
-					err: nil,
+					err:    nil,
 				},
 			},
 			testTotal,
@@ -348,12 +354,12 @@ func TestGenerator_LimitedTracklist(t *testing.T) {
 				},
 				rec: fakeRecommender{
 					tracks: nil,
-					err: nil,
+					err:    nil,
 				},
 			},
 			0,
 			nil,
-			errRangeInvalid,
+			ErrRangeInvalid,
 		},
 	}
 	for _, test := range tests {
@@ -370,6 +376,390 @@ func TestGenerator_LimitedTracklist(t *testing.T) {
 	}
 }
 
+func TestGenerator_TopTracklist(t *testing.T) {
+	tests := []struct {
+		name     string
+		gen      generator
+		total    int
+		wantList []Track
+		wantErr  error
+	}{
+		{
+			"Valid responses",
+			generator{
+				serv: fakeMusicService{
+					artists: []Artist{
+						{ID: "0", Name: "foo"},
+					},
+					top: []Track{
+						{ID: "10", Name: "baz", Artist: Artist{ID: "0", Name: "foo"}},
+					},
+				},
+				rec: fakeRecommender{
+					tracks: []Track{
+						{ID: "20", Name: "qux", Artist: Artist{ID: "0", Name: "foo"}},
+						{ID: "21", Name: "quux", Artist: Artist{ID: "1", Name: "bar"}},
+					},
+				},
+			},
+			testTotal,
+			[]Track{
+				{ID: "21", Name: "quux", Artist: Artist{ID: "1", Name: "bar"}},
+			},
+			nil,
+		},
+		{
+			"Empty top tracks response",
+			generator{
+				serv: fakeMusicService{
+					artists: []Artist{
+						{ID: "0", Name: "foo"},
+					},
+					topErr: testErrFetchTracks,
+				},
+				rec: fakeRecommender{},
+			},
+			testTotal,
+			nil,
+			testErrFetchTracks,
+		},
+		{
+			"Empty top artists response",
+			generator{
+				serv: fakeMusicService{
+					artistErr: testErrFetchArtists,
+					top: []Track{
+						{ID: "10", Name: "baz", Artist: Artist{ID: "0", Name: "foo"}},
+					},
+				},
+				rec: fakeRecommender{},
+			},
+			testTotal,
+			nil,
+			testErrFetchArtists,
+		},
+		{
+			"Empty recommendation response",
+			generator{
+				serv: fakeMusicService{
+					top: []Track{
+						{ID: "10", Name: "baz", Artist: Artist{ID: "0", Name: "foo"}},
+					},
+				},
+				rec: fakeRecommender{
+					err: testErrFetchRecommendations,
+				},
+			},
+			testTotal,
+			nil,
+			testErrFetchRecommendations,
+		},
+		{
+			"Invalid track seed",
+			generator{
+				serv: fakeMusicService{
+					top: []Track{
+						{ID: "", Name: "baz", Artist: Artist{ID: "0", Name: "foo"}},
+					},
+				},
+				rec: fakeRecommender{},
+			},
+			testTotal,
+			nil,
+			errTrackSeed,
+		},
+		{
+			"n out of range",
+			generator{
+				serv: fakeMusicService{},
+				rec:  fakeRecommender{},
+			},
+			0,
+			nil,
+			ErrRangeInvalid,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			list, err := test.gen.TopTracklist(test.total)
+			if !reflect.DeepEqual(errors.Cause(err), test.wantErr) {
+				t.Errorf("got: <%v>, want: <%v>", errors.Cause(err), test.wantErr)
+			}
+
+			if !reflect.DeepEqual(list, test.wantList) {
+				t.Errorf("got: <%v>, want: <%v>", list, test.wantList)
+			}
+		})
+	}
+}
+
+func TestGenerator_TracklistReport(t *testing.T) {
+	tests := []struct {
+		name     string
+		gen      generator
+		total    int
+		wantList []Track
+		wantRep  Report
+		wantErr  error
+	}{
+		{
+			"Known and duplicate artists removed",
+			generator{
+				serv: fakeMusicService{
+					artists: []Artist{
+						{ID: "0", Name: "foo"},
+					},
+					tracks: []Track{
+						{ID: "10", Name: "baz", Artist: Artist{ID: "0", Name: "foo"}},
+						{ID: "11", Name: "qux", Artist: Artist{ID: "0", Name: "foo"}},
+					},
+				},
+				rec: fakeRecommender{
+					tracks: []Track{
+						{ID: "20", Name: "quux", Artist: Artist{ID: "0", Name: "foo"}, Provenance: &Provenance{Group: 0}},
+						{ID: "21", Name: "corge", Artist: Artist{ID: "1", Name: "bar"}, Provenance: &Provenance{Group: 0}},
+						{ID: "22", Name: "grault", Artist: Artist{ID: "1", Name: "bar"}, Provenance: &Provenance{Group: 1}},
+						{ID: "23", Name: "garply", Artist: Artist{ID: "2", Name: "fred"}, Provenance: &Provenance{Group: 1}},
+					},
+				},
+			},
+			testTotal,
+			[]Track{
+				{ID: "21", Name: "corge", Artist: Artist{ID: "1", Name: "bar"}, Provenance: &Provenance{Group: 0}},
+				{ID: "23", Name: "garply", Artist: Artist{ID: "2", Name: "fred"}, Provenance: &Provenance{Group: 1}},
+			},
+			Report{Seeds: 2, SelectedSeeds: 2, SeedGroups: 2, Recommendations: 4, KnownRemoved: 1, DuplicatesRemoved: 1, Final: 2},
+			nil,
+		},
+		{
+			"Report stops at failed stage",
+			generator{
+				serv: fakeMusicService{
+					artistErr: testErrFetchArtists,
+					tracks: []Track{
+						{ID: "10", Name: "baz", Artist: Artist{ID: "0", Name: "foo"}},
+					},
+				},
+				rec: fakeRecommender{
+					tracks: []Track{
+						{ID: "20", Name: "quux", Artist: Artist{ID: "1", Name: "bar"}},
+					},
+				},
+			},
+			testTotal,
+			nil,
+			Report{Seeds: 1, SelectedSeeds: 1, Recommendations: 1},
+			testErrFetchArtists,
+		},
+		{
+			"n out of range",
+			generator{
+				serv: fakeMusicService{},
+				rec:  fakeRecommender{},
+			},
+			0,
+			nil,
+			Report{},
+			ErrRangeInvalid,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			list, rep, err := test.gen.TracklistReport(test.total)
+			if !reflect.DeepEqual(errors.Cause(err), test.wantErr) {
+				t.Errorf("got: <%v>, want: <%v>", errors.Cause(err), test.wantErr)
+			}
+
+			if !reflect.DeepEqual(list, test.wantList) {
+				t.Errorf("got: <%v>, want: <%v>", list, test.wantList)
+			}
+
+			rep.Timings = Timings{}
+			if !reflect.DeepEqual(rep, test.wantRep) {
+				t.Errorf("got: <%v>, want: <%v>", rep, test.wantRep)
+			}
+		})
+	}
+}
+
+func TestGenerator_LimitedTracklistReport(t *testing.T) {
+	gen := generator{
+		serv: fakeMusicService{
+			artists: []Artist{
+				{ID: "0", Name: "foo"},
+				{ID: "1", Name: "bar"},
+			},
+		},
+		rec: fakeRecommender{
+			tracks: []Track{
+				{ID: "20", Name: "quux", Artist: Artist{ID: "1", Name: "bar"}},
+				{ID: "21", Name: "corge", Artist: Artist{ID: "2", Name: "fred"}},
+			},
+		},
+	}
+
+	list, rep, err := gen.LimitedTracklistReport(testTotal)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := Report{Seeds: 2, SelectedSeeds: 2, Recommendations: 2, KnownRemoved: 1, Final: 1}
+	rep.Timings = Timings{}
+	if !reflect.DeepEqual(rep, want) {
+		t.Errorf("got: <%v>, want: <%v>", rep, want)
+	}
+
+	if len(list) != rep.Final {
+		t.Errorf("got: <%v>, want: <%v>", len(list), rep.Final)
+	}
+}
+
+type fakeFeatureService struct {
+	feats map[string]Features
+	err   error
+}
+
+func (f fakeFeatureService) AudioFeatures([]string) (map[string]Features, error) {
+	return f.feats, f.err
+}
+
+func TestGenerator_SetOrderer(t *testing.T) {
+	tests := []struct {
+		name     string
+		ord      Orderer
+		feat     FeatureService
+		wantList []Track
+		wantErr  error
+	}{
+		{
+			"Ordered by tempo",
+			TempoSmooth(),
+			fakeFeatureService{feats: testFeatures},
+			[]Track{testTrackA, testTrackC, testTrackB},
+			nil,
+		},
+		{
+			"Features unavailable",
+			TempoSmooth(),
+			fakeFeatureService{err: testErrFetchTracks},
+			nil,
+			testErrFetchTracks,
+		},
+		{
+			"Nil orderer",
+			nil,
+			fakeFeatureService{},
+			[]Track{testTrackB, testTrackC, testTrackA},
+			errNilOrderer,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			gen := &generator{
+				serv: fakeMusicService{
+					artists: []Artist{{ID: "9", Name: "known"}},
+					tracks:  []Track{{ID: "10", Name: "baz", Artist: Artist{ID: "9", Name: "known"}}},
+				},
+				rec: fakeRecommender{
+					tracks: []Track{testTrackB, testTrackC, testTrackA},
+				},
+			}
+
+			err := gen.SetOrderer(test.ord, test.feat)
+			if err != nil && err != test.wantErr {
+				t.Fatalf("got: <%v>, want: <%v>", err, test.wantErr)
+			}
+
+			list, genErr := gen.Tracklist(testTotal)
+			if err == nil && !reflect.DeepEqual(errors.Cause(genErr), test.wantErr) {
+				t.Errorf("got: <%v>, want: <%v>", errors.Cause(genErr), test.wantErr)
+			}
+
+			if !reflect.DeepEqual(list, test.wantList) {
+				t.Errorf("got: <%v>, want: <%v>", list, test.wantList)
+			}
+		})
+	}
+}
+
+func TestGenerator_SetConstraints(t *testing.T) {
+	gen := &generator{
+		serv: fakeMusicService{
+			artists: []Artist{{ID: "9", Name: "known"}},
+			tracks:  []Track{{ID: "10", Name: "baz", Artist: Artist{ID: "9", Name: "known"}}},
+		},
+		rec: fakeRecommender{
+			tracks: append([]Track{{ID: "11", Name: "qux", Artist: Artist{ID: "9", Name: "known"}}}, testPool...),
+		},
+	}
+
+	if err := gen.SetConstraints(Constraints{MaxPerArtist: -1}); err != errConstraintInvalid {
+		t.Errorf("got: <%v>, want: <%v>", err, errConstraintInvalid)
+	}
+
+	if err := gen.SetConstraints(Constraints{MaxPerArtist: 2, MinArtistSpacing: 1}); err != nil {
+		t.Fatal(err)
+	}
+
+	list, rep, err := gen.TracklistReport(4)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []Track{testPool[0], testPool[2], testPool[1], testPool[3]}
+	if !reflect.DeepEqual(list, want) {
+		t.Errorf("got: <%v>, want: <%v>", list, want)
+	}
+
+	if rep.KnownRemoved != 1 || rep.ConstraintsRemoved != 1 || rep.Final != 4 {
+		t.Errorf("got: <%+v>, want known 1, constraints 1 and final 4", rep)
+	}
+}
+
+type fakeTunedRecommender struct {
+	fakeRecommender
+	tune *Tuning
+}
+
+func (f fakeTunedRecommender) TunedRecommendations(n int, sds []Seed, t Tuning) ([]Track, error) {
+	*f.tune = t
+	return f.tracks, f.err
+}
+
+func TestGenerator_SetPreset(t *testing.T) {
+	gen := &generator{serv: fakeMusicService{}, rec: fakeRecommender{}}
+	if err := gen.SetPreset("focus"); err != ErrNoTuning {
+		t.Errorf("got: <%v>, want: <%v>", err, ErrNoTuning)
+	}
+
+	var tune Tuning
+	gen = &generator{
+		serv: fakeMusicService{
+			tracks: []Track{{ID: "10", Name: "baz", Artist: Artist{ID: "0", Name: "foo"}}},
+		},
+		rec: fakeTunedRecommender{
+			fakeRecommender: fakeRecommender{tracks: []Track{testTrackA}},
+			tune:            &tune,
+		},
+	}
+
+	if err := gen.SetPreset("sleep"); errors.Cause(err) != errPresetUnknown {
+		t.Errorf("got: <%v>, want: <%v>", errors.Cause(err), errPresetUnknown)
+	}
+
+	if err := gen.SetPreset("workout"); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := gen.Tracklist(testTotal); err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(tune, Presets["workout"]) {
+		t.Errorf("got: <%v>, want: <%v>", tune, Presets["workout"])
+	}
+}
+
 func TestToMap(t *testing.T) {
 	tests := []struct {
 		name string
@@ -406,19 +796,19 @@ func TestToMap(t *testing.T) {
 				{ID: "6", Name: "six"},
 			},
 			map[string]Artist{
-				"one": {ID: "1", Name: "one"},
-				"two": {ID: "2", Name: "two"},
+				"one":   {ID: "1", Name: "one"},
+				"two":   {ID: "2", Name: "two"},
 				"three": {ID: "3", Name: "three"},
-				"four": {ID: "4", Name: "four"},
-				"five": {ID: "5", Name: "five"},
-				"six": {ID: "6", Name: "six"},
+				"four":  {ID: "4", Name: "four"},
+				"five":  {ID: "5", Name: "five"},
+				"six":   {ID: "6", Name: "six"},
 			},
 		},
 	}
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
 			got := toMap(test.prev)
-			if !reflect.DeepEqual(got, test.want){
+			if !reflect.DeepEqual(got, test.want) {
 				t.Errorf("got: <%v>, want: <%v>", got, test.want)
 			}
 		})
@@ -429,7 +819,7 @@ func TestFilter(t *testing.T) {
 	tests := []struct {
 		name string
 		prev []Track
-		rmv map[string]Artist
+		rmv  map[string]Artist
 		want []Track
 	}{
 		{
@@ -461,7 +851,7 @@ func TestFilter(t *testing.T) {
 			nil,
 			map[string]Artist{
 				"grault": {ID: "11", Name: "grault"},
-				"fred": {ID: "14", Name: "fred"},
+				"fred":   {ID: "14", Name: "fred"},
 			},
 			nil,
 		},
@@ -493,7 +883,7 @@ func TestFilter(t *testing.T) {
 			},
 			map[string]Artist{
 				"grault": {ID: "11", Name: "grault"},
-				"fred": {ID: "14", Name: "fred"},
+				"fred":   {ID: "14", Name: "fred"},
 			},
 			[]Track{
 				{ID: "2", Name: "bar", Artist: Artist{ID: "12", Name: "garply"}},
@@ -504,9 +894,219 @@ func TestFilter(t *testing.T) {
 		t.Run(test.name, func(t *testing.T) {
 			got := filter(test.prev, test.rmv)
 
-			if !reflect.DeepEqual(got, test.want){
+			if !reflect.DeepEqual(got, test.want) {
 				t.Errorf("got: <%v>, want: <%v>", got, test.want)
 			}
 		})
 	}
 }
+
+func TestGenerator_SetSelector(t *testing.T) {
+	tests := []struct {
+		name    string
+		n       int
+		sel     Selector
+		wantErr error
+	}{
+		{"Valid selector", 5, FrequencyWeighted(), nil},
+		{"Nil selector", 5, nil, errNilSelector},
+		{"n out of range", 0, FrequencyWeighted(), ErrRangeInvalid},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			g := &generator{serv: fakeMusicService{}, rec: fakeRecommender{}}
+
+			err := g.SetSelector(test.n, test.sel)
+			if err != test.wantErr {
+				t.Errorf("got: <%v>, want: <%v>", err, test.wantErr)
+			}
+		})
+	}
+}
+
+func TestGenerator_selectSeeds(t *testing.T) {
+	sds := []Seed{
+		{Category: TrackSeed, ID: "0"},
+		{Category: TrackSeed, ID: "1"},
+		{Category: TrackSeed, ID: "1"},
+	}
+
+	g := generator{}
+	if got := g.selectSeeds(sds); !reflect.DeepEqual(got, sds) {
+		t.Errorf("got: <%v>, want: <%v>", got, sds)
+	}
+
+	g = generator{sel: FrequencyWeighted(), seeds: 1}
+	want := []Seed{{Category: TrackSeed, ID: "1"}}
+	if got := g.selectSeeds(sds); !reflect.DeepEqual(got, want) {
+		t.Errorf("got: <%v>, want: <%v>", got, want)
+	}
+}
+
+type fakeRangedMusicService struct {
+	fakeMusicService
+	ranged    []Artist
+	rangedErr error
+}
+
+func (f fakeRangedMusicService) TopArtistsRange(TimeRange, int) ([]Artist, error) {
+	return f.ranged, f.rangedErr
+}
+
+func (f fakeRangedMusicService) TopTracksRange(TimeRange, int) ([]Track, error) {
+	return nil, nil
+}
+
+func TestGenerator_RangedTracklist(t *testing.T) {
+	tests := []struct {
+		name     string
+		gen      generator
+		total    int
+		wantList []Track
+		wantErr  error
+	}{
+		{
+			"Valid responses",
+			generator{
+				serv: fakeRangedMusicService{
+					fakeMusicService: fakeMusicService{
+						artists: []Artist{
+							{ID: "0", Name: "foo"},
+							{ID: "1", Name: "bar"},
+						},
+					},
+					ranged: []Artist{
+						{ID: "0", Name: "foo"},
+					},
+				},
+				rec: fakeRecommender{
+					tracks: []Track{
+						{ID: "10", Name: "qux", Artist: Artist{ID: "1", Name: "bar"}},
+						{ID: "11", Name: "quux", Artist: Artist{ID: "2", Name: "baz"}},
+					},
+				},
+			},
+			testTotal,
+			[]Track{
+				{ID: "11", Name: "quux", Artist: Artist{ID: "2", Name: "baz"}},
+			},
+			nil,
+		},
+		{
+			"Unsupported music service",
+			generator{
+				serv: fakeMusicService{},
+				rec:  fakeRecommender{},
+			},
+			testTotal,
+			nil,
+			ErrNoRanges,
+		},
+		{
+			"Empty ranged artists response",
+			generator{
+				serv: fakeRangedMusicService{
+					rangedErr: testErrFetchArtists,
+				},
+				rec: fakeRecommender{},
+			},
+			testTotal,
+			nil,
+			testErrFetchArtists,
+		},
+		{
+			"Invalid artist seed",
+			generator{
+				serv: fakeRangedMusicService{
+					ranged: []Artist{
+						{ID: "", Name: "foo"},
+					},
+				},
+				rec: fakeRecommender{},
+			},
+			testTotal,
+			nil,
+			errArtistSeed,
+		},
+		{
+			"n out of range",
+			generator{
+				serv: fakeRangedMusicService{},
+				rec:  fakeRecommender{},
+			},
+			0,
+			nil,
+			ErrRangeInvalid,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			list, err := test.gen.RangedTracklist(test.total, ShortTerm)
+			if !reflect.DeepEqual(errors.Cause(err), test.wantErr) {
+				t.Errorf("got: <%v>, want: <%v>", errors.Cause(err), test.wantErr)
+			}
+
+			if !reflect.DeepEqual(list, test.wantList) {
+				t.Errorf("got: <%v>, want: <%v>", list, test.wantList)
+			}
+		})
+	}
+}
+
+// groupedRecommender splits seeds into pairs like a recommender limited to
+// two seeds per request.
+type groupedRecommender struct {
+	fakeRecommender
+}
+
+func (groupedRecommender) SeedGroups(sds []Seed, t Tuning) int {
+	return (len(sds) + 1) / 2
+}
+
+func TestGenerator_ReportSeeds(t *testing.T) {
+	serv := fakeMusicService{
+		tracks: []Track{
+			{ID: "10", Artist: Artist{ID: "0"}},
+			{ID: "11", Artist: Artist{ID: "1"}},
+			{ID: "12", Artist: Artist{ID: "2"}},
+			{ID: "13", Artist: Artist{ID: "3"}},
+			{ID: "14", Artist: Artist{ID: "4"}},
+		},
+	}
+
+	// Only the first group produces recommendations.
+	recs := []Track{{ID: "20", Artist: Artist{ID: "5"}, Provenance: &Provenance{Group: 0}}}
+
+	tests := []struct {
+		name       string
+		rec        Recommender
+		opts       []Option
+		wantSeeds  int
+		wantSel    int
+		wantGroups int
+	}{
+		{"Every seed", fakeRecommender{tracks: recs}, nil, 5, 5, 1},
+		{"Selected seeds", fakeRecommender{tracks: recs}, []Option{WithSelector(3, RecencyWeighted())}, 5, 3, 1},
+		{"Requested groups", groupedRecommender{fakeRecommender{tracks: recs}}, nil, 5, 5, 3},
+		{"Requested groups of selected seeds", groupedRecommender{fakeRecommender{tracks: recs}}, []Option{WithSelector(4, RecencyWeighted())}, 5, 4, 2},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			g, err := New(serv, test.rec, test.opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			_, rep, err := g.TracklistReport(testTotal)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got := []int{rep.Seeds, rep.SelectedSeeds, rep.SeedGroups}
+			want := []int{test.wantSeeds, test.wantSel, test.wantGroups}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("got: <%v>, want: <%v>", got, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,162 @@
+package graph
+
+import (
+	"encoding/json"
+	"github.com/Henry-Sarabia/refind"
+	"github.com/pkg/errors"
+	"io"
+	"os"
+	"sync"
+)
+
+var errCacheMiss = errors.New("artist is not in the graph cache")
+
+// Source provides the related artists and most popular tracks of an artist.
+type Source interface {
+	RelatedArtists(id string) ([]refind.Artist, error)
+	ArtistTopTracks(id string) ([]refind.Track, error)
+}
+
+// Cache stores the parts of the related artists graph that have already been
+// fetched so they can be reused across runs. A Cache is itself a Source that
+// only answers from what it holds.
+type Cache struct {
+	mu        sync.Mutex
+	Related   map[string][]refind.Artist `json:"related"`
+	TopTracks map[string][]refind.Track  `json:"top_tracks"`
+}
+
+func NewCache() *Cache {
+	return &Cache{
+		Related:   make(map[string][]refind.Artist),
+		TopTracks: make(map[string][]refind.Track),
+	}
+}
+
+// LoadCache decodes a cache previously written by Save.
+func LoadCache(r io.Reader) (*Cache, error) {
+	c := NewCache()
+	if err := json.NewDecoder(r).Decode(c); err != nil {
+		return nil, errors.Wrap(err, "cannot decode graph cache")
+	}
+
+	if c.Related == nil {
+		c.Related = make(map[string][]refind.Artist)
+	}
+	if c.TopTracks == nil {
+		c.TopTracks = make(map[string][]refind.Track)
+	}
+
+	return c, nil
+}
+
+// LoadCacheFile reads a cache from the named file. A missing file results in
+// an empty cache.
+func LoadCacheFile(name string) (*Cache, error) {
+	f, err := os.Open(name)
+	if os.IsNotExist(err) {
+		return NewCache(), nil
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot open graph cache")
+	}
+	defer f.Close()
+
+	return LoadCache(f)
+}
+
+func (c *Cache) Save(w io.Writer) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(c); err != nil {
+		return errors.Wrap(err, "cannot encode graph cache")
+	}
+
+	return nil
+}
+
+func (c *Cache) SaveFile(name string) error {
+	f, err := os.Create(name)
+	if err != nil {
+		return errors.Wrap(err, "cannot create graph cache")
+	}
+
+	if err := c.Save(f); err != nil {
+		f.Close()
+		return err
+	}
+
+	return f.Close()
+}
+
+func (c *Cache) RelatedArtists(id string) ([]refind.Artist, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	arts, ok := c.Related[id]
+	if !ok {
+		return nil, errCacheMiss
+	}
+
+	return arts, nil
+}
+
+func (c *Cache) ArtistTopTracks(id string) ([]refind.Track, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	trks, ok := c.TopTracks[id]
+	if !ok {
+		return nil, errCacheMiss
+	}
+
+	return trks, nil
+}
+
+type cached struct {
+	src   Source
+	cache *Cache
+}
+
+// Cached returns a Source that answers from the cache when it can and stores
+// every response of src in it otherwise.
+func Cached(src Source, c *Cache) Source {
+	return cached{src: src, cache: c}
+}
+
+func (c cached) RelatedArtists(id string) ([]refind.Artist, error) {
+	if arts, err := c.cache.RelatedArtists(id); err == nil {
+		return arts, nil
+	}
+
+	arts, err := c.src.RelatedArtists(id)
+	if err != nil {
+		return nil, err
+	}
+
+	c.cache.mu.Lock()
+	c.cache.Related[id] = arts
+	c.cache.mu.Unlock()
+
+	return arts, nil
+}
+
+func (c cached) ArtistTopTracks(id string) ([]refind.Track, error) {
+	if trks, err := c.cache.ArtistTopTracks(id); err == nil {
+		return trks, nil
+	}
+
+	trks, err := c.src.ArtistTopTracks(id)
+	if err != nil {
+		return nil, err
+	}
+
+	c.cache.mu.Lock()
+	c.cache.TopTracks[id] = trks
+	c.cache.mu.Unlock()
+
+	return trks, nil
+}
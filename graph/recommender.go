@@ -0,0 +1,275 @@
+package graph
+
+import (
+	"github.com/Henry-Sarabia/refind"
+	"github.com/pkg/errors"
+	"math/rand"
+)
+
+// walkFactor bounds the number of random walks to this many per requested
+// track so that sparse graphs cannot loop forever.
+const walkFactor int = 10
+
+var (
+	errNilSource = errors.New("cannot initialize recommender using nil source")
+)
+
+// TrackSource provides the full data of tracks, including their artists.
+type TrackSource interface {
+	Tracks(ids []string) ([]refind.Track, error)
+}
+
+type recommender struct {
+	src    Source
+	trks   TrackSource
+	depth  int
+	random bool
+	seed   int64
+	known  map[string]bool
+}
+
+// New returns a Recommender that discovers artists by walking the related
+// artists graph breadth first from the artist seeds, up to depth hops away,
+// and recommends the most popular track of each discovered artist. Track
+// seeds are only used once a TrackSource is set and genre seeds are ignored.
+func New(src Source, depth int) (*recommender, error) {
+	if src == nil {
+		return nil, errNilSource
+	}
+
+	if depth <= 0 {
+		return nil, refind.ErrRangeInvalid
+	}
+
+	return &recommender{src: src, depth: depth, known: make(map[string]bool)}, nil
+}
+
+// SetRandomWalk replaces the breadth first search with random walks of at
+// most depth hops that start from a random seed artist.
+func (r *recommender) SetRandomWalk(seed int64) {
+	r.random = true
+	r.seed = seed
+}
+
+// SetKnown makes the walk pass through but never recommend the given
+// artists, such as a user's top artists.
+func (r *recommender) SetKnown(arts []refind.Artist) {
+	for _, a := range arts {
+		r.known[a.ID] = true
+	}
+}
+
+// SetTrackSource makes the walk also start from the artists of track seeds,
+// which are looked up in ts.
+func (r *recommender) SetTrackSource(ts TrackSource) {
+	r.trks = ts
+}
+
+type discovery struct {
+	artist refind.Artist
+	root   int
+	depth  int
+}
+
+func (r *recommender) Recommendations(n int, seeds []refind.Seed) ([]refind.Track, error) {
+	if n <= 0 {
+		return nil, refind.ErrRangeInvalid
+	}
+
+	roots, origins, err := r.roots(seeds)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(roots) == 0 {
+		return nil, errors.Wrap(refind.ErrSeedsMissing, "no artist seeds to start from")
+	}
+
+	var found []discovery
+	if r.random {
+		found, err = r.walk(n, roots)
+	} else {
+		found, err = r.bfs(n, roots)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var list []refind.Track
+	for _, d := range found {
+		trks, err := r.src.ArtistTopTracks(d.artist.ID)
+		if err != nil {
+			return nil, errors.Wrap(err, "cannot fetch artist top tracks")
+		}
+
+		if len(trks) == 0 {
+			continue
+		}
+
+		t := trks[0]
+		t.Provenance = &refind.Provenance{
+			Group:      d.root,
+			Attributes: map[string]float64{"depth": float64(d.depth)},
+		}
+		if o := origins[d.root]; o.Category == refind.TrackSeed {
+			t.Provenance.Tracks = []string{o.ID}
+		} else {
+			t.Provenance.Artists = []string{o.ID}
+		}
+		list = append(list, t)
+
+		if len(list) == n {
+			break
+		}
+	}
+
+	return list, nil
+}
+
+// roots returns the artists to start walking from in seed order, along with
+// the seed each one came from. Track seeds are resolved to their artists
+// when a TrackSource is set.
+func (r *recommender) roots(seeds []refind.Seed) ([]string, []refind.Seed, error) {
+	artists := make(map[string]string)
+	if r.trks != nil {
+		var ids []string
+		for _, sd := range seeds {
+			if sd.Category == refind.TrackSeed {
+				ids = append(ids, sd.ID)
+			}
+		}
+
+		if len(ids) > 0 {
+			trks, err := r.trks.Tracks(ids)
+			if err != nil {
+				return nil, nil, errors.Wrap(err, "cannot fetch seed tracks")
+			}
+
+			for _, t := range trks {
+				artists[t.ID] = t.Artist.ID
+			}
+		}
+	}
+
+	var roots []string
+	var origins []refind.Seed
+	seen := make(map[string]bool)
+	for _, sd := range seeds {
+		id := sd.ID
+		switch sd.Category {
+		case refind.ArtistSeed:
+		case refind.TrackSeed:
+			id = artists[sd.ID]
+		default:
+			continue
+		}
+
+		if id == "" || seen[id] {
+			continue
+		}
+		seen[id] = true
+		roots = append(roots, id)
+		origins = append(origins, sd)
+	}
+
+	return roots, origins, nil
+}
+
+func (r *recommender) skip(id string, roots []string) bool {
+	if r.known[id] {
+		return true
+	}
+
+	for _, rt := range roots {
+		if rt == id {
+			return true
+		}
+	}
+
+	return false
+}
+
+// bfs expands every root one level at a time so that discoveries stay as
+// close to the seeds as possible.
+func (r *recommender) bfs(n int, roots []string) ([]discovery, error) {
+	type node struct {
+		id    string
+		root  int
+		depth int
+	}
+
+	seen := make(map[string]bool)
+	var queue []node
+	for i, rt := range roots {
+		seen[rt] = true
+		queue = append(queue, node{id: rt, root: i})
+	}
+
+	var found []discovery
+	for len(queue) > 0 && len(found) < n {
+		cur := queue[0]
+		queue = queue[1:]
+
+		if cur.depth >= r.depth {
+			continue
+		}
+
+		rel, err := r.src.RelatedArtists(cur.id)
+		if err != nil {
+			return nil, errors.Wrap(err, "cannot fetch related artists")
+		}
+
+		for _, a := range rel {
+			if seen[a.ID] {
+				continue
+			}
+			seen[a.ID] = true
+			queue = append(queue, node{id: a.ID, root: cur.root, depth: cur.depth + 1})
+
+			if !r.skip(a.ID, roots) {
+				found = append(found, discovery{artist: a, root: cur.root, depth: cur.depth + 1})
+			}
+		}
+	}
+
+	return found, nil
+}
+
+// walk follows random related artists from random roots and keeps every new
+// artist it passes.
+func (r *recommender) walk(n int, roots []string) ([]discovery, error) {
+	rnd := rand.New(rand.NewSource(r.seed))
+	seen := make(map[string]bool)
+	for _, rt := range roots {
+		seen[rt] = true
+	}
+
+	var found []discovery
+	for w := 0; w < n*walkFactor && len(found) < n; w++ {
+		root := rnd.Intn(len(roots))
+		cur := roots[root]
+		for d := 1; d <= r.depth; d++ {
+			rel, err := r.src.RelatedArtists(cur)
+			if err != nil {
+				return nil, errors.Wrap(err, "cannot fetch related artists")
+			}
+
+			if len(rel) == 0 {
+				break
+			}
+
+			a := rel[rnd.Intn(len(rel))]
+			cur = a.ID
+			if seen[a.ID] {
+				continue
+			}
+			seen[a.ID] = true
+
+			if !r.skip(a.ID, roots) {
+				found = append(found, discovery{artist: a, root: root, depth: d})
+			}
+		}
+	}
+
+	return found, nil
+}
@@ -0,0 +1,289 @@
+package graph
+
+import (
+	"bytes"
+	"github.com/Henry-Sarabia/refind"
+	"github.com/pkg/errors"
+	"os"
+	"reflect"
+	"testing"
+)
+
+const testFileGraph string = "test_data/graph.json"
+
+var testRoots = []refind.Seed{
+	{Category: refind.ArtistSeed, ID: "r1"},
+	{Category: refind.ArtistSeed, ID: "r2"},
+}
+
+func testCache(t *testing.T) *Cache {
+	f, err := os.Open(testFileGraph)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	c, err := LoadCache(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return c
+}
+
+func testIDs(list []refind.Track) []string {
+	var IDs []string
+	for _, l := range list {
+		IDs = append(IDs, l.ID)
+	}
+
+	return IDs
+}
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name    string
+		src     Source
+		depth   int
+		wantErr error
+	}{
+		{"Valid source", NewCache(), 2, nil},
+		{"Nil source", nil, 2, errNilSource},
+		{"Depth out of range", NewCache(), 0, refind.ErrRangeInvalid},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := New(test.src, test.depth)
+			if err != test.wantErr {
+				t.Errorf("got: <%v>, want: <%v>", err, test.wantErr)
+			}
+		})
+	}
+}
+
+func TestRecommender_Recommendations(t *testing.T) {
+	tests := []struct {
+		name    string
+		depth   int
+		n       int
+		seeds   []refind.Seed
+		wantIDs []string
+		wantErr error
+	}{
+		{
+			"Breadth first within depth",
+			2,
+			10,
+			testRoots,
+			[]string{"ta1", "ta2a", "tb1", "tb2", "tb3"},
+			nil,
+		},
+		{
+			"Single hop",
+			1,
+			10,
+			testRoots,
+			[]string{"ta1", "ta2a"},
+			nil,
+		},
+		{
+			"Limited total",
+			2,
+			1,
+			testRoots,
+			[]string{"ta1"},
+			nil,
+		},
+		{
+			"Track seeds only",
+			2,
+			10,
+			[]refind.Seed{{Category: refind.TrackSeed, ID: "ta1"}},
+			nil,
+			refind.ErrSeedsMissing,
+		},
+		{
+			"Artist missing from offline graph",
+			2,
+			10,
+			[]refind.Seed{{Category: refind.ArtistSeed, ID: "x"}},
+			nil,
+			errCacheMiss,
+		},
+		{
+			"n out of range",
+			2,
+			0,
+			testRoots,
+			nil,
+			refind.ErrRangeInvalid,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			r, err := New(testCache(t), test.depth)
+			if err != nil {
+				t.Fatal(err)
+			}
+			r.SetKnown([]refind.Artist{{ID: "k1", Name: "Muse"}})
+
+			list, err := r.Recommendations(test.n, test.seeds)
+			if errors.Cause(err) != test.wantErr {
+				t.Errorf("got: <%v>, want: <%v>", errors.Cause(err), test.wantErr)
+			}
+
+			if got := testIDs(list); !reflect.DeepEqual(got, test.wantIDs) {
+				t.Errorf("got: <%v>, want: <%v>", got, test.wantIDs)
+			}
+		})
+	}
+}
+
+type fakeTrackSource map[string]string
+
+func (f fakeTrackSource) Tracks(ids []string) ([]refind.Track, error) {
+	var list []refind.Track
+	for _, id := range ids {
+		if a, ok := f[id]; ok {
+			list = append(list, refind.Track{ID: id, Artist: refind.Artist{ID: a}})
+		}
+	}
+
+	return list, nil
+}
+
+func TestRecommender_TrackSeeds(t *testing.T) {
+	r, err := New(testCache(t), 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.SetTrackSource(fakeTrackSource{"s1": "r2", "s2": "r2"})
+
+	seeds := []refind.Seed{
+		{Category: refind.TrackSeed, ID: "s1"},
+		{Category: refind.TrackSeed, ID: "s2"},
+		{Category: refind.TrackSeed, ID: "unknown"},
+	}
+	list, err := r.Recommendations(10, seeds)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := &refind.Provenance{Group: 0, Tracks: []string{"s1"}, Attributes: map[string]float64{"depth": 1}}
+	if len(list) != 1 || !reflect.DeepEqual(list[0].Provenance, want) {
+		t.Errorf("got: <%v>, want: <%v>", list, want)
+	}
+}
+
+func TestRecommender_Provenance(t *testing.T) {
+	r, err := New(testCache(t), 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	list, err := r.Recommendations(10, testRoots[1:])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := &refind.Provenance{Group: 0, Artists: []string{"r2"}, Attributes: map[string]float64{"depth": 1}}
+	if len(list) != 1 || !reflect.DeepEqual(list[0].Provenance, want) {
+		t.Errorf("got: <%v>, want: <%v>", list, want)
+	}
+}
+
+func TestRecommender_RandomWalk(t *testing.T) {
+	skip := map[string]bool{"tk1": true}
+	for seed := int64(0); seed < 10; seed++ {
+		r, err := New(testCache(t), 2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		r.SetKnown([]refind.Artist{{ID: "k1", Name: "Muse"}})
+		r.SetRandomWalk(seed)
+
+		got, err := r.Recommendations(3, testRoots)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		again, err := r.Recommendations(3, testRoots)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !reflect.DeepEqual(got, again) {
+			t.Errorf("seed %d: got: <%v>, want: <%v>", seed, testIDs(again), testIDs(got))
+		}
+
+		if len(got) > 3 {
+			t.Errorf("seed %d: got %d tracks, want at most 3", seed, len(got))
+		}
+
+		for _, tr := range got {
+			if skip[tr.ID] {
+				t.Errorf("seed %d: got track %s by known artist", seed, tr.ID)
+			}
+		}
+	}
+}
+
+type countingSource struct {
+	src   Source
+	calls *int
+}
+
+func (c countingSource) RelatedArtists(id string) ([]refind.Artist, error) {
+	*c.calls++
+	return c.src.RelatedArtists(id)
+}
+
+func (c countingSource) ArtistTopTracks(id string) ([]refind.Track, error) {
+	*c.calls++
+	return c.src.ArtistTopTracks(id)
+}
+
+func TestCached(t *testing.T) {
+	var calls int
+	c := NewCache()
+	src := Cached(countingSource{src: testCache(t), calls: &calls}, c)
+
+	r, err := New(src, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want, err := r.Recommendations(10, testRoots)
+	if err != nil {
+		t.Fatal(err)
+	}
+	first := calls
+
+	var buf bytes.Buffer
+	if err := c.Save(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := LoadCache(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r, err = New(Cached(countingSource{src: testCache(t), calls: &calls}, loaded), 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := r.Recommendations(10, testRoots)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if calls != first {
+		t.Errorf("got: <%v>, want: <%v>", calls, first)
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got: <%v>, want: <%v>", testIDs(got), testIDs(want))
+	}
+}
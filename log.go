@@ -0,0 +1,48 @@
+package refind
+
+import (
+	"regexp"
+)
+
+const redacted string = "[REDACTED]"
+
+// Logger receives log records as a message followed by alternating keys and
+// values. A *slog.Logger from log/slog satisfies it.
+type Logger interface {
+	Debug(msg string, args ...interface{})
+	Info(msg string, args ...interface{})
+	Warn(msg string, args ...interface{})
+	Error(msg string, args ...interface{})
+}
+
+type nopLogger struct{}
+
+func (nopLogger) Debug(string, ...interface{}) {}
+func (nopLogger) Info(string, ...interface{})  {}
+func (nopLogger) Warn(string, ...interface{})  {}
+func (nopLogger) Error(string, ...interface{}) {}
+
+// NopLogger returns a Logger that discards every record.
+func NopLogger() Logger {
+	return nopLogger{}
+}
+
+var secrets = []*regexp.Regexp{
+	regexp.MustCompile(`(?i)(bearer\s+)[a-z0-9\-._~+/]+=*`),
+	regexp.MustCompile(`(?i)((?:access_token|refresh_token|id_token|client_secret)["']?\s*[:=]\s*["']?)[^"'&\s,}]+`),
+}
+
+// Redact replaces OAuth tokens, client secrets and bearer credentials in s so
+// that error messages can be logged safely.
+func Redact(s string) string {
+	for _, re := range secrets {
+		s = re.ReplaceAllString(s, "${1}"+redacted)
+	}
+
+	return s
+}
+
+// redactErr returns the redacted message of err.
+func redactErr(err error) string {
+	return Redact(err.Error())
+}
@@ -0,0 +1,128 @@
+package refind
+
+import (
+	"github.com/pkg/errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type logRecord struct {
+	level string
+	msg   string
+	args  []interface{}
+}
+
+type recordingLogger struct {
+	records *[]logRecord
+}
+
+func (r recordingLogger) add(level, msg string, args []interface{}) {
+	*r.records = append(*r.records, logRecord{level: level, msg: msg, args: args})
+}
+
+func (r recordingLogger) Debug(msg string, args ...interface{}) { r.add("debug", msg, args) }
+func (r recordingLogger) Info(msg string, args ...interface{})  { r.add("info", msg, args) }
+func (r recordingLogger) Warn(msg string, args ...interface{})  { r.add("warn", msg, args) }
+func (r recordingLogger) Error(msg string, args ...interface{}) { r.add("error", msg, args) }
+
+// arg returns the value logged under key.
+func (l logRecord) arg(key string) interface{} {
+	for i := 0; i+1 < len(l.args); i += 2 {
+		if l.args[i] == key {
+			return l.args[i+1]
+		}
+	}
+
+	return nil
+}
+
+func TestRedact(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"No secrets", "cannot fetch top artists", "cannot fetch top artists"},
+		{"Bearer header", "Authorization: Bearer BQD4x-y_z.1/2+3==", "Authorization: Bearer [REDACTED]"},
+		{"JSON token", `{"access_token":"abc123","token_type":"Bearer"}`, `{"access_token":"[REDACTED]","token_type":"Bearer"}`},
+		{"Form values", "refresh_token=def456&client_secret=s3cr3t&grant_type=refresh_token", "refresh_token=[REDACTED]&client_secret=[REDACTED]&grant_type=refresh_token"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := Redact(test.in)
+			if got != test.want {
+				t.Errorf("got: <%v>, want: <%v>", got, test.want)
+			}
+		})
+	}
+}
+
+func TestGenerator_Logging(t *testing.T) {
+	tests := []struct {
+		name      string
+		serv      MusicService
+		wantMsgs  []string
+		wantError string
+	}{
+		{
+			name: "Every stage",
+			serv: fakeMusicService{
+				artists: []Artist{testArtistA},
+				tracks:  []Track{testTrackA, testTrackB},
+			},
+			wantMsgs: []string{"seeds selected", "seed IDs", "recommendations fetched", "tracks filtered", "tracklist generated"},
+		},
+		{
+			name:      "Failed stage",
+			serv:      fakeMusicService{trackErr: errors.New("401 Unauthorized: access_token=abc123")},
+			wantMsgs:  []string{"generation failed"},
+			wantError: "cannot fetch recent tracks: 401 Unauthorized: access_token=[REDACTED]",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var recs []logRecord
+			g, err := New(test.serv, fakeRecommender{tracks: []Track{testTrackC}}, WithLogger(recordingLogger{records: &recs}))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			g.Tracklist(testTotal)
+
+			var msgs []string
+			for _, r := range recs {
+				msgs = append(msgs, r.msg)
+			}
+
+			if !reflect.DeepEqual(msgs, test.wantMsgs) {
+				t.Fatalf("got: <%v>, want: <%v>", msgs, test.wantMsgs)
+			}
+
+			last := recs[len(recs)-1]
+			if test.wantError != "" && last.arg("error") != test.wantError {
+				t.Errorf("got: <%v>, want: <%v>", last.arg("error"), test.wantError)
+			}
+
+			for _, r := range recs {
+				if r.msg == "seed IDs" && r.level != "debug" {
+					t.Errorf("got: <%v>, want: <%v>", r.level, "debug")
+				}
+
+				if r.msg == "seeds selected" && r.arg("seeds") != 2 {
+					t.Errorf("got: <%v>, want: <%v>", r.arg("seeds"), 2)
+				}
+
+				if strings.Contains(r.msg, "abc123") {
+					t.Errorf("got: <%v>, want token redacted", r.msg)
+				}
+			}
+		})
+	}
+}
+
+func TestNew_NilLogger(t *testing.T) {
+	if _, err := New(fakeMusicService{}, fakeRecommender{}, WithLogger(nil)); err != errNilLogger {
+		t.Errorf("got: <%v>, want: <%v>", err, errNilLogger)
+	}
+}
@@ -0,0 +1,204 @@
+package match
+
+import (
+	"github.com/Henry-Sarabia/blank"
+	"github.com/Henry-Sarabia/refind"
+	"github.com/pkg/errors"
+	"math"
+	"regexp"
+	"strings"
+	"time"
+	"unicode"
+)
+
+const (
+	durationTolerance time.Duration = 3 * time.Second
+	titleWeight       float64       = 0.6
+	artistWeight      float64       = 0.3
+	durationWeight    float64       = 0.1
+	durationUnknown   float64       = 0.5
+)
+
+var (
+	errNilSearcher  = errors.New("cannot initialize new matcher using nil interface")
+	errRangeInvalid = errors.New("confidence parameter is out of range")
+)
+
+var (
+	bracketed = regexp.MustCompile(`\([^)]*\)|\[[^\]]*\]`)
+	featuring = regexp.MustCompile(`\s(feat\.?|ft\.?|featuring)\s.*$`)
+	remaster  = regexp.MustCompile(`\s-\s.*(remaster|mono|stereo|single version|album version).*$`)
+)
+
+type Method int
+
+const (
+	ISRC Method = iota
+	Metadata
+)
+
+// Result is a candidate track that was matched to a track from another
+// provider along with how the match was made and how sure it is.
+type Result struct {
+	Track      refind.Track
+	Method     Method
+	Confidence float64
+}
+
+// Searcher finds candidate tracks in one provider's catalog that may be
+// the same recording as a track from another provider.
+type Searcher interface {
+	Search(refind.Track) ([]refind.Track, error)
+}
+
+// Match returns the candidate that most likely is the same recording as t.
+// An ISRC match is exact. Otherwise titles and artists are compared after
+// normalization and durations must fall within a small tolerance. Tracks
+// from recommendations carry no ISRC until they are looked up with a
+// refind.CatalogService, so they are always matched by metadata.
+func Match(t refind.Track, cands []refind.Track) (Result, bool) {
+	if !blank.Is(t.ISRC) {
+		for _, c := range cands {
+			if strings.EqualFold(t.ISRC, c.ISRC) {
+				return Result{Track: c, Method: ISRC, Confidence: 1}, true
+			}
+		}
+	}
+
+	var best Result
+	var ok bool
+	for _, c := range cands {
+		conf, valid := confidence(t, c)
+		if valid && conf > best.Confidence {
+			best = Result{Track: c, Method: Metadata, Confidence: conf}
+			ok = true
+		}
+	}
+
+	return best, ok
+}
+
+type matcher struct {
+	search Searcher
+	min    float64
+}
+
+// New returns a matcher that looks up tracks through s and only accepts
+// matches with a confidence of at least min.
+func New(s Searcher, min float64) (*matcher, error) {
+	if s == nil {
+		return nil, errNilSearcher
+	}
+
+	if min < 0 || min > 1 {
+		return nil, errRangeInvalid
+	}
+
+	return &matcher{search: s, min: min}, nil
+}
+
+func (m *matcher) Find(t refind.Track) (Result, bool, error) {
+	cands, err := m.search.Search(t)
+	if err != nil {
+		return Result{}, false, errors.Wrap(err, "cannot search for track candidates")
+	}
+
+	res, ok := Match(t, cands)
+	if !ok || res.Confidence < m.min {
+		return Result{}, false, nil
+	}
+
+	return res, true, nil
+}
+
+// Resolve replaces every track with its best match. Tracks without a match
+// are returned separately in their original order.
+func (m *matcher) Resolve(list []refind.Track) ([]refind.Track, []refind.Track, error) {
+	var found []refind.Track
+	var missing []refind.Track
+
+	for _, t := range list {
+		res, ok, err := m.Find(t)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if !ok {
+			missing = append(missing, t)
+			continue
+		}
+		found = append(found, res.Track)
+	}
+
+	return found, missing, nil
+}
+
+func confidence(a, b refind.Track) (float64, bool) {
+	title := similarity(Normalize(a.Name), Normalize(b.Name))
+	artist := similarity(Normalize(a.Artist.Name), Normalize(b.Artist.Name))
+	if title == 0 || artist == 0 {
+		return 0, false
+	}
+
+	dur := durationUnknown
+	if a.Duration > 0 && b.Duration > 0 {
+		diff := a.Duration - b.Duration
+		if diff < 0 {
+			diff = -diff
+		}
+
+		if diff > durationTolerance {
+			return 0, false
+		}
+		dur = 1 - float64(diff)/float64(durationTolerance)
+	}
+
+	conf := titleWeight*title + artistWeight*artist + durationWeight*dur
+	return math.Round(conf*1000) / 1000, true
+}
+
+// Normalize lowercases s and strips punctuation, bracketed qualifiers,
+// featured artists and remaster suffixes so that equivalent titles and
+// names from different providers compare equal.
+func Normalize(s string) string {
+	s = strings.ToLower(s)
+	s = bracketed.ReplaceAllString(s, " ")
+	s = remaster.ReplaceAllString(s, "")
+	s = featuring.ReplaceAllString(s, "")
+	s = strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return r
+		}
+		return ' '
+	}, s)
+
+	return strings.Join(strings.Fields(s), " ")
+}
+
+func similarity(a, b string) float64 {
+	if blank.Is(a) || blank.Is(b) {
+		return 0
+	}
+
+	if a == b {
+		return 1
+	}
+
+	as := strings.Fields(a)
+	bs := make(map[string]bool)
+	for _, f := range strings.Fields(b) {
+		bs[f] = true
+	}
+
+	var shared int
+	seen := make(map[string]bool)
+	for _, f := range as {
+		if bs[f] && !seen[f] {
+			shared++
+		}
+		seen[f] = true
+	}
+
+	union := len(seen) + len(bs) - shared
+	return float64(shared) / float64(union)
+}
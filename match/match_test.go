@@ -0,0 +1,219 @@
+package match
+
+import (
+	"github.com/Henry-Sarabia/refind"
+	"github.com/pkg/errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+var testErrSearch = errors.New("cannot search tracks")
+
+var (
+	testReckoner = refind.Track{
+		ID:       "6LgJvl0Xdtc73RJ1mmpotq",
+		Name:     "Reckoner",
+		Artist:   refind.Artist{ID: "4Z8W4fKeB5YxbusRsdQVPb", Name: "Radiohead"},
+		Duration: 290213 * time.Millisecond,
+		ISRC:     "GBSTK0700057",
+	}
+	testLight = refind.Track{
+		ID:       "0WQiDwKJclirSYG9v5tayI",
+		Name:     "There Is a Light That Never Goes Out - 2011 Remaster",
+		Artist:   refind.Artist{ID: "3yY2gUcIsjMr8hjo51PoJ8", Name: "The Smiths"},
+		Duration: 244826 * time.Millisecond,
+		ISRC:     "GBAHT1100131",
+	}
+)
+
+type fakeSearcher struct {
+	tracks []refind.Track
+	err    error
+}
+
+func (f fakeSearcher) Search(refind.Track) ([]refind.Track, error) {
+	return f.tracks, f.err
+}
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		name string
+		s    string
+		want string
+	}{
+		{"Empty", "", ""},
+		{"Case and spacing", "  Just   Like HEAVEN ", "just like heaven"},
+		{"Punctuation", "Don't Stop!", "don t stop"},
+		{"Bracketed qualifier", "Reckoner (Live) [Bonus]", "reckoner"},
+		{"Remaster suffix", "Days - Remastered", "days"},
+		{"Remaster year suffix", "There Is a Light That Never Goes Out - 2011 Remaster", "there is a light that never goes out"},
+		{"Remix suffix", "Moments - Seeb Remix", "moments seeb remix"},
+		{"Featured artist", "Timebomb feat. Someone", "timebomb"},
+		{"Non-ASCII", "La Bohème", "la bohème"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := Normalize(test.s)
+			if got != test.want {
+				t.Errorf("got: <%v>, want: <%v>", got, test.want)
+			}
+		})
+	}
+}
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		name    string
+		t       refind.Track
+		cands   []refind.Track
+		wantRes Result
+		wantOK  bool
+	}{
+		{
+			name:    "No candidates",
+			t:       testReckoner,
+			cands:   nil,
+			wantRes: Result{},
+			wantOK:  false,
+		},
+		{
+			name:    "ISRC match",
+			t:       refind.Track{Name: "Something Else", ISRC: "gbstk0700057"},
+			cands:   []refind.Track{testLight, testReckoner},
+			wantRes: Result{Track: testReckoner, Method: ISRC, Confidence: 1},
+			wantOK:  true,
+		},
+		{
+			name: "Exact metadata match",
+			t: refind.Track{
+				Name:     "There Is A Light That Never Goes Out",
+				Artist:   refind.Artist{Name: "the smiths"},
+				Duration: 244826 * time.Millisecond,
+			},
+			cands:   []refind.Track{testReckoner, testLight},
+			wantRes: Result{Track: testLight, Method: Metadata, Confidence: 1},
+			wantOK:  true,
+		},
+		{
+			name: "Metadata match with unknown duration",
+			t: refind.Track{
+				Name:   "Reckoner",
+				Artist: refind.Artist{Name: "Radiohead"},
+			},
+			cands:   []refind.Track{testReckoner},
+			wantRes: Result{Track: testReckoner, Method: Metadata, Confidence: 0.95},
+			wantOK:  true,
+		},
+		{
+			name: "Duration out of tolerance",
+			t: refind.Track{
+				Name:     "Reckoner",
+				Artist:   refind.Artist{Name: "Radiohead"},
+				Duration: 200 * time.Second,
+			},
+			cands:   []refind.Track{testReckoner},
+			wantRes: Result{},
+			wantOK:  false,
+		},
+		{
+			name: "Different artist",
+			t: refind.Track{
+				Name:     "Reckoner",
+				Artist:   refind.Artist{Name: "Someone Else"},
+				Duration: 290213 * time.Millisecond,
+			},
+			cands:   []refind.Track{testReckoner},
+			wantRes: Result{},
+			wantOK:  false,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			res, ok := Match(test.t, test.cands)
+			if ok != test.wantOK {
+				t.Errorf("got: <%v>, want: <%v>", ok, test.wantOK)
+			}
+
+			if !reflect.DeepEqual(res, test.wantRes) {
+				t.Errorf("got: <%v>, want: <%v>", res, test.wantRes)
+			}
+		})
+	}
+}
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name    string
+		s       Searcher
+		min     float64
+		wantErr error
+	}{
+		{"Valid searcher", fakeSearcher{}, 0.8, nil},
+		{"Nil searcher", nil, 0.8, errNilSearcher},
+		{"Confidence below range", fakeSearcher{}, -0.1, errRangeInvalid},
+		{"Confidence above range", fakeSearcher{}, 1.1, errRangeInvalid},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := New(test.s, test.min)
+			if err != test.wantErr {
+				t.Errorf("got: <%v>, want: <%v>", err, test.wantErr)
+			}
+		})
+	}
+}
+
+func TestMatcher_Resolve(t *testing.T) {
+	tests := []struct {
+		name        string
+		s           Searcher
+		list        []refind.Track
+		wantFound   []refind.Track
+		wantMissing []refind.Track
+		wantErr     error
+	}{
+		{
+			name: "Matched and unmatched tracks",
+			s:    fakeSearcher{tracks: []refind.Track{testReckoner}},
+			list: []refind.Track{
+				{Name: "Reckoner", Artist: refind.Artist{Name: "Radiohead"}, ISRC: "GBSTK0700057"},
+				{Name: "Bells Bleed & Bloom", Artist: refind.Artist{Name: "ef"}},
+			},
+			wantFound: []refind.Track{testReckoner},
+			wantMissing: []refind.Track{
+				{Name: "Bells Bleed & Bloom", Artist: refind.Artist{Name: "ef"}},
+			},
+			wantErr: nil,
+		},
+		{
+			name:        "Search error",
+			s:           fakeSearcher{err: testErrSearch},
+			list:        []refind.Track{testReckoner},
+			wantFound:   nil,
+			wantMissing: nil,
+			wantErr:     testErrSearch,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			m, err := New(test.s, 0.8)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			found, missing, err := m.Resolve(test.list)
+			if errors.Cause(err) != test.wantErr {
+				t.Errorf("got: <%v>, want: <%v>", errors.Cause(err), test.wantErr)
+			}
+
+			if !reflect.DeepEqual(found, test.wantFound) {
+				t.Errorf("got: <%v>, want: <%v>", found, test.wantFound)
+			}
+
+			if !reflect.DeepEqual(missing, test.wantMissing) {
+				t.Errorf("got: <%v>, want: <%v>", missing, test.wantMissing)
+			}
+		})
+	}
+}
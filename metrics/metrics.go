@@ -0,0 +1,103 @@
+package metrics
+
+import (
+	"github.com/Henry-Sarabia/refind"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var errNilRegisterer = errors.New("cannot initialize metrics using nil registerer")
+
+type counter struct {
+	help   string
+	labels []string
+}
+
+type histogram struct {
+	help    string
+	labels  []string
+	buckets []float64
+}
+
+var counters = map[string]counter{
+	refind.MetricSpotifyRequests: {"Spotify API requests by endpoint and status.", []string{"endpoint", "status"}},
+	refind.MetricBufferRequests:  {"Buffered music service calls by method and result.", []string{"method", "result"}},
+	refind.MetricGenerations:     {"Tracklist generations by mode and result.", []string{"mode", "result"}},
+}
+
+var histograms = map[string]histogram{
+	refind.MetricSpotifyLatency:     {"Spotify API request latency in seconds.", []string{"endpoint"}, prometheus.DefBuckets},
+	refind.MetricGenerationDuration: {"Tracklist generation time in seconds by mode and result.", []string{"mode", "result"}, prometheus.ExponentialBuckets(0.25, 2, 8)},
+	refind.MetricGenerationTracks:   {"Tracks in each generated tracklist.", []string{"mode"}, prometheus.LinearBuckets(10, 10, 10)},
+	refind.MetricGenerationYield:    {"Fraction of recommendations kept after filtering.", []string{"mode"}, prometheus.LinearBuckets(0.1, 0.1, 10)},
+}
+
+// collector records refind's metrics as Prometheus counters and histograms.
+// Metrics with unknown names or labels are ignored.
+type collector struct {
+	counters   map[string]*prometheus.CounterVec
+	histograms map[string]*prometheus.HistogramVec
+}
+
+// New registers every refind metric with reg, such as
+// prometheus.DefaultRegisterer, and returns a refind.Metrics that records
+// them.
+func New(reg prometheus.Registerer) (*collector, error) {
+	if reg == nil {
+		return nil, errNilRegisterer
+	}
+
+	c := &collector{
+		counters:   make(map[string]*prometheus.CounterVec),
+		histograms: make(map[string]*prometheus.HistogramVec),
+	}
+
+	for name, def := range counters {
+		v := prometheus.NewCounterVec(prometheus.CounterOpts{Name: name, Help: def.help}, def.labels)
+		if err := reg.Register(v); err != nil {
+			return nil, errors.Wrapf(err, "cannot register %s", name)
+		}
+		c.counters[name] = v
+	}
+
+	for name, def := range histograms {
+		v := prometheus.NewHistogramVec(prometheus.HistogramOpts{Name: name, Help: def.help, Buckets: def.buckets}, def.labels)
+		if err := reg.Register(v); err != nil {
+			return nil, errors.Wrapf(err, "cannot register %s", name)
+		}
+		c.histograms[name] = v
+	}
+
+	return c, nil
+}
+
+func (c *collector) Inc(name string, labels ...string) {
+	v, ok := c.counters[name]
+	if !ok {
+		return
+	}
+
+	if m, err := v.GetMetricWith(toLabels(labels)); err == nil {
+		m.Inc()
+	}
+}
+
+func (c *collector) Observe(name string, value float64, labels ...string) {
+	v, ok := c.histograms[name]
+	if !ok {
+		return
+	}
+
+	if m, err := v.GetMetricWith(toLabels(labels)); err == nil {
+		m.Observe(value)
+	}
+}
+
+func toLabels(pairs []string) prometheus.Labels {
+	l := make(prometheus.Labels, len(pairs)/2)
+	for i := 0; i+1 < len(pairs); i += 2 {
+		l[pairs[i]] = pairs[i+1]
+	}
+
+	return l
+}
@@ -0,0 +1,64 @@
+package metrics
+
+import (
+	"github.com/Henry-Sarabia/refind"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"testing"
+)
+
+func TestNew(t *testing.T) {
+	if _, err := New(nil); err != errNilRegisterer {
+		t.Errorf("got: <%v>, want: <%v>", err, errNilRegisterer)
+	}
+
+	reg := prometheus.NewRegistry()
+	if _, err := New(reg); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := New(reg); err == nil {
+		t.Errorf("got: <%v>, want duplicate registration error", err)
+	}
+}
+
+func TestCollector(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	c, err := New(reg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c.Inc(refind.MetricBufferRequests, "method", "TopArtists", "result", "hit")
+	c.Inc(refind.MetricBufferRequests, "method", "TopArtists", "result", "hit")
+	c.Inc(refind.MetricBufferRequests, "method", "TopArtists", "result", "miss")
+	c.Observe(refind.MetricSpotifyLatency, 0.2, "endpoint", "GET /me")
+
+	// Unknown metrics and labels are ignored rather than panicking.
+	c.Inc("unknown_total")
+	c.Inc(refind.MetricGenerations, "colour", "blue")
+
+	tests := []struct {
+		name   string
+		metric prometheus.Collector
+		want   float64
+	}{
+		{"Hits", c.counters[refind.MetricBufferRequests].WithLabelValues("TopArtists", "hit"), 2},
+		{"Misses", c.counters[refind.MetricBufferRequests].WithLabelValues("TopArtists", "miss"), 1},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := testutil.ToFloat64(test.metric); got != test.want {
+				t.Errorf("got: <%v>, want: <%v>", got, test.want)
+			}
+		})
+	}
+
+	if got := testutil.CollectAndCount(c.histograms[refind.MetricSpotifyLatency]); got != 1 {
+		t.Errorf("got: <%v>, want: <%v>", got, 1)
+	}
+
+	if got := testutil.CollectAndCount(c.counters[refind.MetricGenerations]); got != 0 {
+		t.Errorf("got: <%v>, want: <%v>", got, 0)
+	}
+}
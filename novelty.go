@@ -0,0 +1,115 @@
+package refind
+
+import (
+	"github.com/pkg/errors"
+	"math"
+)
+
+// hopSources limits how many artists are expanded per hop when walking the
+// related artists of known artists.
+const hopSources int = 20
+
+var errNoveltyInvalid = errors.New("novelty must be between 0 and 1")
+
+// RelatedArtistService returns the artists a music service considers
+// similar to the artist with the given ID.
+type RelatedArtistService interface {
+	RelatedArtists(id string) ([]Artist, error)
+}
+
+// Novelty describes how far from a user's known taste recommendations may
+// go. It is derived from a single level between 0 and 1 by Dial.
+type Novelty struct {
+	Level            float64
+	TargetPopularity int
+	MaxPopularity    int
+	// MinDistance is the number of related artist hops a candidate artist
+	// must be from every known artist. Known artists are at distance 0.
+	MinDistance int
+	// AllowAdjacent keeps artists closer than MinDistance when their own
+	// popularity is no higher than MaxPopularity.
+	AllowAdjacent bool
+}
+
+// Dial maps a novelty level to popularity bounds and related artist rules.
+// A level of 0.5 keeps the original target popularity of 40 and maximum of
+// 50 without any related artist hops.
+func Dial(level float64) (Novelty, error) {
+	if level < 0 || level > 1 || math.IsNaN(level) {
+		return Novelty{}, errNoveltyInvalid
+	}
+
+	n := Novelty{
+		Level:            level,
+		TargetPopularity: int(math.Round(70 - 60*level)),
+		MaxPopularity:    int(math.Round(90 - 80*level)),
+		MinDistance:      1,
+	}
+
+	switch {
+	case level >= 0.9:
+		n.MinDistance = 3
+	case level > 0.5:
+		n.MinDistance = 2
+		n.AllowAdjacent = level < 0.75
+	}
+
+	return n, nil
+}
+
+// Hops reports whether the related artist graph is needed.
+func (n Novelty) Hops() bool {
+	return n.MinDistance > 1
+}
+
+func (n Novelty) attributes() map[string]float64 {
+	return map[string]float64{
+		"target_popularity": float64(n.TargetPopularity),
+		"max_popularity":    float64(n.MaxPopularity),
+	}
+}
+
+// nearby returns every artist closer than MinDistance hops to the known
+// artists that the novelty does not allow, including the known artists.
+func (n Novelty) nearby(rel RelatedArtistService, known []Artist) ([]Artist, error) {
+	if !n.Hops() {
+		return known, nil
+	}
+
+	seen := make(map[string]bool)
+	for _, k := range known {
+		seen[k.ID] = true
+	}
+
+	out := append([]Artist{}, known...)
+	level := known
+	for d := 1; d < n.MinDistance; d++ {
+		var next []Artist
+		for i, a := range level {
+			if i >= hopSources {
+				break
+			}
+
+			rs, err := rel.RelatedArtists(a.ID)
+			if err != nil {
+				return nil, errors.Wrap(err, "cannot fetch related artists")
+			}
+
+			for _, r := range rs {
+				if seen[r.ID] {
+					continue
+				}
+				seen[r.ID] = true
+				next = append(next, r)
+
+				if d == 1 && n.AllowAdjacent && r.Popularity <= n.MaxPopularity {
+					continue
+				}
+				out = append(out, r)
+			}
+		}
+		level = next
+	}
+
+	return out, nil
+}
@@ -0,0 +1,142 @@
+package refind
+
+import (
+	"github.com/pkg/errors"
+	"reflect"
+	"testing"
+)
+
+var (
+	testArtistA = Artist{ID: "a", Name: "alpha", Popularity: 60}
+	testArtistB = Artist{ID: "b", Name: "bravo", Popularity: 30}
+	testArtistC = Artist{ID: "c", Name: "charlie", Popularity: 80}
+	testArtistD = Artist{ID: "d", Name: "delta", Popularity: 10}
+)
+
+type fakeRelatedService struct {
+	fakeMusicService
+	related map[string][]Artist
+	err     error
+}
+
+func (f fakeRelatedService) RelatedArtists(id string) ([]Artist, error) {
+	return f.related[id], f.err
+}
+
+var testRelated = map[string][]Artist{
+	"a": {testArtistB, testArtistC},
+	"b": {testArtistA, testArtistD},
+}
+
+func TestDial(t *testing.T) {
+	tests := []struct {
+		name    string
+		level   float64
+		want    Novelty
+		wantErr error
+	}{
+		{"Familiar", 0, Novelty{Level: 0, TargetPopularity: 70, MaxPopularity: 90, MinDistance: 1}, nil},
+		{"Original defaults", 0.5, Novelty{Level: 0.5, TargetPopularity: 40, MaxPopularity: 50, MinDistance: 1}, nil},
+		{"Adjacent allowed", 0.6, Novelty{Level: 0.6, TargetPopularity: 34, MaxPopularity: 42, MinDistance: 2, AllowAdjacent: true}, nil},
+		{"Adjacent excluded", 0.8, Novelty{Level: 0.8, TargetPopularity: 22, MaxPopularity: 26, MinDistance: 2}, nil},
+		{"Furthest", 1, Novelty{Level: 1, TargetPopularity: 10, MaxPopularity: 10, MinDistance: 3}, nil},
+		{"Below range", -0.1, Novelty{}, errNoveltyInvalid},
+		{"Above range", 1.1, Novelty{}, errNoveltyInvalid},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := Dial(test.level)
+			if err != test.wantErr {
+				t.Errorf("got: <%v>, want: <%v>", err, test.wantErr)
+			}
+
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("got: <%v>, want: <%v>", got, test.want)
+			}
+		})
+	}
+}
+
+func TestNovelty_Nearby(t *testing.T) {
+	tests := []struct {
+		name    string
+		level   float64
+		rel     fakeRelatedService
+		want    []Artist
+		wantErr error
+	}{
+		{"No hops", 0.5, fakeRelatedService{related: testRelated}, []Artist{testArtistA}, nil},
+		{"Lesser known adjacent artist allowed", 0.6, fakeRelatedService{related: testRelated}, []Artist{testArtistA, testArtistC}, nil},
+		{"Adjacent artists excluded", 0.8, fakeRelatedService{related: testRelated}, []Artist{testArtistA, testArtistB, testArtistC}, nil},
+		{"Two hops excluded", 1, fakeRelatedService{related: testRelated}, []Artist{testArtistA, testArtistB, testArtistC, testArtistD}, nil},
+		{"Related artists unavailable", 1, fakeRelatedService{err: testErrFetchArtists}, nil, testErrFetchArtists},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			n, err := Dial(test.level)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := n.nearby(test.rel, []Artist{testArtistA})
+			if errors.Cause(err) != test.wantErr {
+				t.Errorf("got: <%v>, want: <%v>", errors.Cause(err), test.wantErr)
+			}
+
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("got: <%v>, want: <%v>", got, test.want)
+			}
+		})
+	}
+}
+
+func TestGenerator_SetNovelty(t *testing.T) {
+	var tune Tuning
+	rec := fakeTunedRecommender{
+		fakeRecommender: fakeRecommender{
+			tracks: []Track{
+				{ID: "20", Name: "quux", Artist: testArtistB},
+				{ID: "21", Name: "corge", Artist: testArtistD},
+			},
+		},
+		tune: &tune,
+	}
+	serv := fakeMusicService{
+		artists: []Artist{testArtistA},
+		tracks:  []Track{{ID: "10", Name: "baz", Artist: testArtistA}},
+	}
+
+	gen := &generator{serv: serv, rec: fakeRecommender{}}
+	if err := gen.SetNovelty(0.5); err != ErrNoTuning {
+		t.Errorf("got: <%v>, want: <%v>", err, ErrNoTuning)
+	}
+
+	gen = &generator{serv: serv, rec: rec}
+	if err := gen.SetNovelty(0.8); err != ErrNoRelated {
+		t.Errorf("got: <%v>, want: <%v>", err, ErrNoRelated)
+	}
+
+	gen = &generator{serv: fakeRelatedService{fakeMusicService: serv, related: testRelated}, rec: rec}
+	if err := gen.SetNovelty(2); err != errNoveltyInvalid {
+		t.Errorf("got: <%v>, want: <%v>", err, errNoveltyInvalid)
+	}
+
+	if err := gen.SetNovelty(0.8); err != nil {
+		t.Fatal(err)
+	}
+
+	list, err := gen.Tracklist(testTotal)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []Track{{ID: "21", Name: "corge", Artist: testArtistD}}
+	if !reflect.DeepEqual(list, want) {
+		t.Errorf("got: <%v>, want: <%v>", list, want)
+	}
+
+	wantAttrs := map[string]float64{"target_popularity": 22, "max_popularity": 26}
+	if !reflect.DeepEqual(tune.Attributes, wantAttrs) {
+		t.Errorf("got: <%v>, want: <%v>", tune.Attributes, wantAttrs)
+	}
+}
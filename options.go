@@ -0,0 +1,97 @@
+package refind
+
+import (
+	"github.com/pkg/errors"
+	"time"
+)
+
+var (
+	errNilOption = errors.New("cannot apply nil option")
+	errNilClock  = errors.New("cannot use nil clock")
+)
+
+// Option configures a generator when it is created with New. Each option
+// validates its input the same way as the setter it wraps.
+type Option func(*generator) error
+
+// WithSelector makes the generator use at most n seeds chosen by sel.
+func WithSelector(n int, sel Selector) Option {
+	return func(g *generator) error {
+		return g.SetSelector(n, sel)
+	}
+}
+
+// WithRandomSeeds samples at most n seeds at random from the given source
+// seed, so that the same seed always picks the same seeds.
+func WithRandomSeeds(n int, seed int64) Option {
+	return WithSelector(n, RandomSampling(seed))
+}
+
+// WithConstraints filters the tracklist with the diversity constraints.
+func WithConstraints(c Constraints) Option {
+	return func(g *generator) error {
+		return g.SetConstraints(c)
+	}
+}
+
+// WithTuning narrows every recommendation with t.
+func WithTuning(t Tuning) Option {
+	return func(g *generator) error {
+		return g.SetTuning(t)
+	}
+}
+
+// WithPreset tunes recommendations with the built in preset of that name.
+func WithPreset(name string) Option {
+	return func(g *generator) error {
+		return g.SetPreset(name)
+	}
+}
+
+// WithNovelty controls how far recommendations stray from known taste.
+func WithNovelty(level float64) Option {
+	return func(g *generator) error {
+		return g.SetNovelty(level)
+	}
+}
+
+// WithOrderer arranges the tracklist with ord using features from feat.
+func WithOrderer(ord Orderer, feat FeatureService) Option {
+	return func(g *generator) error {
+		return g.SetOrderer(ord, feat)
+	}
+}
+
+// WithClock replaces the wall clock used for date based modes such as the
+// new release radar.
+func WithClock(now func() time.Time) Option {
+	return func(g *generator) error {
+		if now == nil {
+			return errNilClock
+		}
+
+		g.now = now
+		return nil
+	}
+}
+
+// WithLogger logs every generation stage to l, such as a *slog.Logger.
+func WithLogger(l Logger) Option {
+	return func(g *generator) error {
+		return g.SetLogger(l)
+	}
+}
+
+// WithMetrics records the duration and yield of every tracklist to m.
+func WithMetrics(m Metrics) Option {
+	return func(g *generator) error {
+		return g.SetMetrics(m)
+	}
+}
+
+// WithTracer emits a trace of every tracklist to t.
+func WithTracer(t Tracer) Option {
+	return func(g *generator) error {
+		return g.SetTracer(t)
+	}
+}
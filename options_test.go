@@ -0,0 +1,105 @@
+package refind
+
+import (
+	"github.com/pkg/errors"
+	"testing"
+	"time"
+)
+
+func TestNew_Options(t *testing.T) {
+	now := time.Date(2020, 6, 15, 0, 0, 0, 0, time.UTC)
+	var tune Tuning
+	tuned := fakeTunedRecommender{tune: &tune}
+
+	tests := []struct {
+		name    string
+		rec     Recommender
+		opts    []Option
+		check   func(*generator) bool
+		wantErr error
+	}{
+		{
+			name:    "No options",
+			rec:     fakeRecommender{},
+			opts:    nil,
+			check:   func(g *generator) bool { return g.sel == nil && g.seeds == 0 && g.now == nil },
+			wantErr: nil,
+		},
+		{
+			name:    "Seed strategy",
+			rec:     fakeRecommender{},
+			opts:    []Option{WithRandomSeeds(5, 1)},
+			check:   func(g *generator) bool { return g.sel != nil && g.seeds == 5 },
+			wantErr: nil,
+		},
+		{
+			name:    "Clock",
+			rec:     fakeRecommender{},
+			opts:    []Option{WithClock(func() time.Time { return now })},
+			check:   func(g *generator) bool { return g.clock().Equal(now) },
+			wantErr: nil,
+		},
+		{
+			name:    "Preset and novelty with tuned recommender",
+			rec:     tuned,
+			opts:    []Option{WithPreset("focus"), WithNovelty(0.2)},
+			check:   func(g *generator) bool { return g.tune != nil && g.nov != nil },
+			wantErr: nil,
+		},
+		{
+			name:    "Invalid seed count",
+			rec:     fakeRecommender{},
+			opts:    []Option{WithSelector(0, RecencyWeighted())},
+			wantErr: ErrRangeInvalid,
+		},
+		{
+			name:    "Invalid constraints",
+			rec:     fakeRecommender{},
+			opts:    []Option{WithConstraints(Constraints{MaxPerArtist: -1})},
+			wantErr: errConstraintInvalid,
+		},
+		{
+			name:    "Tuning without tuned recommender",
+			rec:     fakeRecommender{},
+			opts:    []Option{WithTuning(Tuning{})},
+			wantErr: ErrNoTuning,
+		},
+		{
+			name:    "Nil orderer",
+			rec:     fakeRecommender{},
+			opts:    []Option{WithOrderer(nil, nil)},
+			wantErr: errNilOrderer,
+		},
+		{
+			name:    "Nil clock",
+			rec:     fakeRecommender{},
+			opts:    []Option{WithClock(nil)},
+			wantErr: errNilClock,
+		},
+		{
+			name:    "Nil option",
+			rec:     fakeRecommender{},
+			opts:    []Option{nil},
+			wantErr: errNilOption,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := New(fakeMusicService{}, test.rec, test.opts...)
+			if errors.Cause(err) != test.wantErr {
+				t.Fatalf("got: <%v>, want: <%v>", errors.Cause(err), test.wantErr)
+			}
+
+			if err != nil {
+				if got != nil {
+					t.Errorf("got: <%v>, want: <%v>", got, nil)
+				}
+				return
+			}
+
+			if !test.check(got) {
+				t.Errorf("got: <%+v>, options not applied", got)
+			}
+		})
+	}
+}
@@ -0,0 +1,263 @@
+package refind
+
+import (
+	"math/rand"
+	"sort"
+)
+
+// Orderer arranges a finished tracklist using the audio features of its
+// tracks. Tracks without features keep their relative order at the end.
+type Orderer interface {
+	Order(list []Track, feats map[string]Features) []Track
+}
+
+// FeatureService returns the audio features of the tracks with the given
+// IDs. Tracks without features are left out of the map.
+type FeatureService interface {
+	AudioFeatures(ids []string) (map[string]Features, error)
+}
+
+type energyArc struct{}
+
+// EnergyArc builds up to the most energetic tracks in the middle of the
+// list and cools down towards the end.
+func EnergyArc() Orderer {
+	return energyArc{}
+}
+
+func (energyArc) Order(list []Track, feats map[string]Features) []Track {
+	known, rest := split(list, feats)
+	sort.SliceStable(known, func(i, j int) bool {
+		return feats[known[i].ID].Energy < feats[known[j].ID].Energy
+	})
+
+	var up, down []Track
+	for i, t := range known {
+		if i%2 == 0 {
+			up = append(up, t)
+		} else {
+			down = append(down, t)
+		}
+	}
+
+	arc := up
+	for i := len(down) - 1; i >= 0; i-- {
+		arc = append(arc, down[i])
+	}
+
+	return append(arc, rest...)
+}
+
+type harmonic struct{}
+
+// Harmonic chains tracks so that each one is as close as possible to the
+// previous one on the Camelot wheel, starting from the calmest track. Ties
+// are broken by the smallest change in tempo.
+func Harmonic() Orderer {
+	return harmonic{}
+}
+
+func (harmonic) Order(list []Track, feats map[string]Features) []Track {
+	known, rest := split(list, feats)
+	if len(known) == 0 {
+		return rest
+	}
+
+	first := 0
+	for i, t := range known {
+		if feats[t.ID].Energy < feats[known[first].ID].Energy {
+			first = i
+		}
+	}
+
+	chain := []Track{known[first]}
+	known = append(known[:first:first], known[first+1:]...)
+	for len(known) > 0 {
+		prev := feats[chain[len(chain)-1].ID]
+		best := 0
+		for i := range known {
+			if closerKey(prev, feats[known[i].ID], feats[known[best].ID]) {
+				best = i
+			}
+		}
+
+		chain = append(chain, known[best])
+		known = append(known[:best:best], known[best+1:]...)
+	}
+
+	return append(chain, rest...)
+}
+
+func closerKey(prev, a, b Features) bool {
+	da, db := CamelotDistance(prev, a), CamelotDistance(prev, b)
+	if da != db {
+		return da < db
+	}
+
+	return abs(prev.Tempo-a.Tempo) < abs(prev.Tempo-b.Tempo)
+}
+
+type tempoSmooth struct{}
+
+// TempoSmooth orders tracks from the slowest to the fastest tempo so that
+// neighboring tracks differ as little as possible.
+func TempoSmooth() Orderer {
+	return tempoSmooth{}
+}
+
+func (tempoSmooth) Order(list []Track, feats map[string]Features) []Track {
+	known, rest := split(list, feats)
+	sort.SliceStable(known, func(i, j int) bool {
+		return feats[known[i].ID].Tempo < feats[known[j].ID].Tempo
+	})
+
+	return append(known, rest...)
+}
+
+type shuffle struct {
+	seed    int64
+	spacing int
+}
+
+// Shuffle randomly orders tracks using the given seed while keeping at least
+// spacing other tracks between two tracks by the same artist wherever
+// possible. Audio features are not used.
+func Shuffle(seed int64, spacing int) Orderer {
+	return shuffle{seed: seed, spacing: spacing}
+}
+
+func (s shuffle) Order(list []Track, _ map[string]Features) []Track {
+	pool := make([]Track, len(list))
+	copy(pool, list)
+
+	rnd := rand.New(rand.NewSource(s.seed))
+	rnd.Shuffle(len(pool), func(i, j int) {
+		pool[i], pool[j] = pool[j], pool[i]
+	})
+
+	return space(pool, s.spacing)
+}
+
+// space keeps the order of list as far as possible while putting at least
+// spacing other tracks between two tracks by the same artist.
+func space(list []Track, spacing int) []Track {
+	if spacing <= 0 {
+		return list
+	}
+
+	pool := make([]Track, len(list))
+	copy(pool, list)
+
+	left := make(map[string]int)
+	for _, t := range pool {
+		left[t.Artist.ID]++
+	}
+
+	var out []Track
+	for len(pool) > 0 {
+		pick := -1
+		for i, t := range pool {
+			if recent(out, t.Artist.ID, spacing) {
+				continue
+			}
+
+			if pick < 0 {
+				pick = i
+			}
+
+			// An artist with this many tracks left can no longer be spaced
+			// out unless one of them is placed now.
+			if (left[t.Artist.ID]-1)*(spacing+1)+2 > len(pool) {
+				pick = i
+				break
+			}
+		}
+
+		if pick < 0 {
+			pick = 0
+		}
+
+		left[pool[pick].Artist.ID]--
+		out = append(out, pool[pick])
+		pool = append(pool[:pick:pick], pool[pick+1:]...)
+	}
+
+	return out
+}
+
+func recent(list []Track, artist string, spacing int) bool {
+	for i := len(list) - 1; i >= 0 && i >= len(list)-spacing; i-- {
+		if list[i].Artist.ID == artist {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Camelot returns the position of the features' key on the Camelot wheel
+// from 1 to 12 and whether the key is minor. A zero position means the key
+// is unknown.
+func Camelot(f Features) (int, bool) {
+	if f.Key < 0 || f.Key > 11 {
+		return 0, false
+	}
+
+	minor := f.Mode == 0
+	n := (7*f.Key + 8) % 12
+	if minor {
+		n = (7*f.Key + 5) % 12
+	}
+
+	if n == 0 {
+		n = 12
+	}
+
+	return n, minor
+}
+
+// CamelotDistance returns the number of steps between two keys on the
+// Camelot wheel, counting a switch between major and minor as one step.
+// Unknown keys are treated as the furthest possible distance.
+func CamelotDistance(a, b Features) int {
+	na, ma := Camelot(a)
+	nb, mb := Camelot(b)
+	if na == 0 || nb == 0 {
+		return 7
+	}
+
+	d := na - nb
+	if d < 0 {
+		d = -d
+	}
+	if d > 6 {
+		d = 12 - d
+	}
+
+	if ma != mb {
+		d++
+	}
+
+	return d
+}
+
+func split(list []Track, feats map[string]Features) ([]Track, []Track) {
+	var known, rest []Track
+	for _, t := range list {
+		if _, ok := feats[t.ID]; ok {
+			known = append(known, t)
+		} else {
+			rest = append(rest, t)
+		}
+	}
+
+	return known, rest
+}
+
+func abs(x float64) float64 {
+	if x < 0 {
+		return -x
+	}
+
+	return x
+}
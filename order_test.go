@@ -0,0 +1,132 @@
+package refind
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+)
+
+var (
+	testTrackA = Track{ID: "a", Artist: Artist{ID: "0", Name: "a"}}
+	testTrackB = Track{ID: "b", Artist: Artist{ID: "1", Name: "b"}}
+	testTrackC = Track{ID: "c", Artist: Artist{ID: "2", Name: "c"}}
+	testTrackD = Track{ID: "d", Artist: Artist{ID: "3", Name: "d"}}
+	testTrackE = Track{ID: "e", Artist: Artist{ID: "4", Name: "e"}}
+)
+
+var testFeatures = map[string]Features{
+	"a": {Energy: 0.1, Tempo: 90, Key: 0, Mode: 1},
+	"b": {Energy: 0.5, Tempo: 120, Key: 7, Mode: 1},
+	"c": {Energy: 0.9, Tempo: 100, Key: 9, Mode: 0},
+	"d": {Energy: 0.3, Tempo: 140, Key: 2, Mode: 1},
+}
+
+func TestOrderer_Order(t *testing.T) {
+	tests := []struct {
+		name  string
+		ord   Orderer
+		list  []Track
+		feats map[string]Features
+		want  []Track
+	}{
+		{
+			"Energy arc with nil list",
+			EnergyArc(),
+			nil,
+			testFeatures,
+			nil,
+		},
+		{
+			"Energy arc",
+			EnergyArc(),
+			[]Track{testTrackE, testTrackA, testTrackB, testTrackC, testTrackD},
+			testFeatures,
+			[]Track{testTrackA, testTrackB, testTrackC, testTrackD, testTrackE},
+		},
+		{
+			"Harmonic",
+			Harmonic(),
+			[]Track{testTrackE, testTrackD, testTrackC, testTrackB, testTrackA},
+			testFeatures,
+			[]Track{testTrackA, testTrackC, testTrackB, testTrackD, testTrackE},
+		},
+		{
+			"Harmonic without features",
+			Harmonic(),
+			[]Track{testTrackB, testTrackA},
+			nil,
+			[]Track{testTrackB, testTrackA},
+		},
+		{
+			"Tempo smooth",
+			TempoSmooth(),
+			[]Track{testTrackD, testTrackE, testTrackC, testTrackB, testTrackA},
+			testFeatures,
+			[]Track{testTrackA, testTrackC, testTrackB, testTrackD, testTrackE},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := test.ord.Order(test.list, test.feats)
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("got: <%v>, want: <%v>", got, test.want)
+			}
+		})
+	}
+}
+
+func TestShuffle_Order(t *testing.T) {
+	list := []Track{
+		{ID: "a", Artist: Artist{ID: "0"}},
+		{ID: "b", Artist: Artist{ID: "0"}},
+		{ID: "c", Artist: Artist{ID: "0"}},
+		{ID: "d", Artist: Artist{ID: "1"}},
+		{ID: "e", Artist: Artist{ID: "1"}},
+		{ID: "f", Artist: Artist{ID: "2"}},
+	}
+
+	for seed := int64(0); seed < 20; seed++ {
+		got := Shuffle(seed, 1).Order(list, nil)
+		again := Shuffle(seed, 1).Order(list, nil)
+		if !reflect.DeepEqual(got, again) {
+			t.Fatalf("seed %d: got: <%v>, want: <%v>", seed, again, got)
+		}
+
+		var ids []string
+		for i, tr := range got {
+			ids = append(ids, tr.ID)
+			if i > 0 && got[i-1].Artist.ID == tr.Artist.ID {
+				t.Errorf("seed %d: artist %s repeated at position %d", seed, tr.Artist.ID, i)
+			}
+		}
+
+		sort.Strings(ids)
+		if want := []string{"a", "b", "c", "d", "e", "f"}; !reflect.DeepEqual(ids, want) {
+			t.Errorf("seed %d: got: <%v>, want: <%v>", seed, ids, want)
+		}
+	}
+}
+
+func TestCamelotDistance(t *testing.T) {
+	tests := []struct {
+		name string
+		a    Features
+		b    Features
+		want int
+	}{
+		{"Same key", Features{Key: 0, Mode: 1}, Features{Key: 0, Mode: 1}, 0},
+		{"Relative minor", Features{Key: 0, Mode: 1}, Features{Key: 9, Mode: 0}, 1},
+		{"Adjacent key", Features{Key: 0, Mode: 1}, Features{Key: 7, Mode: 1}, 1},
+		{"Wraps around wheel", Features{Key: 11, Mode: 1}, Features{Key: 4, Mode: 1}, 1},
+		{"Opposite key", Features{Key: 0, Mode: 1}, Features{Key: 6, Mode: 1}, 6},
+		{"Unknown key", Features{Key: -1}, Features{Key: 0, Mode: 1}, 7},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := CamelotDistance(test.a, test.b)
+			if got != test.want {
+				t.Errorf("got: <%v>, want: <%v>", got, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,141 @@
+package refind
+
+import (
+	"encoding/json"
+	"github.com/pkg/errors"
+	"io"
+	"strings"
+)
+
+var (
+	errPresetUnknown    = errors.New("no preset exists with that name")
+	errAttributeUnknown = errors.New("unknown tuning attribute")
+)
+
+var attributes = map[string]bool{
+	"acousticness":     true,
+	"danceability":     true,
+	"duration_ms":      true,
+	"energy":           true,
+	"instrumentalness": true,
+	"key":              true,
+	"liveness":         true,
+	"loudness":         true,
+	"mode":             true,
+	"popularity":       true,
+	"speechiness":      true,
+	"tempo":            true,
+	"time_signature":   true,
+	"valence":          true,
+}
+
+// Tuning narrows recommendations to tracks with certain attributes. Keys in
+// Attributes are a target_, min_ or max_ prefix followed by an attribute
+// name such as energy or tempo. Genres are used as additional seeds.
+type Tuning struct {
+	Attributes map[string]float64 `json:"attributes"`
+	Genres     []string           `json:"genres,omitempty"`
+}
+
+func (t Tuning) Validate() error {
+	for k := range t.Attributes {
+		i := strings.Index(k, "_")
+		if i < 0 {
+			return errors.Wrap(errAttributeUnknown, k)
+		}
+
+		switch k[:i] {
+		case "target", "min", "max":
+		default:
+			return errors.Wrap(errAttributeUnknown, k)
+		}
+
+		if !attributes[k[i+1:]] {
+			return errors.Wrap(errAttributeUnknown, k)
+		}
+	}
+
+	return nil
+}
+
+// TunedRecommender is implemented by recommenders that can narrow their
+// recommendations with a Tuning.
+type TunedRecommender interface {
+	TunedRecommendations(int, []Seed, Tuning) ([]Track, error)
+}
+
+var Presets = map[string]Tuning{
+	"focus": {
+		Attributes: map[string]float64{
+			"target_energy":           0.4,
+			"max_energy":              0.6,
+			"target_instrumentalness": 0.7,
+			"max_speechiness":         0.1,
+			"target_valence":          0.4,
+		},
+		Genres: []string{"study", "ambient"},
+	},
+	"workout": {
+		Attributes: map[string]float64{
+			"target_energy":       0.85,
+			"min_energy":          0.7,
+			"target_tempo":        140,
+			"min_tempo":           120,
+			"target_danceability": 0.7,
+		},
+		Genres: []string{"work-out", "edm"},
+	},
+	"chill": {
+		Attributes: map[string]float64{
+			"target_energy":       0.3,
+			"max_energy":          0.5,
+			"target_valence":      0.5,
+			"target_acousticness": 0.6,
+			"max_tempo":           110,
+		},
+		Genres: []string{"chill", "acoustic"},
+	},
+	"party": {
+		Attributes: map[string]float64{
+			"target_danceability": 0.8,
+			"min_danceability":    0.6,
+			"target_energy":       0.8,
+			"target_valence":      0.8,
+		},
+		Genres: []string{"party", "dance"},
+	},
+}
+
+// LoadPresets decodes a JSON object of named tunings and returns them merged
+// over the built in presets. Presets in the file replace built in presets
+// with the same name.
+func LoadPresets(r io.Reader) (map[string]Tuning, error) {
+	var custom map[string]Tuning
+	if err := json.NewDecoder(r).Decode(&custom); err != nil {
+		return nil, errors.Wrap(err, "cannot decode presets")
+	}
+
+	all := make(map[string]Tuning)
+	for name, t := range Presets {
+		all[name] = t
+	}
+
+	for name, t := range custom {
+		if err := t.Validate(); err != nil {
+			return nil, errors.Wrapf(err, "invalid preset %q", name)
+		}
+		all[name] = t
+	}
+
+	return all, nil
+}
+
+// Preset returns the tuning with the given name from presets.
+func Preset(presets map[string]Tuning, name string) (Tuning, error) {
+	t, ok := presets[name]
+	if !ok {
+		return Tuning{}, errors.Wrap(errPresetUnknown, name)
+	}
+
+	return t, nil
+}
@@ -0,0 +1,101 @@
+package refind
+
+import (
+	"github.com/pkg/errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestTuning_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		tune    Tuning
+		wantErr error
+	}{
+		{"Empty tuning", Tuning{}, nil},
+		{"Valid attributes", Tuning{Attributes: map[string]float64{"target_energy": 0.5, "max_tempo": 120}}, nil},
+		{"Missing prefix", Tuning{Attributes: map[string]float64{"energy": 0.5}}, errAttributeUnknown},
+		{"Unknown prefix", Tuning{Attributes: map[string]float64{"avg_energy": 0.5}}, errAttributeUnknown},
+		{"Unknown attribute", Tuning{Attributes: map[string]float64{"target_mood": 0.5}}, errAttributeUnknown},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := test.tune.Validate()
+			if errors.Cause(err) != test.wantErr {
+				t.Errorf("got: <%v>, want: <%v>", errors.Cause(err), test.wantErr)
+			}
+		})
+	}
+}
+
+func TestPresets_Validate(t *testing.T) {
+	for name, p := range Presets {
+		if err := p.Validate(); err != nil {
+			t.Errorf("preset %s: %v", name, err)
+		}
+	}
+}
+
+func TestLoadPresets(t *testing.T) {
+	tests := []struct {
+		name     string
+		file     string
+		preset   string
+		wantTune Tuning
+		wantErr  error
+	}{
+		{
+			"Custom preset",
+			`{"study": {"attributes": {"max_energy": 0.3}, "genres": ["classical"]}}`,
+			"study",
+			Tuning{Attributes: map[string]float64{"max_energy": 0.3}, Genres: []string{"classical"}},
+			nil,
+		},
+		{
+			"Overridden preset",
+			`{"focus": {"attributes": {"max_energy": 0.2}}}`,
+			"focus",
+			Tuning{Attributes: map[string]float64{"max_energy": 0.2}},
+			nil,
+		},
+		{
+			"Built in preset kept",
+			`{}`,
+			"party",
+			Presets["party"],
+			nil,
+		},
+		{
+			"Unknown preset",
+			`{}`,
+			"sleep",
+			Tuning{},
+			errPresetUnknown,
+		},
+		{
+			"Invalid attribute",
+			`{"study": {"attributes": {"max_focus": 1}}}`,
+			"study",
+			Tuning{},
+			errAttributeUnknown,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			presets, err := LoadPresets(strings.NewReader(test.file))
+			var tune Tuning
+			if err == nil {
+				tune, err = Preset(presets, test.preset)
+			}
+
+			if errors.Cause(err) != test.wantErr {
+				t.Errorf("got: <%v>, want: <%v>", errors.Cause(err), test.wantErr)
+			}
+
+			if !reflect.DeepEqual(tune, test.wantTune) {
+				t.Errorf("got: <%v>, want: <%v>", tune, test.wantTune)
+			}
+		})
+	}
+}
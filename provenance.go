@@ -0,0 +1,53 @@
+package refind
+
+// Provenance records the seed group that produced a recommended track and
+// the tuning attributes that were applied to that group's request.
+type Provenance struct {
+	Group      int
+	Tracks     []string
+	Artists    []string
+	Genres     []string
+	Attributes map[string]float64
+}
+
+// GroupedRecommender is implemented by recommenders that split seeds into
+// groups fetched by separate requests. SeedGroups returns how many groups
+// the seeds are split into when recommending with the given tuning.
+type GroupedRecommender interface {
+	SeedGroups(sds []Seed, t Tuning) int
+}
+
+// Explanation is a set of tracks that share the same provenance.
+type Explanation struct {
+	Provenance *Provenance
+	Tracks     []Track
+}
+
+// Explain groups tracks by the seed group that produced them, in the order
+// each group first appears. Tracks without provenance are grouped last.
+func Explain(list []Track) []Explanation {
+	var exps []Explanation
+	var unknown []Track
+	idx := make(map[int]int)
+
+	for _, t := range list {
+		if t.Provenance == nil {
+			unknown = append(unknown, t)
+			continue
+		}
+
+		i, ok := idx[t.Provenance.Group]
+		if !ok {
+			i = len(exps)
+			idx[t.Provenance.Group] = i
+			exps = append(exps, Explanation{Provenance: t.Provenance})
+		}
+		exps[i].Tracks = append(exps[i].Tracks, t)
+	}
+
+	if len(unknown) > 0 {
+		exps = append(exps, Explanation{Tracks: unknown})
+	}
+
+	return exps
+}
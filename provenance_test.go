@@ -0,0 +1,70 @@
+package refind
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestExplain(t *testing.T) {
+	first := &Provenance{Group: 0, Tracks: []string{"10"}}
+	second := &Provenance{Group: 1, Artists: []string{"20"}, Genres: []string{"classical"}}
+
+	tests := []struct {
+		name string
+		list []Track
+		want []Explanation
+	}{
+		{
+			"Nil tracks",
+			nil,
+			nil,
+		},
+		{
+			"Tracks without provenance",
+			[]Track{
+				{ID: "0", Name: "foo"},
+				{ID: "1", Name: "bar"},
+			},
+			[]Explanation{
+				{Tracks: []Track{{ID: "0", Name: "foo"}, {ID: "1", Name: "bar"}}},
+			},
+		},
+		{
+			"Interleaved groups",
+			[]Track{
+				{ID: "0", Name: "foo", Provenance: second},
+				{ID: "1", Name: "bar", Provenance: first},
+				{ID: "2", Name: "baz"},
+				{ID: "3", Name: "qux", Provenance: second},
+			},
+			[]Explanation{
+				{
+					Provenance: second,
+					Tracks: []Track{
+						{ID: "0", Name: "foo", Provenance: second},
+						{ID: "3", Name: "qux", Provenance: second},
+					},
+				},
+				{
+					Provenance: first,
+					Tracks: []Track{
+						{ID: "1", Name: "bar", Provenance: first},
+					},
+				},
+				{
+					Tracks: []Track{
+						{ID: "2", Name: "baz"},
+					},
+				},
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := Explain(test.list)
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("got: <%v>, want: <%v>", got, test.want)
+			}
+		})
+	}
+}
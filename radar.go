@@ -0,0 +1,221 @@
+package refind
+
+import (
+	"github.com/pkg/errors"
+	"sort"
+	"time"
+)
+
+// radarArtists limits how many artists near the user's taste have their
+// releases fetched.
+const radarArtists int = 50
+
+// Radar configures the new release radar. Types limits the kinds of release
+// considered and includes every kind when empty.
+type Radar struct {
+	Weeks int
+	Types []ReleaseType
+}
+
+func (r Radar) wants(t ReleaseType) bool {
+	if len(r.Types) == 0 {
+		return true
+	}
+
+	for _, rt := range r.Types {
+		if rt == t {
+			return true
+		}
+	}
+
+	return false
+}
+
+// RadarTracklist returns the lead tracks of releases from the last few weeks
+// by artists related to the user's top artists. Top artists themselves are
+// filtered out and each artist appears at most once, newest releases first.
+func (g generator) RadarTracklist(n int, r Radar) ([]Track, error) {
+	list, _, err := g.RadarTracklistReport(n, r)
+	return list, err
+}
+
+func (g generator) RadarTracklistReport(n int, r Radar) ([]Track, Report, error) {
+	g, run := g.begin("RadarTracklist")
+	return run.end(g.radar(n, r))
+}
+
+func (g generator) radar(n int, r Radar) ([]Track, Report, error) {
+	var rep Report
+	if n <= 0 || r.Weeks <= 0 {
+		return nil, rep, ErrRangeInvalid
+	}
+
+	rs, ok := g.serv.(ReleaseService)
+	if !ok {
+		return nil, rep, ErrNoReleases
+	}
+
+	rel, ok := g.serv.(RelatedArtistService)
+	if !ok {
+		return nil, rep, ErrNoRelated
+	}
+
+	log := g.logger()
+	fail := func(stage string, err error) ([]Track, Report, error) {
+		log.Error("radar failed", "stage", stage, "error", redactErr(err))
+		return nil, rep, err
+	}
+
+	start := time.Now()
+	top, err := g.topArtists()
+	if err != nil {
+		return fail("seeds", err)
+	}
+
+	near, err := nearArtists(rel, top)
+	if err != nil {
+		return fail("seeds", err)
+	}
+	rep.Seeds = len(near)
+	rep.Timings.Seeds = time.Since(start)
+	log.Info("related artists found", "top_artists", len(top), "related_artists", rep.Seeds, "duration", rep.Timings.Seeds)
+
+	mark := time.Now()
+	releases, err := g.releases(rs, near, r)
+	if err != nil {
+		return fail("releases", err)
+	}
+
+	var ids []string
+	for _, rl := range releases {
+		ids = append(ids, rl.Album.ID)
+	}
+
+	leads, err := rs.LeadTracks(ids)
+	if err != nil {
+		return fail("releases", errors.Wrap(err, "cannot fetch release tracks"))
+	}
+
+	var recs []Track
+	for _, rl := range releases {
+		t, ok := leads[rl.Album.ID]
+		if !ok {
+			continue
+		}
+
+		if t.Artist.ID == "" {
+			t.Artist = rl.Artist
+		}
+		if t.Album.ID == "" {
+			t.Album = rl.Album
+		}
+		recs = append(recs, t)
+	}
+	rep.Recommendations = len(recs)
+	rep.Timings.Recommendations = time.Since(mark)
+	log.Info("releases fetched", "releases", len(releases), "lead_tracks", rep.Recommendations, "duration", rep.Timings.Recommendations)
+
+	mark = time.Now()
+	f, rmvKnown, rmvDup := filterCount(recs, toMap(top))
+	if len(f) > n {
+		f = f[:n]
+	}
+	rep.KnownRemoved = rmvKnown
+	rep.DuplicatesRemoved = rmvDup
+	rep.Final = len(f)
+	rep.Timings.Filter = time.Since(mark)
+	log.Info("tracks filtered", "known_removed", rep.KnownRemoved, "duplicates_removed", rep.DuplicatesRemoved, "final", rep.Final, "duration", rep.Timings.Filter)
+
+	mark = time.Now()
+	f, err = g.order(f)
+	if err != nil {
+		return fail("order", err)
+	}
+	rep.Timings.Order = time.Since(mark)
+	rep.Timings.Total = time.Since(start)
+	log.Info("radar generated", "tracks", len(f), "duration", rep.Timings.Total)
+
+	return f, rep, nil
+}
+
+// releases collects the recent releases of the given artists from their
+// discographies and the service's new releases, newest first.
+func (g generator) releases(rs ReleaseService, near []Artist, r Radar) ([]Release, error) {
+	cutoff := g.clock().AddDate(0, 0, -7*r.Weeks)
+	wanted := make(map[string]bool)
+	for _, a := range near {
+		wanted[a.ID] = true
+	}
+
+	seen := make(map[string]bool)
+	var out []Release
+	keep := func(rl Release) {
+		if seen[rl.Album.ID] || !r.wants(rl.Type) || rl.Album.ReleaseDate.Before(cutoff) {
+			return
+		}
+		seen[rl.Album.ID] = true
+		out = append(out, rl)
+	}
+
+	fresh, err := rs.NewReleases()
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot fetch new releases")
+	}
+
+	for _, rl := range fresh {
+		if wanted[rl.Artist.ID] {
+			keep(rl)
+		}
+	}
+
+	for i, a := range near {
+		if i >= radarArtists {
+			break
+		}
+
+		rls, err := rs.ArtistReleases(a.ID, r.Types)
+		if err != nil {
+			return nil, errors.Wrap(err, "cannot fetch artist releases")
+		}
+
+		for _, rl := range rls {
+			keep(rl)
+		}
+	}
+
+	sort.SliceStable(out, func(i, j int) bool {
+		return out[i].Album.ReleaseDate.After(out[j].Album.ReleaseDate)
+	})
+
+	return out, nil
+}
+
+// nearArtists returns the artists related to the top artists that are not
+// top artists themselves, in the order they were found.
+func nearArtists(rel RelatedArtistService, top []Artist) ([]Artist, error) {
+	seen := make(map[string]bool)
+	for _, t := range top {
+		seen[t.ID] = true
+	}
+
+	var near []Artist
+	for i, t := range top {
+		if i >= hopSources {
+			break
+		}
+
+		rs, err := rel.RelatedArtists(t.ID)
+		if err != nil {
+			return nil, errors.Wrap(err, "cannot fetch related artists")
+		}
+
+		for _, r := range rs {
+			if !seen[r.ID] {
+				seen[r.ID] = true
+				near = append(near, r)
+			}
+		}
+	}
+
+	return near, nil
+}
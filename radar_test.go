@@ -0,0 +1,175 @@
+package refind
+
+import (
+	"github.com/pkg/errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+var testNow = time.Date(2020, 6, 15, 0, 0, 0, 0, time.UTC)
+
+type fakeReleaseService struct {
+	fakeRelatedService
+	fresh  []Release
+	discog map[string][]Release
+	err    error
+}
+
+func (f fakeReleaseService) NewReleases() ([]Release, error) {
+	return f.fresh, f.err
+}
+
+func (f fakeReleaseService) ArtistReleases(id string, types []ReleaseType) ([]Release, error) {
+	return f.discog[id], f.err
+}
+
+func (f fakeReleaseService) LeadTracks(ids []string) (map[string]Track, error) {
+	leads := make(map[string]Track)
+	for _, id := range ids {
+		leads[id] = Track{ID: "lead-" + id}
+	}
+
+	return leads, nil
+}
+
+func testRadarRelease(id string, art Artist, days int, typ ReleaseType) Release {
+	return Release{
+		Album:  Album{ID: id, ReleaseDate: testNow.AddDate(0, 0, -days)},
+		Artist: art,
+		Type:   typ,
+	}
+}
+
+func testLead(rl Release, art Artist) Track {
+	return Track{ID: "lead-" + rl.Album.ID, Artist: art, Album: rl.Album}
+}
+
+func TestGenerator_RadarTracklist(t *testing.T) {
+	b1 := testRadarRelease("b1", testArtistB, 7, AlbumRelease)
+	b2 := testRadarRelease("b2", testArtistB, 70, SingleRelease)
+	b3 := testRadarRelease("b3", testArtistB, 4, SingleRelease)
+	c1 := testRadarRelease("c1", testArtistC, 2, SingleRelease)
+	c2 := testRadarRelease("c2", testArtistA, 3, CompilationRelease)
+	d1 := testRadarRelease("d1", testArtistD, 1, AlbumRelease)
+
+	serv := fakeReleaseService{
+		fakeRelatedService: fakeRelatedService{
+			fakeMusicService: fakeMusicService{artists: []Artist{testArtistA}},
+			related:          map[string][]Artist{"a": {testArtistB, testArtistC}},
+		},
+		fresh: []Release{d1, b1},
+		discog: map[string][]Release{
+			"b": {b1, b2, b3},
+			"c": {c1, c2},
+		},
+	}
+
+	tests := []struct {
+		name    string
+		serv    MusicService
+		n       int
+		radar   Radar
+		want    []Track
+		wantErr error
+	}{
+		{
+			name:    "Recent releases newest first",
+			serv:    serv,
+			n:       10,
+			radar:   Radar{Weeks: 2},
+			want:    []Track{testLead(c1, testArtistC), testLead(b3, testArtistB)},
+			wantErr: nil,
+		},
+		{
+			name:    "Older releases within range",
+			serv:    serv,
+			n:       1,
+			radar:   Radar{Weeks: 12},
+			want:    []Track{testLead(c1, testArtistC)},
+			wantErr: nil,
+		},
+		{
+			name:    "Albums only",
+			serv:    serv,
+			n:       10,
+			radar:   Radar{Weeks: 2, Types: []ReleaseType{AlbumRelease}},
+			want:    []Track{testLead(b1, testArtistB)},
+			wantErr: nil,
+		},
+		{
+			name:    "Zero weeks",
+			serv:    serv,
+			n:       10,
+			radar:   Radar{},
+			want:    nil,
+			wantErr: ErrRangeInvalid,
+		},
+		{
+			name:    "Releases unsupported",
+			serv:    serv.fakeRelatedService,
+			n:       10,
+			radar:   Radar{Weeks: 2},
+			want:    nil,
+			wantErr: ErrNoReleases,
+		},
+		{
+			name:    "Fetch error",
+			serv:    fakeReleaseService{fakeRelatedService: serv.fakeRelatedService, err: testErrFetchTracks},
+			n:       10,
+			radar:   Radar{Weeks: 2},
+			want:    nil,
+			wantErr: testErrFetchTracks,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			g := &generator{serv: test.serv, rec: fakeRecommender{}, now: func() time.Time { return testNow }}
+
+			got, err := g.RadarTracklist(test.n, test.radar)
+			if errors.Cause(err) != test.wantErr {
+				t.Errorf("got: <%v>, want: <%v>", errors.Cause(err), test.wantErr)
+			}
+
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("got: <%v>, want: <%v>", got, test.want)
+			}
+		})
+	}
+}
+
+func TestGenerator_RadarLogging(t *testing.T) {
+	serv := fakeReleaseService{
+		fakeRelatedService: fakeRelatedService{
+			fakeMusicService: fakeMusicService{artists: []Artist{testArtistA}},
+			related:          map[string][]Artist{"a": {testArtistB}},
+		},
+		discog: map[string][]Release{"b": {testRadarRelease("b1", testArtistB, 1, AlbumRelease)}},
+	}
+
+	tests := []struct {
+		name     string
+		serv     MusicService
+		wantMsgs []string
+	}{
+		{"Every stage", serv, []string{"related artists found", "releases fetched", "tracks filtered", "radar generated"}},
+		{"Failed stage", fakeReleaseService{fakeRelatedService: serv.fakeRelatedService, err: testErrFetchTracks}, []string{"related artists found", "radar failed"}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var recs []logRecord
+			g := &generator{serv: test.serv, rec: fakeRecommender{}, now: func() time.Time { return testNow }, log: recordingLogger{records: &recs}}
+
+			g.RadarTracklist(testTotal, Radar{Weeks: 2})
+
+			var msgs []string
+			for _, r := range recs {
+				msgs = append(msgs, r.msg)
+			}
+
+			if !reflect.DeepEqual(msgs, test.wantMsgs) {
+				t.Errorf("got: <%v>, want: <%v>", msgs, test.wantMsgs)
+			}
+		})
+	}
+}
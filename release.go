@@ -0,0 +1,26 @@
+package refind
+
+// ReleaseType distinguishes albums from singles and compilations.
+type ReleaseType int
+
+const (
+	AlbumRelease ReleaseType = iota
+	SingleRelease
+	CompilationRelease
+)
+
+// Release is an album, single or compilation credited first to Artist.
+type Release struct {
+	Album  Album
+	Artist Artist
+	Type   ReleaseType
+}
+
+// ReleaseService is implemented by music services that can list recent
+// releases and the first track of a release.
+type ReleaseService interface {
+	NewReleases() ([]Release, error)
+	ArtistReleases(id string, types []ReleaseType) ([]Release, error)
+	// LeadTracks returns the first track of each album, keyed by album ID.
+	LeadTracks(albumIDs []string) (map[string]Track, error)
+}
@@ -0,0 +1,490 @@
+package replay
+
+import (
+	"encoding/json"
+	"github.com/Henry-Sarabia/refind"
+	"github.com/pkg/errors"
+	"io"
+	"reflect"
+	"sync"
+)
+
+const (
+	methodTopArtists      string = "TopArtists"
+	methodTopTracks       string = "TopTracks"
+	methodRecentTracks    string = "RecentTracks"
+	methodTopArtistsRange string = "TopArtistsRange"
+	methodTopTracksRange  string = "TopTracksRange"
+	methodRecommendations string = "Recommendations"
+	methodAudioFeatures   string = "AudioFeatures"
+	methodTuned           string = "TunedRecommendations"
+	methodRelatedArtists  string = "RelatedArtists"
+	methodNewReleases     string = "NewReleases"
+	methodArtistReleases  string = "ArtistReleases"
+	methodLeadTracks      string = "LeadTracks"
+)
+
+var (
+	errNilRecorder  = errors.New("cannot initialize new recorder using nil interface")
+	errNoFeatures   = errors.New("music service does not support audio features")
+	errSessionEmpty = errors.New("cannot replay session without recorded calls")
+	errExhausted    = errors.New("no recorded calls remain in session")
+	errCallMismatch = errors.New("call does not match next recorded call")
+)
+
+// Session is every response a generation received from its MusicService and
+// Recommender along with the random seed it used for sampling.
+type Session struct {
+	Seed  int64  `json:"seed"`
+	Calls []Call `json:"calls"`
+}
+
+// Call is a single recorded request and its response.
+type Call struct {
+	Method   string                     `json:"method"`
+	N        int                        `json:"n,omitempty"`
+	Range    *refind.TimeRange          `json:"range,omitempty"`
+	Seeds    []refind.Seed              `json:"seeds,omitempty"`
+	IDs      []string                   `json:"ids,omitempty"`
+	Tuning   *refind.Tuning             `json:"tuning,omitempty"`
+	Types    []refind.ReleaseType       `json:"types,omitempty"`
+	Artists  []refind.Artist            `json:"artists,omitempty"`
+	Tracks   []refind.Track             `json:"tracks,omitempty"`
+	Feats    map[string]refind.Features `json:"features,omitempty"`
+	Releases []refind.Release           `json:"releases,omitempty"`
+	Leads    map[string]refind.Track    `json:"leads,omitempty"`
+	Err      string                     `json:"error,omitempty"`
+	Sentinel string                     `json:"sentinel,omitempty"`
+}
+
+// sentinels are the refind errors that a replayed error still matches with
+// errors.Is, under the name recorded in Call.Sentinel.
+var sentinels = []struct {
+	name string
+	err  error
+}{
+	{"unauthorized", refind.ErrUnauthorized},
+	{"rate_limited", refind.ErrRateLimited},
+	{"range_invalid", refind.ErrRangeInvalid},
+	{"seeds_missing", refind.ErrSeedsMissing},
+	{"data_invalid", refind.ErrDataInvalid},
+	{"no_history", refind.ErrNoHistory},
+	{"no_ranges", refind.ErrNoRanges},
+	{"no_related", refind.ErrNoRelated},
+	{"no_releases", refind.ErrNoReleases},
+	{"no_tuning", refind.ErrNoTuning},
+}
+
+// recordedError is a replayed error. It keeps the recorded message and
+// matches the recorded sentinel, if any.
+type recordedError struct {
+	msg      string
+	sentinel error
+}
+
+func (e recordedError) Error() string {
+	return e.msg
+}
+
+func (e recordedError) Is(target error) bool {
+	return e.sentinel != nil && target == e.sentinel
+}
+
+func (c Call) err() error {
+	if c.Err == "" {
+		return nil
+	}
+
+	e := recordedError{msg: c.Err}
+	for _, st := range sentinels {
+		if st.name == c.Sentinel {
+			e.sentinel = st.err
+		}
+	}
+
+	return e
+}
+
+// sentinelName returns the name of the first sentinel that err matches.
+func sentinelName(err error) string {
+	if err == nil {
+		return ""
+	}
+
+	for _, st := range sentinels {
+		if errors.Is(err, st.err) {
+			return st.name
+		}
+	}
+
+	return ""
+}
+
+func errString(err error) string {
+	if err == nil {
+		return ""
+	}
+
+	return err.Error()
+}
+
+// Load decodes a session previously written by Save.
+func Load(r io.Reader) (Session, error) {
+	var s Session
+	if err := json.NewDecoder(r).Decode(&s); err != nil {
+		return Session{}, errors.Wrap(err, "cannot decode session")
+	}
+
+	return s, nil
+}
+
+// Save encodes the session as indented JSON so that identical sessions are
+// written byte for byte the same.
+func Save(w io.Writer, s Session) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(s); err != nil {
+		return errors.Wrap(err, "cannot encode session")
+	}
+
+	return nil
+}
+
+type recorder struct {
+	serv refind.MusicService
+	rec  refind.Recommender
+
+	mu   sync.Mutex
+	sess Session
+}
+
+// NewRecorder returns a MusicService and Recommender that pass every call
+// through to serv and rec while recording the responses.
+func NewRecorder(serv refind.MusicService, rec refind.Recommender, seed int64) (*recorder, error) {
+	if serv == nil || rec == nil {
+		return nil, errNilRecorder
+	}
+
+	return &recorder{serv: serv, rec: rec, sess: Session{Seed: seed}}, nil
+}
+
+// Session returns a copy of every call recorded so far.
+func (r *recorder) Session() Session {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	s := Session{Seed: r.sess.Seed}
+	s.Calls = append(s.Calls, r.sess.Calls...)
+	return s
+}
+
+func (r *recorder) add(c Call, err error) {
+	c.Err = errString(err)
+	c.Sentinel = sentinelName(err)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.sess.Calls = append(r.sess.Calls, c)
+}
+
+func (r *recorder) TopArtists() ([]refind.Artist, error) {
+	art, err := r.serv.TopArtists()
+	r.add(Call{Method: methodTopArtists, Artists: art}, err)
+	return art, err
+}
+
+func (r *recorder) TopTracks() ([]refind.Track, error) {
+	trk, err := r.serv.TopTracks()
+	r.add(Call{Method: methodTopTracks, Tracks: trk}, err)
+	return trk, err
+}
+
+func (r *recorder) RecentTracks() ([]refind.Track, error) {
+	trk, err := r.serv.RecentTracks()
+	r.add(Call{Method: methodRecentTracks, Tracks: trk}, err)
+	return trk, err
+}
+
+func (r *recorder) TopArtistsRange(tr refind.TimeRange, limit int) ([]refind.Artist, error) {
+	var art []refind.Artist
+	err := refind.ErrNoRanges
+	if rs, ok := r.serv.(refind.RangedMusicService); ok {
+		art, err = rs.TopArtistsRange(tr, limit)
+	}
+
+	r.add(Call{Method: methodTopArtistsRange, N: limit, Range: &tr, Artists: art}, err)
+	return art, err
+}
+
+func (r *recorder) TopTracksRange(tr refind.TimeRange, limit int) ([]refind.Track, error) {
+	var trk []refind.Track
+	err := refind.ErrNoRanges
+	if rs, ok := r.serv.(refind.RangedMusicService); ok {
+		trk, err = rs.TopTracksRange(tr, limit)
+	}
+
+	r.add(Call{Method: methodTopTracksRange, N: limit, Range: &tr, Tracks: trk}, err)
+	return trk, err
+}
+
+func (r *recorder) AudioFeatures(ids []string) (map[string]refind.Features, error) {
+	var feats map[string]refind.Features
+	err := errNoFeatures
+	if fs, ok := r.serv.(refind.FeatureService); ok {
+		feats, err = fs.AudioFeatures(ids)
+	}
+
+	r.add(Call{Method: methodAudioFeatures, IDs: ids, Feats: feats}, err)
+	return feats, err
+}
+
+func (r *recorder) Recommendations(n int, sds []refind.Seed) ([]refind.Track, error) {
+	trk, err := r.rec.Recommendations(n, sds)
+	r.add(Call{Method: methodRecommendations, N: n, Seeds: sds, Tracks: trk}, err)
+	return trk, err
+}
+
+func (r *recorder) TunedRecommendations(n int, sds []refind.Seed, t refind.Tuning) ([]refind.Track, error) {
+	var trk []refind.Track
+	err := refind.ErrNoTuning
+	if tr, ok := r.rec.(refind.TunedRecommender); ok {
+		trk, err = tr.TunedRecommendations(n, sds, t)
+	}
+
+	r.add(Call{Method: methodTuned, N: n, Seeds: sds, Tuning: &t, Tracks: trk}, err)
+	return trk, err
+}
+
+func (r *recorder) RelatedArtists(id string) ([]refind.Artist, error) {
+	var art []refind.Artist
+	err := refind.ErrNoRelated
+	if rs, ok := r.serv.(refind.RelatedArtistService); ok {
+		art, err = rs.RelatedArtists(id)
+	}
+
+	r.add(Call{Method: methodRelatedArtists, IDs: []string{id}, Artists: art}, err)
+	return art, err
+}
+
+func (r *recorder) NewReleases() ([]refind.Release, error) {
+	var rls []refind.Release
+	err := refind.ErrNoReleases
+	if rs, ok := r.serv.(refind.ReleaseService); ok {
+		rls, err = rs.NewReleases()
+	}
+
+	r.add(Call{Method: methodNewReleases, Releases: rls}, err)
+	return rls, err
+}
+
+func (r *recorder) ArtistReleases(id string, types []refind.ReleaseType) ([]refind.Release, error) {
+	var rls []refind.Release
+	err := refind.ErrNoReleases
+	if rs, ok := r.serv.(refind.ReleaseService); ok {
+		rls, err = rs.ArtistReleases(id, types)
+	}
+
+	r.add(Call{Method: methodArtistReleases, IDs: []string{id}, Types: types, Releases: rls}, err)
+	return rls, err
+}
+
+func (r *recorder) LeadTracks(ids []string) (map[string]refind.Track, error) {
+	var leads map[string]refind.Track
+	err := refind.ErrNoReleases
+	if rs, ok := r.serv.(refind.ReleaseService); ok {
+		leads, err = rs.LeadTracks(ids)
+	}
+
+	r.add(Call{Method: methodLeadTracks, IDs: ids, Leads: leads}, err)
+	return leads, err
+}
+
+type player struct {
+	mu    sync.Mutex
+	sess  Session
+	calls []Call
+}
+
+// NewPlayer returns a MusicService and Recommender that answer each call
+// with the next response recorded in the session. A call that differs from
+// the recorded one returns an error so that divergent runs are caught.
+func NewPlayer(s Session) (*player, error) {
+	if len(s.Calls) == 0 {
+		return nil, errSessionEmpty
+	}
+
+	return &player{sess: s, calls: s.Calls}, nil
+}
+
+// Seed returns the random seed used by the recorded generation.
+func (p *player) Seed() int64 {
+	return p.sess.Seed
+}
+
+// Remaining returns the number of recorded calls that have not been replayed.
+func (p *player) Remaining() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return len(p.calls)
+}
+
+func (p *player) next(want Call) (Call, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if len(p.calls) == 0 {
+		return Call{}, errExhausted
+	}
+
+	c := p.calls[0]
+	if !matches(c, want) {
+		return Call{}, errors.Wrapf(errCallMismatch, "got %s, recorded %s", want.Method, c.Method)
+	}
+	p.calls = p.calls[1:]
+
+	return c, nil
+}
+
+func matches(c, want Call) bool {
+	if c.Method != want.Method || c.N != want.N {
+		return false
+	}
+
+	if !reflect.DeepEqual(c.Range, want.Range) || !reflect.DeepEqual(c.Tuning, want.Tuning) {
+		return false
+	}
+
+	if len(c.Types) > 0 || len(want.Types) > 0 {
+		if !reflect.DeepEqual(c.Types, want.Types) {
+			return false
+		}
+	}
+
+	return sameSeeds(c.Seeds, want.Seeds) && sameIDs(c.IDs, want.IDs)
+}
+
+func sameSeeds(a, b []refind.Seed) bool {
+	if len(a) == 0 && len(b) == 0 {
+		return true
+	}
+
+	return reflect.DeepEqual(a, b)
+}
+
+func sameIDs(a, b []string) bool {
+	if len(a) == 0 && len(b) == 0 {
+		return true
+	}
+
+	return reflect.DeepEqual(a, b)
+}
+
+func (p *player) TopArtists() ([]refind.Artist, error) {
+	c, err := p.next(Call{Method: methodTopArtists})
+	if err != nil {
+		return nil, err
+	}
+
+	return c.Artists, c.err()
+}
+
+func (p *player) TopTracks() ([]refind.Track, error) {
+	c, err := p.next(Call{Method: methodTopTracks})
+	if err != nil {
+		return nil, err
+	}
+
+	return c.Tracks, c.err()
+}
+
+func (p *player) RecentTracks() ([]refind.Track, error) {
+	c, err := p.next(Call{Method: methodRecentTracks})
+	if err != nil {
+		return nil, err
+	}
+
+	return c.Tracks, c.err()
+}
+
+func (p *player) TopArtistsRange(tr refind.TimeRange, limit int) ([]refind.Artist, error) {
+	c, err := p.next(Call{Method: methodTopArtistsRange, N: limit, Range: &tr})
+	if err != nil {
+		return nil, err
+	}
+
+	return c.Artists, c.err()
+}
+
+func (p *player) TopTracksRange(tr refind.TimeRange, limit int) ([]refind.Track, error) {
+	c, err := p.next(Call{Method: methodTopTracksRange, N: limit, Range: &tr})
+	if err != nil {
+		return nil, err
+	}
+
+	return c.Tracks, c.err()
+}
+
+func (p *player) Recommendations(n int, sds []refind.Seed) ([]refind.Track, error) {
+	c, err := p.next(Call{Method: methodRecommendations, N: n, Seeds: sds})
+	if err != nil {
+		return nil, err
+	}
+
+	return c.Tracks, c.err()
+}
+
+func (p *player) AudioFeatures(ids []string) (map[string]refind.Features, error) {
+	c, err := p.next(Call{Method: methodAudioFeatures, IDs: ids})
+	if err != nil {
+		return nil, err
+	}
+
+	return c.Feats, c.err()
+}
+
+func (p *player) TunedRecommendations(n int, sds []refind.Seed, t refind.Tuning) ([]refind.Track, error) {
+	c, err := p.next(Call{Method: methodTuned, N: n, Seeds: sds, Tuning: &t})
+	if err != nil {
+		return nil, err
+	}
+
+	return c.Tracks, c.err()
+}
+
+func (p *player) RelatedArtists(id string) ([]refind.Artist, error) {
+	c, err := p.next(Call{Method: methodRelatedArtists, IDs: []string{id}})
+	if err != nil {
+		return nil, err
+	}
+
+	return c.Artists, c.err()
+}
+
+func (p *player) NewReleases() ([]refind.Release, error) {
+	c, err := p.next(Call{Method: methodNewReleases})
+	if err != nil {
+		return nil, err
+	}
+
+	return c.Releases, c.err()
+}
+
+func (p *player) ArtistReleases(id string, types []refind.ReleaseType) ([]refind.Release, error) {
+	c, err := p.next(Call{Method: methodArtistReleases, IDs: []string{id}, Types: types})
+	if err != nil {
+		return nil, err
+	}
+
+	return c.Releases, c.err()
+}
+
+func (p *player) LeadTracks(ids []string) (map[string]refind.Track, error) {
+	c, err := p.next(Call{Method: methodLeadTracks, IDs: ids})
+	if err != nil {
+		return nil, err
+	}
+
+	return c.Leads, c.err()
+}
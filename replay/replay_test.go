@@ -0,0 +1,360 @@
+package replay
+
+import (
+	"bytes"
+	"github.com/Henry-Sarabia/refind"
+	"github.com/pkg/errors"
+	"reflect"
+	"testing"
+)
+
+const testSeed int64 = 42
+
+var testErrFetchTracks = errors.New("cannot fetch tracks")
+
+type fakeMusicService struct {
+	artists  []refind.Artist
+	tracks   []refind.Track
+	trackErr error
+}
+
+func (f fakeMusicService) TopArtists() ([]refind.Artist, error) {
+	return f.artists, nil
+}
+
+func (f fakeMusicService) TopTracks() ([]refind.Track, error) {
+	return f.tracks, f.trackErr
+}
+
+func (f fakeMusicService) RecentTracks() ([]refind.Track, error) {
+	return f.tracks, f.trackErr
+}
+
+type fakeRecommender struct {
+	tracks []refind.Track
+}
+
+func (f fakeRecommender) Recommendations(int, []refind.Seed) ([]refind.Track, error) {
+	return f.tracks, nil
+}
+
+func testService() fakeMusicService {
+	return fakeMusicService{
+		artists: []refind.Artist{
+			{ID: "0", Name: "foo", Rankings: []refind.Ranking{{Range: refind.ShortTerm, Rank: 1}}},
+		},
+		tracks: []refind.Track{
+			{ID: "10", Name: "baz", Artist: refind.Artist{ID: "0", Name: "foo"}},
+			{ID: "11", Name: "qux", Artist: refind.Artist{ID: "1", Name: "bar"}},
+			{ID: "12", Name: "quux", Artist: refind.Artist{ID: "2", Name: "fred"}},
+		},
+	}
+}
+
+func testRecommender() fakeRecommender {
+	return fakeRecommender{
+		tracks: []refind.Track{
+			{ID: "20", Name: "corge", Artist: refind.Artist{ID: "3", Name: "thud"}, Provenance: &refind.Provenance{Group: 0, Tracks: []string{"10"}}},
+			{ID: "21", Name: "grault", Artist: refind.Artist{ID: "0", Name: "foo"}, Provenance: &refind.Provenance{Group: 0, Tracks: []string{"10"}}},
+		},
+	}
+}
+
+func TestNewRecorder(t *testing.T) {
+	tests := []struct {
+		name    string
+		serv    refind.MusicService
+		rec     refind.Recommender
+		wantErr error
+	}{
+		{"Valid interfaces", fakeMusicService{}, fakeRecommender{}, nil},
+		{"Nil MusicService", nil, fakeRecommender{}, errNilRecorder},
+		{"Nil Recommender", fakeMusicService{}, nil, errNilRecorder},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := NewRecorder(test.serv, test.rec, testSeed)
+			if err != test.wantErr {
+				t.Errorf("got: <%v>, want: <%v>", err, test.wantErr)
+			}
+		})
+	}
+}
+
+func TestRecordReplay(t *testing.T) {
+	r, err := NewRecorder(testService(), testRecommender(), testSeed)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	gen, err := refind.New(r, r)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := gen.SetSelector(2, refind.RandomSampling(testSeed)); err != nil {
+		t.Fatal(err)
+	}
+
+	want, err := gen.Tracklist(10)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var recorded bytes.Buffer
+	if err := Save(&recorded, r.Session()); err != nil {
+		t.Fatal(err)
+	}
+
+	sess, err := Load(bytes.NewReader(recorded.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p, err := NewPlayer(sess)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Record the replay as well so the two sessions can be compared byte
+	// for byte.
+	again, err := NewRecorder(p, p, p.Seed())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	gen, err = refind.New(again, again)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := gen.SetSelector(2, refind.RandomSampling(p.Seed())); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := gen.Tracklist(10)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got: <%v>, want: <%v>", got, want)
+	}
+
+	if p.Remaining() != 0 {
+		t.Errorf("got: <%v>, want: <%v>", p.Remaining(), 0)
+	}
+
+	var replayed bytes.Buffer
+	if err := Save(&replayed, again.Session()); err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(replayed.Bytes(), recorded.Bytes()) {
+		t.Errorf("got: <%s>, want: <%s>", replayed.String(), recorded.String())
+	}
+}
+
+func TestPlayer_Mismatch(t *testing.T) {
+	tests := []struct {
+		name    string
+		sess    Session
+		call    func(p *player) error
+		wantErr error
+	}{
+		{
+			"Different method",
+			Session{Calls: []Call{{Method: methodTopArtists}}},
+			func(p *player) error {
+				_, err := p.RecentTracks()
+				return err
+			},
+			errCallMismatch,
+		},
+		{
+			"Different seeds",
+			Session{Calls: []Call{{Method: methodRecommendations, N: 10, Seeds: []refind.Seed{{Category: refind.TrackSeed, ID: "10"}}}}},
+			func(p *player) error {
+				_, err := p.Recommendations(10, []refind.Seed{{Category: refind.TrackSeed, ID: "11"}})
+				return err
+			},
+			errCallMismatch,
+		},
+		{
+			"Different range",
+			Session{Calls: []Call{{Method: methodTopArtistsRange, N: 50, Range: new(refind.TimeRange)}}},
+			func(p *player) error {
+				_, err := p.TopArtistsRange(refind.LongTerm, 50)
+				return err
+			},
+			errCallMismatch,
+		},
+		{
+			"Different feature IDs",
+			Session{Calls: []Call{{Method: methodAudioFeatures, IDs: []string{"10"}}}},
+			func(p *player) error {
+				_, err := p.AudioFeatures([]string{"11"})
+				return err
+			},
+			errCallMismatch,
+		},
+		{
+			"Exhausted session",
+			Session{Calls: []Call{{Method: methodTopArtists}}},
+			func(p *player) error {
+				p.TopArtists()
+				_, err := p.TopArtists()
+				return err
+			},
+			errExhausted,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			p, err := NewPlayer(test.sess)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			err = test.call(p)
+			if errors.Cause(err) != test.wantErr {
+				t.Errorf("got: <%v>, want: <%v>", errors.Cause(err), test.wantErr)
+			}
+		})
+	}
+}
+
+func TestPlayer_RecordedError(t *testing.T) {
+	serv := testService()
+	serv.trackErr = testErrFetchTracks
+
+	r, err := NewRecorder(serv, testRecommender(), testSeed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.RecentTracks()
+
+	p, err := NewPlayer(r.Session())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = p.RecentTracks()
+	if err == nil || err.Error() != testErrFetchTracks.Error() {
+		t.Errorf("got: <%v>, want: <%v>", err, testErrFetchTracks)
+	}
+}
+
+func TestNewPlayer_Empty(t *testing.T) {
+	if _, err := NewPlayer(Session{}); err != errSessionEmpty {
+		t.Errorf("got: <%v>, want: <%v>", err, errSessionEmpty)
+	}
+}
+
+func TestPlayer_RecordedSentinel(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		wantIs   error
+		wantName string
+	}{
+		{"Unauthorized", &refind.APIError{Endpoint: "GET /me/player/recently-played", Status: 401, Message: "expired"}, refind.ErrUnauthorized, "unauthorized"},
+		{"Rate limited", errors.Wrap(&refind.APIError{Endpoint: "GET /me/player/recently-played", Status: 429}, "cannot fetch"), refind.ErrRateLimited, "rate_limited"},
+		{"No history", errors.Wrap(refind.ErrNoHistory, "cannot fetch"), refind.ErrNoHistory, "no_history"},
+		{"Plain error", testErrFetchTracks, nil, ""},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			serv := testService()
+			serv.trackErr = test.err
+
+			r, err := NewRecorder(serv, testRecommender(), testSeed)
+			if err != nil {
+				t.Fatal(err)
+			}
+			r.RecentTracks()
+
+			var buf bytes.Buffer
+			if err := Save(&buf, r.Session()); err != nil {
+				t.Fatal(err)
+			}
+
+			sess, err := Load(&buf)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got := sess.Calls[0].Sentinel; got != test.wantName {
+				t.Errorf("got: <%v>, want: <%v>", got, test.wantName)
+			}
+
+			p, err := NewPlayer(sess)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			_, err = p.RecentTracks()
+			if err == nil || err.Error() != test.err.Error() {
+				t.Errorf("got: <%v>, want: <%v>", err, test.err)
+			}
+
+			for _, sentinel := range []error{refind.ErrUnauthorized, refind.ErrRateLimited, refind.ErrNoHistory} {
+				if got := errors.Is(err, sentinel); got != (sentinel == test.wantIs) {
+					t.Errorf("%v got: <%v>, want: <%v>", sentinel, got, sentinel == test.wantIs)
+				}
+			}
+		})
+	}
+}
+
+// capabilities are the optional methods of a recorder and a player.
+type capabilities interface {
+	TopArtistsRange(refind.TimeRange, int) ([]refind.Artist, error)
+	TunedRecommendations(int, []refind.Seed, refind.Tuning) ([]refind.Track, error)
+	RelatedArtists(string) ([]refind.Artist, error)
+	ArtistReleases(string, []refind.ReleaseType) ([]refind.Release, error)
+}
+
+func TestPlayer_CapabilityMiss(t *testing.T) {
+	tests := []struct {
+		name   string
+		call   func(c capabilities) error
+		wantIs error
+	}{
+		{"No ranges", func(c capabilities) error { _, err := c.TopArtistsRange(refind.ShortTerm, 10); return err }, refind.ErrNoRanges},
+		{"No tuning", func(c capabilities) error { _, err := c.TunedRecommendations(5, nil, refind.Tuning{}); return err }, refind.ErrNoTuning},
+		{"No related", func(c capabilities) error { _, err := c.RelatedArtists("0"); return err }, refind.ErrNoRelated},
+		{"No releases", func(c capabilities) error { _, err := c.ArtistReleases("0", nil); return err }, refind.ErrNoReleases},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			r, err := NewRecorder(testService(), testRecommender(), testSeed)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if err := test.call(r); !errors.Is(err, test.wantIs) {
+				t.Errorf("got: <%v>, want: <%v>", err, test.wantIs)
+			}
+
+			var buf bytes.Buffer
+			if err := Save(&buf, r.Session()); err != nil {
+				t.Fatal(err)
+			}
+
+			sess, err := Load(&buf)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			p, err := NewPlayer(sess)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if err := test.call(p); !errors.Is(err, test.wantIs) {
+				t.Errorf("got: <%v>, want: <%v>", err, test.wantIs)
+			}
+		})
+	}
+}
@@ -0,0 +1,28 @@
+package refind
+
+import (
+	"time"
+)
+
+// Report describes how many tracks survived each stage of a generation and
+// how long each stage took. Seeds counts every seed collected, before the
+// generator's selector narrows them down to SelectedSeeds.
+type Report struct {
+	Seeds              int     `json:"seeds"`
+	SelectedSeeds      int     `json:"selected_seeds"`
+	SeedGroups         int     `json:"seed_groups"`
+	Recommendations    int     `json:"recommendations"`
+	KnownRemoved       int     `json:"known_removed"`
+	DuplicatesRemoved  int     `json:"duplicates_removed"`
+	ConstraintsRemoved int     `json:"constraints_removed"`
+	Final              int     `json:"final"`
+	Timings            Timings `json:"timings"`
+}
+
+type Timings struct {
+	Seeds           time.Duration `json:"seeds_ns"`
+	Recommendations time.Duration `json:"recommendations_ns"`
+	Filter          time.Duration `json:"filter_ns"`
+	Order           time.Duration `json:"order_ns"`
+	Total           time.Duration `json:"total_ns"`
+}
@@ -0,0 +1,166 @@
+package refind
+
+import (
+	"math/rand"
+	"sort"
+)
+
+// Selector picks at most n seeds from a list ordered from most to least
+// recent. The list may contain the same seed more than once.
+type Selector interface {
+	Select(n int, sds []Seed) []Seed
+}
+
+type recency struct{}
+
+// RecencyWeighted favors seeds that appear near the front of the list.
+// Repeated seeds accumulate the weight of every position they hold.
+func RecencyWeighted() Selector {
+	return recency{}
+}
+
+func (recency) Select(n int, sds []Seed) []Seed {
+	return top(n, sds, func(pos int) float64 {
+		return 1 / float64(pos+1)
+	})
+}
+
+type frequency struct{}
+
+// FrequencyWeighted favors seeds that appear most often in the list, such
+// as tracks with repeat plays. Ties are broken by recency.
+func FrequencyWeighted() Selector {
+	return frequency{}
+}
+
+func (frequency) Select(n int, sds []Seed) []Seed {
+	return top(n, sds, func(int) float64 {
+		return 1
+	})
+}
+
+type diversity struct{}
+
+// DiversityMaximizing spreads its picks evenly across seed categories and
+// across the whole list rather than clustering on the most recent seeds.
+func DiversityMaximizing() Selector {
+	return diversity{}
+}
+
+func (diversity) Select(n int, sds []Seed) []Seed {
+	uniq := unique(sds)
+	if n <= 0 || len(uniq) == 0 {
+		return nil
+	}
+
+	var cats []SeedCategory
+	groups := make(map[SeedCategory][]Seed)
+	for _, u := range uniq {
+		if _, ok := groups[u.Category]; !ok {
+			cats = append(cats, u.Category)
+		}
+		groups[u.Category] = append(groups[u.Category], u)
+	}
+
+	quota := make(map[SeedCategory]int)
+	for left := minInt(n, len(uniq)); left > 0; {
+		for _, c := range cats {
+			if left > 0 && quota[c] < len(groups[c]) {
+				quota[c]++
+				left--
+			}
+		}
+	}
+
+	picks := make(map[SeedCategory][]Seed)
+	for _, c := range cats {
+		picks[c] = spread(quota[c], groups[c])
+	}
+
+	var curr []Seed
+	for i := 0; len(curr) < minInt(n, len(uniq)); i++ {
+		for _, c := range cats {
+			if i < len(picks[c]) {
+				curr = append(curr, picks[c][i])
+			}
+		}
+	}
+
+	return curr
+}
+
+type random struct {
+	seed int64
+}
+
+// RandomSampling picks seeds uniformly at random. The same seed value
+// always produces the same selection for the same input.
+func RandomSampling(seed int64) Selector {
+	return random{seed: seed}
+}
+
+func (r random) Select(n int, sds []Seed) []Seed {
+	uniq := unique(sds)
+	if n <= 0 || len(uniq) == 0 {
+		return nil
+	}
+
+	rnd := rand.New(rand.NewSource(r.seed))
+	rnd.Shuffle(len(uniq), func(i, j int) {
+		uniq[i], uniq[j] = uniq[j], uniq[i]
+	})
+
+	return uniq[:minInt(n, len(uniq))]
+}
+
+func top(n int, sds []Seed, weight func(pos int) float64) []Seed {
+	uniq := unique(sds)
+	if n <= 0 || len(uniq) == 0 {
+		return nil
+	}
+
+	score := make(map[Seed]float64)
+	for i, sd := range sds {
+		score[sd] += weight(i)
+	}
+
+	sort.SliceStable(uniq, func(i, j int) bool {
+		return score[uniq[i]] > score[uniq[j]]
+	})
+
+	return uniq[:minInt(n, len(uniq))]
+}
+
+func unique(sds []Seed) []Seed {
+	seen := make(map[Seed]bool)
+
+	var curr []Seed
+	for _, sd := range sds {
+		if !seen[sd] {
+			seen[sd] = true
+			curr = append(curr, sd)
+		}
+	}
+
+	return curr
+}
+
+func spread(n int, sds []Seed) []Seed {
+	if n <= 0 {
+		return nil
+	}
+
+	var curr []Seed
+	for i := 0; i < n; i++ {
+		curr = append(curr, sds[i*len(sds)/n])
+	}
+
+	return curr
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,116 @@
+package refind
+
+import (
+	"reflect"
+	"testing"
+)
+
+var (
+	testSeedA = Seed{Category: TrackSeed, ID: "a"}
+	testSeedB = Seed{Category: TrackSeed, ID: "b"}
+	testSeedC = Seed{Category: TrackSeed, ID: "c"}
+	testSeedD = Seed{Category: TrackSeed, ID: "d"}
+	testSeedX = Seed{Category: ArtistSeed, ID: "x"}
+	testSeedY = Seed{Category: ArtistSeed, ID: "y"}
+)
+
+func TestSelector_Select(t *testing.T) {
+	tests := []struct {
+		name string
+		sel  Selector
+		n    int
+		sds  []Seed
+		want []Seed
+	}{
+		{
+			"Recency with nil seeds",
+			RecencyWeighted(),
+			2,
+			nil,
+			nil,
+		},
+		{
+			"Recency with n out of range",
+			RecencyWeighted(),
+			0,
+			[]Seed{testSeedA, testSeedB},
+			nil,
+		},
+		{
+			"Recency with unique seeds",
+			RecencyWeighted(),
+			2,
+			[]Seed{testSeedA, testSeedB, testSeedC},
+			[]Seed{testSeedA, testSeedB},
+		},
+		{
+			"Recency with repeated seeds",
+			RecencyWeighted(),
+			2,
+			[]Seed{testSeedA, testSeedB, testSeedC, testSeedC, testSeedC, testSeedC},
+			[]Seed{testSeedA, testSeedC},
+		},
+		{
+			"Frequency with repeated seeds",
+			FrequencyWeighted(),
+			2,
+			[]Seed{testSeedA, testSeedB, testSeedC, testSeedB, testSeedC, testSeedC},
+			[]Seed{testSeedC, testSeedB},
+		},
+		{
+			"Frequency with ties",
+			FrequencyWeighted(),
+			3,
+			[]Seed{testSeedD, testSeedA, testSeedB},
+			[]Seed{testSeedD, testSeedA, testSeedB},
+		},
+		{
+			"Frequency with n larger than seeds",
+			FrequencyWeighted(),
+			10,
+			[]Seed{testSeedA, testSeedA},
+			[]Seed{testSeedA},
+		},
+		{
+			"Diversity with single category",
+			DiversityMaximizing(),
+			2,
+			[]Seed{testSeedA, testSeedB, testSeedC, testSeedD},
+			[]Seed{testSeedA, testSeedC},
+		},
+		{
+			"Diversity with multiple categories",
+			DiversityMaximizing(),
+			3,
+			[]Seed{testSeedA, testSeedB, testSeedC, testSeedX, testSeedY},
+			[]Seed{testSeedA, testSeedX, testSeedB},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := test.sel.Select(test.n, test.sds)
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("got: <%v>, want: <%v>", got, test.want)
+			}
+		})
+	}
+}
+
+func TestRandomSampling(t *testing.T) {
+	sds := []Seed{testSeedA, testSeedB, testSeedC, testSeedD, testSeedX, testSeedY}
+
+	first := RandomSampling(42).Select(3, sds)
+	second := RandomSampling(42).Select(3, sds)
+
+	if len(first) != 3 {
+		t.Errorf("got: <%v>, want: <%v>", len(first), 3)
+	}
+
+	if !reflect.DeepEqual(first, second) {
+		t.Errorf("got: <%v>, want: <%v>", second, first)
+	}
+
+	if !reflect.DeepEqual(sds, []Seed{testSeedA, testSeedB, testSeedC, testSeedD, testSeedX, testSeedY}) {
+		t.Errorf("input seeds were modified: <%v>", sds)
+	}
+}
@@ -0,0 +1,782 @@
+package server
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"github.com/Henry-Sarabia/refind"
+	"github.com/Henry-Sarabia/refind/user"
+	"github.com/pkg/errors"
+	"github.com/zmb3/spotify"
+	"golang.org/x/oauth2"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	sessionCookie   string        = "refind_session"
+	stateCookie     string        = "refind_state"
+	idBytes         int           = 16
+	stateTTL        time.Duration = 10 * time.Minute
+	sessionTTL      time.Duration = 30 * 24 * time.Hour
+	maxSessions     int           = 1000
+	defaultPlaylist string        = "refind"
+	playlistInfo    string        = "Generated by refind"
+)
+
+var (
+	errNilAuth      = errors.New("cannot initialize server using nil authenticator")
+	errNilConnector = errors.New("cannot initialize server using nil connector")
+	errNilStore     = errors.New("cannot initialize server using nil token store")
+	errNilRegistry  = errors.New("cannot use nil registry")
+	errNilLogger    = errors.New("cannot use nil logger")
+	errTTLInvalid   = errors.New("session TTL must be positive")
+	errMethod       = errors.New("method not allowed")
+	errStateInvalid = errors.New("unknown or expired OAuth state")
+	errNoSession    = errors.New("not logged in")
+	errTotalInvalid = errors.New("n must be a number between 1 and 100")
+	errBodyInvalid  = errors.New("request body must be a JSON object of settings")
+	errNoTracklist  = errors.New("no tracklist has been generated in this session")
+)
+
+// public reports whether the message of err is safe to send to clients.
+// Every other error is logged and answered with the text of its status code.
+func public(err error) bool {
+	switch err {
+	case errMethod, errStateInvalid, errNoSession, errTotalInvalid, errBodyInvalid, errNoTracklist:
+		return true
+	}
+
+	return errors.Is(err, user.ErrSettingsInvalid) || errors.Is(err, user.ErrQuotaExceeded)
+}
+
+// Authenticator runs the OAuth flow against the music service. The
+// authenticator from the spotify package satisfies it.
+type Authenticator interface {
+	AuthURL(state string) string
+	Token(state string, r *http.Request) (*oauth2.Token, error)
+}
+
+// Client is a single user's view of the music service. UserID identifies the
+// user in the registry. Token returns the client's current OAuth token so that
+// the server can keep it once the client refreshes it.
+type Client interface {
+	refind.MusicService
+	refind.Recommender
+	Playlist(name string, info string, list []refind.Track) (*spotify.FullPlaylist, error)
+	UserID() (string, error)
+	Token() (*oauth2.Token, error)
+}
+
+// Connector returns a Client authorized by the given token.
+type Connector func(tok *oauth2.Token) (Client, error)
+
+// Registry keeps the music service, buffer, settings, quota and history of
+// each user. The registry from the user package satisfies it.
+type Registry interface {
+	Register(id string, serv refind.MusicService, rec refind.Recommender) error
+	Settings(id string) (user.Settings, error)
+	SetSettings(id string, s user.Settings) error
+	Refresh(id string) error
+	History(id string) ([]user.Entry, error)
+	Generate(id string, s user.Settings) ([]refind.Track, refind.Report, error)
+}
+
+type result struct {
+	list []refind.Track
+	rep  refind.Report
+}
+
+// session is a logged in browser of the user with the music service ID user.
+// Sessions restored from the token store are connected and registered by the
+// first request that needs them.
+type session struct {
+	seen time.Time
+	user string
+	cl   Client
+	res  *result
+}
+
+type server struct {
+	auth  Authenticator
+	conn  Connector
+	store TokenStore
+	users Registry
+	mux   *http.ServeMux
+	now   func() time.Time
+	log   refind.Logger
+	ttl   time.Duration
+
+	mu       sync.Mutex
+	states   map[string]time.Time
+	sessions map[string]*session
+}
+
+// New returns an http.Handler that serves the refind API:
+//
+//	GET  /login      redirects to the music service to log in
+//	GET  /callback   completes the login and starts a session
+//	POST /tracklist  generates a tracklist, see the mode, n and preset parameters
+//	GET  /tracklist  previews the last generated tracklist
+//	POST /playlist   saves the last generated tracklist as a playlist
+//	GET  /settings   returns the user's generation settings
+//	PUT  /settings   replaces the user's generation settings
+//	GET  /history    lists the tracklists generated for the user
+//	POST /refresh    makes the next tracklist fetch fresh listening data
+//	POST /logout     ends the session and forgets its token
+//
+// Tracklists are generated through a registry that applies each user's
+// settings, quota and buffer, keyed by the user's music service ID so that
+// every session of a user shares them across logins. The parameters of POST
+// /tracklist override the settings for that request only. Sessions idle for
+// longer than the session TTL are logged out and at most 1000 sessions are
+// kept, evicting the least recently used. Tokens already in the store start a
+// session that expires like any other.
+func New(auth Authenticator, conn Connector, store TokenStore) (*server, error) {
+	if auth == nil {
+		return nil, errNilAuth
+	}
+
+	if conn == nil {
+		return nil, errNilConnector
+	}
+
+	if store == nil {
+		return nil, errNilStore
+	}
+
+	ids, err := store.IDs()
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot list stored sessions")
+	}
+
+	users, err := user.New(user.Quota{}, user.NewMemoryHistory())
+	if err != nil {
+		return nil, err
+	}
+
+	s := &server{
+		auth:     auth,
+		conn:     conn,
+		store:    store,
+		users:    users,
+		mux:      http.NewServeMux(),
+		now:      time.Now,
+		log:      refind.NopLogger(),
+		ttl:      sessionTTL,
+		states:   make(map[string]time.Time),
+		sessions: make(map[string]*session),
+	}
+
+	for _, id := range ids {
+		s.sessions[id] = &session{seen: s.now()}
+	}
+
+	s.mux.HandleFunc("/login", s.handleLogin)
+	s.mux.HandleFunc("/callback", s.handleCallback)
+	s.mux.HandleFunc("/logout", s.handleLogout)
+	s.mux.HandleFunc("/tracklist", s.handleTracklist)
+	s.mux.HandleFunc("/playlist", s.handlePlaylist)
+	s.mux.HandleFunc("/settings", s.handleSettings)
+	s.mux.HandleFunc("/history", s.handleHistory)
+	s.mux.HandleFunc("/refresh", s.handleRefresh)
+
+	return s, nil
+}
+
+// SetLogger logs failed requests and token store errors to l.
+func (s *server) SetLogger(l refind.Logger) error {
+	if l == nil {
+		return errNilLogger
+	}
+
+	s.log = l
+	return nil
+}
+
+// SetSessionTTL logs out sessions that are idle for longer than ttl.
+func (s *server) SetSessionTTL(ttl time.Duration) error {
+	if ttl <= 0 {
+		return errTTLInvalid
+	}
+
+	s.ttl = ttl
+	return nil
+}
+
+// SetRegistry replaces the default registry, which has no quota and keeps
+// histories in memory.
+func (s *server) SetRegistry(r Registry) error {
+	if r == nil {
+		return errNilRegistry
+	}
+
+	s.users = r
+	return nil
+}
+
+func (s *server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+func (s *server) handleLogin(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		s.writeError(w, http.StatusMethodNotAllowed, errMethod)
+		return
+	}
+
+	state, err := randomID()
+	if err != nil {
+		s.writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	s.mu.Lock()
+	s.expire()
+	s.states[state] = s.now().Add(stateTTL)
+	s.mu.Unlock()
+
+	// The state is bound to the browser that starts the login, so that a
+	// callback carrying someone else's state cannot log this browser in.
+	http.SetCookie(w, &http.Cookie{
+		Name:     stateCookie,
+		Value:    state,
+		Path:     "/callback",
+		MaxAge:   int(stateTTL / time.Second),
+		Secure:   true,
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+
+	http.Redirect(w, r, s.auth.AuthURL(state), http.StatusFound)
+}
+
+func (s *server) handleCallback(w http.ResponseWriter, r *http.Request) {
+	state := r.FormValue("state")
+
+	s.mu.Lock()
+	s.expire()
+	_, ok := s.states[state]
+	delete(s.states, state)
+	s.mu.Unlock()
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     stateCookie,
+		Value:    "",
+		Path:     "/callback",
+		MaxAge:   -1,
+		Secure:   true,
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+
+	c, err := r.Cookie(stateCookie)
+	if err != nil || subtle.ConstantTimeCompare([]byte(c.Value), []byte(state)) != 1 {
+		ok = false
+	}
+
+	if !ok {
+		s.writeError(w, http.StatusForbidden, errStateInvalid)
+		return
+	}
+
+	tok, err := s.auth.Token(state, r)
+	if err != nil {
+		s.writeError(w, http.StatusForbidden, errors.Wrap(err, "cannot get token"))
+		return
+	}
+
+	id, err := randomID()
+	if err != nil {
+		s.writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	uid, cl, err := s.connect(tok)
+	if err != nil {
+		s.writeError(w, statusOf(err, http.StatusBadGateway), err)
+		return
+	}
+
+	if err := s.store.SetToken(id, tok); err != nil {
+		s.writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	s.mu.Lock()
+	s.evict()
+	s.sessions[id] = &session{seen: s.now(), user: uid, cl: cl}
+	s.mu.Unlock()
+
+	// Browsers treat localhost as secure, so the cookie still works while
+	// developing over plain HTTP.
+	http.SetCookie(w, &http.Cookie{
+		Name:     sessionCookie,
+		Value:    id,
+		Path:     "/",
+		MaxAge:   int(s.ttl / time.Second),
+		Secure:   true,
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+
+	writeJSON(w, http.StatusOK, map[string]string{"status": "logged in"})
+}
+
+func (s *server) handleLogout(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		s.writeError(w, http.StatusMethodNotAllowed, errMethod)
+		return
+	}
+
+	id, err := s.session(r)
+	if err != nil {
+		s.writeError(w, statusOf(err, http.StatusInternalServerError), err)
+		return
+	}
+
+	s.mu.Lock()
+	s.remove(id)
+	s.mu.Unlock()
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     sessionCookie,
+		Value:    "",
+		Path:     "/",
+		MaxAge:   -1,
+		Secure:   true,
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+
+	writeJSON(w, http.StatusOK, map[string]string{"status": "logged out"})
+}
+
+// expire forgets OAuth states that were never used. The caller must hold mu.
+func (s *server) expire() {
+	now := s.now()
+	for st, exp := range s.states {
+		if now.After(exp) {
+			delete(s.states, st)
+		}
+	}
+}
+
+// evict logs out sessions that have been idle for longer than the session
+// TTL and then the least recently used sessions until there is room for a
+// new one. The caller must hold mu.
+func (s *server) evict() {
+	now := s.now()
+	for id, sess := range s.sessions {
+		if now.Sub(sess.seen) > s.ttl {
+			s.remove(id)
+		}
+	}
+
+	for len(s.sessions) >= maxSessions {
+		var old string
+		for id, sess := range s.sessions {
+			if old == "" || sess.seen.Before(s.sessions[old].seen) {
+				old = id
+			}
+		}
+		s.remove(old)
+	}
+}
+
+// remove forgets the session and its token. The user stays registered, so
+// that their settings and quota outlive the session. The caller must hold mu.
+func (s *server) remove(id string) {
+	delete(s.sessions, id)
+	if err := s.store.DeleteToken(id); err != nil {
+		s.log.Error("cannot delete session token", "error", refind.Redact(err.Error()))
+	}
+}
+
+// session returns the ID of the request's session and marks it as used.
+func (s *server) session(r *http.Request) (string, error) {
+	c, err := r.Cookie(sessionCookie)
+	if err != nil {
+		return "", errNoSession
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sess, ok := s.sessions[c.Value]
+	if !ok {
+		return "", errNoSession
+	}
+
+	if s.now().Sub(sess.seen) > s.ttl {
+		s.remove(c.Value)
+		return "", errNoSession
+	}
+
+	sess.seen = s.now()
+	return c.Value, nil
+}
+
+// client returns the session ID, user ID and Client of the request's
+// session. A session restored from the token store is connected on its first
+// request, so that later requests share the user's buffer and the Client
+// refreshes the token on its own.
+func (s *server) client(r *http.Request) (string, string, Client, error) {
+	id, err := s.session(r)
+	if err != nil {
+		return "", "", nil, err
+	}
+
+	if uid, cl := s.connected(id); cl != nil {
+		return id, uid, cl, nil
+	}
+
+	// The store, connector and registry may be slow, so they are called
+	// without holding mu.
+	tok, err := s.store.Token(id)
+	if err != nil {
+		return "", "", nil, errNoSession
+	}
+
+	uid, cl, err := s.connect(tok)
+	if err != nil {
+		return "", "", nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sess, ok := s.sessions[id]
+	if !ok {
+		return "", "", nil, errNoSession
+	}
+
+	// A concurrent request may have connected the session first.
+	if sess.cl == nil {
+		sess.user, sess.cl = uid, cl
+	}
+
+	return id, sess.user, sess.cl, nil
+}
+
+// connect returns a Client authorized by tok and the ID of its user, who is
+// registered with the Client as their music service.
+func (s *server) connect(tok *oauth2.Token) (string, Client, error) {
+	cl, err := s.conn(tok)
+	if err != nil {
+		return "", nil, errors.Wrap(err, "cannot connect to music service")
+	}
+
+	uid, err := cl.UserID()
+	if err != nil {
+		return "", nil, err
+	}
+
+	if err := s.users.Register(uid, cl, cl); err != nil {
+		return "", nil, errors.Wrap(err, "cannot register user")
+	}
+
+	return uid, cl, nil
+}
+
+// connected returns the user ID and Client of the session, or a nil Client
+// when it has not been connected yet.
+func (s *server) connected(id string) (string, Client) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sess, ok := s.sessions[id]
+	if !ok {
+		return "", nil
+	}
+
+	return sess.user, sess.cl
+}
+
+// keep saves the client's token when it differs from the stored one, which
+// happens once the client has refreshed an expired token.
+func (s *server) keep(id string, cl Client) {
+	tok, err := cl.Token()
+	if err != nil {
+		return
+	}
+
+	old, err := s.store.Token(id)
+	if err == nil && old.AccessToken == tok.AccessToken && old.RefreshToken == tok.RefreshToken {
+		return
+	}
+
+	if err := s.store.SetToken(id, tok); err != nil {
+		s.log.Error("cannot save refreshed token", "error", refind.Redact(err.Error()))
+	}
+}
+
+type trackView struct {
+	ID     string `json:"id"`
+	Name   string `json:"name"`
+	Artist string `json:"artist"`
+	URI    string `json:"uri,omitempty"`
+}
+
+type tracklistResponse struct {
+	Report refind.Report `json:"report"`
+	Tracks []trackView   `json:"tracks"`
+}
+
+// result returns the last tracklist generated in the session, if any.
+func (s *server) result(id string) *result {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sess, ok := s.sessions[id]
+	if !ok {
+		return nil
+	}
+
+	return sess.res
+}
+
+func newTracklistResponse(res result) tracklistResponse {
+	out := tracklistResponse{Report: res.rep, Tracks: []trackView{}}
+	for _, t := range res.list {
+		out.Tracks = append(out.Tracks, trackView{ID: t.ID, Name: t.Name, Artist: t.Artist.Name, URI: t.URI})
+	}
+
+	return out
+}
+
+func (s *server) handleTracklist(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		s.preview(w, r)
+	case http.MethodPost:
+		s.generate(w, r)
+	default:
+		s.writeError(w, http.StatusMethodNotAllowed, errMethod)
+	}
+}
+
+func (s *server) preview(w http.ResponseWriter, r *http.Request) {
+	id, err := s.session(r)
+	if err != nil {
+		s.writeError(w, statusOf(err, http.StatusInternalServerError), err)
+		return
+	}
+
+	res := s.result(id)
+	if res == nil {
+		s.writeError(w, http.StatusNotFound, errNoTracklist)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, newTracklistResponse(*res))
+}
+
+func (s *server) generate(w http.ResponseWriter, r *http.Request) {
+	id, uid, cl, err := s.client(r)
+	if err != nil {
+		s.writeError(w, statusOf(err, http.StatusInternalServerError), err)
+		return
+	}
+	defer s.keep(id, cl)
+
+	set, err := s.users.Settings(uid)
+	if err != nil {
+		s.writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	if v := r.FormValue("mode"); v != "" {
+		set.Mode = v
+	}
+
+	if v := r.FormValue("n"); v != "" {
+		set.Total, err = strconv.Atoi(v)
+		if err != nil {
+			s.writeError(w, http.StatusBadRequest, errTotalInvalid)
+			return
+		}
+	}
+
+	if v := r.FormValue("preset"); v != "" {
+		set.Preset = v
+	}
+
+	var res result
+	res.list, res.rep, err = s.users.Generate(uid, set)
+	if err != nil {
+		s.writeError(w, statusOf(err, http.StatusBadGateway), err)
+		return
+	}
+
+	s.mu.Lock()
+	if sess, ok := s.sessions[id]; ok {
+		sess.res = &res
+	}
+	s.mu.Unlock()
+
+	writeJSON(w, http.StatusOK, newTracklistResponse(res))
+}
+
+type playlistResponse struct {
+	ID  string `json:"id"`
+	URL string `json:"url"`
+}
+
+func (s *server) handlePlaylist(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		s.writeError(w, http.StatusMethodNotAllowed, errMethod)
+		return
+	}
+
+	id, _, cl, err := s.client(r)
+	if err != nil {
+		s.writeError(w, statusOf(err, http.StatusInternalServerError), err)
+		return
+	}
+	defer s.keep(id, cl)
+
+	res := s.result(id)
+	if res == nil {
+		s.writeError(w, http.StatusNotFound, errNoTracklist)
+		return
+	}
+
+	name := r.FormValue("name")
+	if name == "" {
+		name = defaultPlaylist
+	}
+
+	pl, err := cl.Playlist(name, playlistInfo, res.list)
+	if err != nil {
+		s.writeError(w, http.StatusBadGateway, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, playlistResponse{ID: string(pl.ID), URL: pl.ExternalURLs["spotify"]})
+}
+
+func (s *server) handleSettings(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodPut {
+		s.writeError(w, http.StatusMethodNotAllowed, errMethod)
+		return
+	}
+
+	_, uid, _, err := s.client(r)
+	if err != nil {
+		s.writeError(w, statusOf(err, http.StatusInternalServerError), err)
+		return
+	}
+
+	if r.Method == http.MethodPut {
+		var set user.Settings
+		if err := json.NewDecoder(r.Body).Decode(&set); err != nil {
+			s.writeError(w, http.StatusBadRequest, errBodyInvalid)
+			return
+		}
+
+		if err := s.users.SetSettings(uid, set); err != nil {
+			s.writeError(w, statusOf(err, http.StatusInternalServerError), err)
+			return
+		}
+	}
+
+	set, err := s.users.Settings(uid)
+	if err != nil {
+		s.writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, set)
+}
+
+func (s *server) handleHistory(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		s.writeError(w, http.StatusMethodNotAllowed, errMethod)
+		return
+	}
+
+	_, uid, _, err := s.client(r)
+	if err != nil {
+		s.writeError(w, statusOf(err, http.StatusInternalServerError), err)
+		return
+	}
+
+	hist, err := s.users.History(uid)
+	if err != nil {
+		s.writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	if hist == nil {
+		hist = []user.Entry{}
+	}
+
+	writeJSON(w, http.StatusOK, hist)
+}
+
+func (s *server) handleRefresh(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		s.writeError(w, http.StatusMethodNotAllowed, errMethod)
+		return
+	}
+
+	_, uid, _, err := s.client(r)
+	if err != nil {
+		s.writeError(w, statusOf(err, http.StatusInternalServerError), err)
+		return
+	}
+
+	if err := s.users.Refresh(uid); err != nil {
+		s.writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]string{"status": "refreshed"})
+}
+
+// statusOf returns the status code of errors that the client can act on and
+// def for every other error.
+func statusOf(err error, def int) int {
+	switch {
+	case err == errNoSession, errors.Is(err, refind.ErrUnauthorized):
+		return http.StatusUnauthorized
+	case errors.Is(err, user.ErrSettingsInvalid):
+		return http.StatusBadRequest
+	case errors.Is(err, user.ErrQuotaExceeded), errors.Is(err, refind.ErrRateLimited):
+		return http.StatusTooManyRequests
+	}
+
+	return def
+}
+
+func writeJSON(w http.ResponseWriter, code int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(v)
+}
+
+// writeError sends clients the message of public errors only, so that
+// upstream responses and internal details stay in the log.
+func (s *server) writeError(w http.ResponseWriter, code int, err error) {
+	msg := http.StatusText(code)
+	if public(err) {
+		msg = err.Error()
+	} else {
+		s.log.Warn("request failed", "status", code, "error", refind.Redact(err.Error()))
+	}
+
+	writeJSON(w, code, map[string]string{"error": msg})
+}
+
+func randomID() (string, error) {
+	b := make([]byte, idBytes)
+	if _, err := rand.Read(b); err != nil {
+		return "", errors.Wrap(err, "cannot generate random ID")
+	}
+
+	return hex.EncodeToString(b), nil
+}
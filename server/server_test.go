@@ -0,0 +1,742 @@
+package server
+
+import (
+	"encoding/json"
+	"github.com/Henry-Sarabia/refind"
+	"github.com/Henry-Sarabia/refind/spotify"
+	"github.com/Henry-Sarabia/refind/user"
+	"github.com/pkg/errors"
+	api "github.com/zmb3/spotify"
+	"golang.org/x/oauth2"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+const (
+	testAuthURL    string = "https://accounts.example.com/authorize"
+	testPlaylistID string = "7I6yjOAxMq4qsvzgqxw7aU"
+	testSnapshot   string = "snapshot"
+)
+
+var testErrDenied = errors.New("access denied")
+
+// fixtureClient answers the Spotify requests the server makes with the
+// spotify package's test data and records the name of every playlist it
+// creates in saved. The embedded client is never called.
+type fixtureClient struct {
+	api.Client
+	tok   *oauth2.Token
+	saved *[]string
+	top   *int
+}
+
+func fixture(name string, v interface{}) error {
+	b, err := ioutil.ReadFile(filepath.Join("..", "spotify", "test_data", name))
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(b, v)
+}
+
+func (f *fixtureClient) CurrentUsersTopArtistsOpt(*api.Options) (*api.FullArtistPage, error) {
+	if f.top != nil {
+		*f.top++
+	}
+
+	var page *api.FullArtistPage
+	return page, fixture("current_users_top_artists.json", &page)
+}
+
+func (f *fixtureClient) CurrentUsersTopTracksOpt(*api.Options) (*api.FullTrackPage, error) {
+	var page *api.FullTrackPage
+	return page, fixture("current_users_top_tracks.json", &page)
+}
+
+func (f *fixtureClient) PlayerRecentlyPlayedOpt(*api.RecentlyPlayedOptions) ([]api.RecentlyPlayedItem, error) {
+	var items []api.RecentlyPlayedItem
+	return items, fixture("player_recently_played.json", &items)
+}
+
+func (f *fixtureClient) GetRecommendations(sds api.Seeds, attrs *api.TrackAttributes, opt *api.Options) (*api.Recommendations, error) {
+	var recs *api.Recommendations
+	if err := fixture("get_recommendations.json", &recs); err != nil {
+		return nil, err
+	}
+
+	if opt != nil && opt.Limit != nil && len(recs.Tracks) > *opt.Limit {
+		recs.Tracks = recs.Tracks[:*opt.Limit]
+	}
+
+	return recs, nil
+}
+
+func (f *fixtureClient) CurrentUser() (*api.PrivateUser, error) {
+	var u *api.PrivateUser
+	return u, fixture("current_user.json", &u)
+}
+
+func (f *fixtureClient) CreatePlaylistForUser(user string, name string, info string, public bool) (*api.FullPlaylist, error) {
+	if f.saved != nil {
+		*f.saved = append(*f.saved, name)
+	}
+
+	var pl *api.FullPlaylist
+	return pl, fixture("create_playlist_for_user.json", &pl)
+}
+
+func (f *fixtureClient) AddTracksToPlaylist(api.ID, ...api.ID) (string, error) {
+	return testSnapshot, nil
+}
+
+func (f *fixtureClient) Token() (*oauth2.Token, error) {
+	return f.tok, nil
+}
+
+// testConnector returns a Connector whose clients are answered by fixtures.
+// Each client holds refreshed instead of its session's token when refreshed
+// is not nil, as if it had refreshed an expired token.
+func testConnector(saved *[]string, refreshed *oauth2.Token) Connector {
+	return func(tok *oauth2.Token) (Client, error) {
+		if refreshed != nil {
+			tok = refreshed
+		}
+
+		return spotify.New(&fixtureClient{tok: tok, saved: saved})
+	}
+}
+
+type fakeAuth struct {
+	err error
+}
+
+func (f fakeAuth) AuthURL(state string) string {
+	return testAuthURL + "?state=" + state
+}
+
+func (f fakeAuth) Token(state string, r *http.Request) (*oauth2.Token, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+
+	return &oauth2.Token{AccessToken: "access-" + r.FormValue("code")}, nil
+}
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name    string
+		auth    Authenticator
+		conn    Connector
+		store   TokenStore
+		wantErr error
+	}{
+		{"Valid arguments", fakeAuth{}, testConnector(nil, nil), NewMemoryStore(), nil},
+		{"Nil Authenticator", nil, testConnector(nil, nil), NewMemoryStore(), errNilAuth},
+		{"Nil Connector", fakeAuth{}, nil, NewMemoryStore(), errNilConnector},
+		{"Nil TokenStore", fakeAuth{}, testConnector(nil, nil), nil, errNilStore},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := New(test.auth, test.conn, test.store)
+			if err != test.wantErr {
+				t.Errorf("got: <%v>, want: <%v>", err, test.wantErr)
+			}
+		})
+	}
+}
+
+// login runs the OAuth flow against srv and returns the session cookie.
+func login(t *testing.T, srv http.Handler) *http.Cookie {
+	t.Helper()
+
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/login", nil))
+	if w.Code != http.StatusFound {
+		t.Fatalf("got: <%v>, want: <%v>", w.Code, http.StatusFound)
+	}
+
+	u, err := url.Parse(w.Header().Get("Location"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := httptest.NewRequest(http.MethodGet, "/callback?code=abc&state="+u.Query().Get("state"), nil)
+	for _, c := range w.Result().Cookies() {
+		r.AddCookie(c)
+	}
+
+	w = httptest.NewRecorder()
+	srv.ServeHTTP(w, r)
+	if w.Code != http.StatusOK {
+		t.Fatalf("got: <%v>, want: <%v>", w.Code, http.StatusOK)
+	}
+
+	for _, c := range w.Result().Cookies() {
+		if c.Name == sessionCookie {
+			return c
+		}
+	}
+
+	t.Fatal("no session cookie set")
+	return nil
+}
+
+func TestServer_Callback(t *testing.T) {
+	tests := []struct {
+		name     string
+		auth     fakeAuth
+		state    string
+		cookie   string
+		expired  bool
+		wantCode int
+	}{
+		{"Valid state", fakeAuth{}, "", "", false, http.StatusOK},
+		{"Unknown state", fakeAuth{}, "forged", "", false, http.StatusForbidden},
+		{"Expired state", fakeAuth{}, "", "", true, http.StatusForbidden},
+		{"Token denied", fakeAuth{err: testErrDenied}, "", "", false, http.StatusForbidden},
+		{"State of another browser", fakeAuth{}, "", "other", false, http.StatusForbidden},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			store := NewMemoryStore()
+			srv, err := New(test.auth, testConnector(nil, nil), store)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			w := httptest.NewRecorder()
+			srv.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/login", nil))
+			u, err := url.Parse(w.Header().Get("Location"))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			state := u.Query().Get("state")
+			if test.state != "" {
+				state = test.state
+			}
+
+			if test.expired {
+				srv.now = func() time.Time { return time.Now().Add(2 * stateTTL) }
+			}
+
+			bound := u.Query().Get("state")
+			if test.cookie != "" {
+				bound = test.cookie
+			}
+
+			r := httptest.NewRequest(http.MethodGet, "/callback?code=abc&state="+state, nil)
+			r.AddCookie(&http.Cookie{Name: stateCookie, Value: bound})
+
+			w = httptest.NewRecorder()
+			srv.ServeHTTP(w, r)
+			if w.Code != test.wantCode {
+				t.Errorf("got: <%v>, want: <%v>", w.Code, test.wantCode)
+			}
+
+			if test.wantCode != http.StatusOK {
+				return
+			}
+
+			var c *http.Cookie
+			for _, ck := range w.Result().Cookies() {
+				if ck.Name == sessionCookie {
+					c = ck
+				}
+			}
+
+			if c == nil {
+				t.Fatalf("got: <%v>, want: <%v>", w.Result().Cookies(), sessionCookie)
+			}
+
+			if !c.Secure || !c.HttpOnly {
+				t.Errorf("got: <%v>, want: <%v>", c, "a secure, HTTP only cookie")
+			}
+
+			tok, err := store.Token(c.Value)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if tok.AccessToken != "access-abc" {
+				t.Errorf("got: <%v>, want: <%v>", tok.AccessToken, "access-abc")
+			}
+		})
+	}
+}
+
+func TestServer_Tracklist(t *testing.T) {
+	srv, err := New(fakeAuth{}, testConnector(nil, nil), NewMemoryStore())
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := login(t, srv)
+
+	tests := []struct {
+		name     string
+		method   string
+		target   string
+		session  bool
+		wantCode int
+	}{
+		{"No session", http.MethodPost, "/tracklist", false, http.StatusUnauthorized},
+		{"Invalid mode", http.MethodPost, "/tracklist?mode=radar", true, http.StatusBadRequest},
+		{"Invalid total", http.MethodPost, "/tracklist?n=0", true, http.StatusBadRequest},
+		{"Total not a number", http.MethodPost, "/tracklist?n=few", true, http.StatusBadRequest},
+		{"Nothing to preview", http.MethodGet, "/tracklist", true, http.StatusNotFound},
+		{"Full tracklist", http.MethodPost, "/tracklist?n=5", true, http.StatusOK},
+		{"Preview", http.MethodGet, "/tracklist", true, http.StatusOK},
+		{"Limited tracklist", http.MethodPost, "/tracklist?mode=limited&n=5", true, http.StatusOK},
+		{"Preset", http.MethodPost, "/tracklist?preset=focus&n=5", true, http.StatusOK},
+		{"Unknown preset", http.MethodPost, "/tracklist?preset=foo", true, http.StatusBadRequest},
+		{"Unsupported method", http.MethodDelete, "/tracklist", true, http.StatusMethodNotAllowed},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			r := httptest.NewRequest(test.method, test.target, nil)
+			if test.session {
+				r.AddCookie(c)
+			}
+
+			w := httptest.NewRecorder()
+			srv.ServeHTTP(w, r)
+			if w.Code != test.wantCode {
+				t.Fatalf("got: <%v>, want: <%v>: %s", w.Code, test.wantCode, w.Body.String())
+			}
+
+			if w.Code != http.StatusOK {
+				return
+			}
+
+			var res tracklistResponse
+			if err := json.NewDecoder(w.Body).Decode(&res); err != nil {
+				t.Fatal(err)
+			}
+
+			if len(res.Tracks) == 0 || len(res.Tracks) > 5 {
+				t.Errorf("got: <%v>, want: <%v>", len(res.Tracks), "between 1 and 5")
+			}
+
+			if res.Report.Final != len(res.Tracks) {
+				t.Errorf("got: <%v>, want: <%v>", res.Report.Final, len(res.Tracks))
+			}
+		})
+	}
+}
+
+func TestServer_Playlist(t *testing.T) {
+	var saved []string
+	srv, err := New(fakeAuth{}, testConnector(&saved, nil), NewMemoryStore())
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := login(t, srv)
+
+	post := func(target string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodPost, target, nil)
+		r.AddCookie(c)
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, r)
+		return w
+	}
+
+	if w := post("/playlist"); w.Code != http.StatusNotFound {
+		t.Errorf("got: <%v>, want: <%v>", w.Code, http.StatusNotFound)
+	}
+
+	if w := post("/tracklist?n=5"); w.Code != http.StatusOK {
+		t.Fatalf("got: <%v>, want: <%v>", w.Code, http.StatusOK)
+	}
+
+	w := post("/playlist?name=weekly")
+	if w.Code != http.StatusOK {
+		t.Fatalf("got: <%v>, want: <%v>: %s", w.Code, http.StatusOK, w.Body.String())
+	}
+
+	var got playlistResponse
+	if err := json.NewDecoder(w.Body).Decode(&got); err != nil {
+		t.Fatal(err)
+	}
+
+	want := playlistResponse{
+		ID:  testPlaylistID,
+		URL: "http://open.spotify.com/user/someone/playlist/" + testPlaylistID,
+	}
+	if got != want {
+		t.Errorf("got: <%v>, want: <%v>", got, want)
+	}
+
+	if !reflect.DeepEqual(saved, []string{"weekly"}) {
+		t.Errorf("got: <%v>, want: <%v>", saved, []string{"weekly"})
+	}
+}
+
+func TestServer_Logout(t *testing.T) {
+	store := NewMemoryStore()
+	srv, err := New(fakeAuth{}, testConnector(nil, nil), store)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := login(t, srv)
+
+	post := func(target string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodPost, target, nil)
+		r.AddCookie(c)
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, r)
+		return w
+	}
+
+	if w := post("/logout"); w.Code != http.StatusOK {
+		t.Fatalf("got: <%v>, want: <%v>", w.Code, http.StatusOK)
+	}
+
+	if _, err := store.Token(c.Value); err != errTokenMissing {
+		t.Errorf("got: <%v>, want: <%v>", err, errTokenMissing)
+	}
+
+	if w := post("/tracklist"); w.Code != http.StatusUnauthorized {
+		t.Errorf("got: <%v>, want: <%v>", w.Code, http.StatusUnauthorized)
+	}
+
+	if w := post("/logout"); w.Code != http.StatusUnauthorized {
+		t.Errorf("got: <%v>, want: <%v>", w.Code, http.StatusUnauthorized)
+	}
+}
+
+func TestServer_SessionExpiry(t *testing.T) {
+	tests := []struct {
+		name     string
+		idle     time.Duration
+		wantCode int
+	}{
+		{"Active session", time.Hour, http.StatusOK},
+		{"Idle session", 2 * time.Hour, http.StatusUnauthorized},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			store := NewMemoryStore()
+			srv, err := New(fakeAuth{}, testConnector(nil, nil), store)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if err := srv.SetSessionTTL(90 * time.Minute); err != nil {
+				t.Fatal(err)
+			}
+			c := login(t, srv)
+
+			srv.now = func() time.Time { return time.Now().Add(test.idle) }
+
+			r := httptest.NewRequest(http.MethodPost, "/tracklist?n=5", nil)
+			r.AddCookie(c)
+			w := httptest.NewRecorder()
+			srv.ServeHTTP(w, r)
+			if w.Code != test.wantCode {
+				t.Fatalf("got: <%v>, want: <%v>", w.Code, test.wantCode)
+			}
+
+			_, err = store.Token(c.Value)
+			if (err == nil) != (test.wantCode == http.StatusOK) {
+				t.Errorf("got: <%v>, want: <%v>", err, test.wantCode == http.StatusOK)
+			}
+		})
+	}
+}
+
+func TestServer_Evict(t *testing.T) {
+	store := NewMemoryStore()
+	srv, err := New(fakeAuth{}, testConnector(nil, nil), store)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Now()
+	for i := 0; i < maxSessions; i++ {
+		id := strconv.Itoa(i)
+		store.SetToken(id, &oauth2.Token{AccessToken: id})
+		srv.sessions[id] = &session{seen: start.Add(time.Duration(i) * time.Second)}
+	}
+
+	c := login(t, srv)
+
+	if len(srv.sessions) != maxSessions {
+		t.Errorf("got: <%v>, want: <%v>", len(srv.sessions), maxSessions)
+	}
+
+	if _, err := store.Token("0"); err != errTokenMissing {
+		t.Errorf("got: <%v>, want: <%v>", err, errTokenMissing)
+	}
+
+	for _, id := range []string{"1", c.Value} {
+		if _, err := store.Token(id); err != nil {
+			t.Errorf("got: <%v>, want: <%v>", err, nil)
+		}
+	}
+}
+
+func TestNew_StoredSessions(t *testing.T) {
+	store := NewMemoryStore()
+	store.SetToken("foo", &oauth2.Token{AccessToken: "bar"})
+
+	srv, err := New(fakeAuth{}, testConnector(nil, nil), store)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := httptest.NewRequest(http.MethodPost, "/tracklist?n=5", nil)
+	r.AddCookie(&http.Cookie{Name: sessionCookie, Value: "foo"})
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, r)
+	if w.Code != http.StatusOK {
+		t.Errorf("got: <%v>, want: <%v>", w.Code, http.StatusOK)
+	}
+}
+
+func TestServer_RefreshedToken(t *testing.T) {
+	tests := []struct {
+		name      string
+		refreshed *oauth2.Token
+		want      string
+	}{
+		{"Unchanged token", nil, "access-abc"},
+		{"Refreshed token", &oauth2.Token{AccessToken: "refreshed", RefreshToken: "foo"}, "refreshed"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			store := NewMemoryStore()
+			srv, err := New(fakeAuth{}, testConnector(nil, test.refreshed), store)
+			if err != nil {
+				t.Fatal(err)
+			}
+			c := login(t, srv)
+
+			r := httptest.NewRequest(http.MethodPost, "/tracklist?n=5", nil)
+			r.AddCookie(c)
+			w := httptest.NewRecorder()
+			srv.ServeHTTP(w, r)
+			if w.Code != http.StatusOK {
+				t.Fatalf("got: <%v>, want: <%v>", w.Code, http.StatusOK)
+			}
+
+			tok, err := store.Token(c.Value)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if tok.AccessToken != test.want {
+				t.Errorf("got: <%v>, want: <%v>", tok.AccessToken, test.want)
+			}
+		})
+	}
+}
+
+func TestServer_WriteError(t *testing.T) {
+	tests := []struct {
+		name    string
+		code    int
+		err     error
+		wantMsg string
+	}{
+		{"Public error", http.StatusBadRequest, errTotalInvalid, errTotalInvalid.Error()},
+		{"Internal error", http.StatusInternalServerError, errors.New("open /var/lib/refind/tokens.json: permission denied"), http.StatusText(http.StatusInternalServerError)},
+		{"Upstream error", http.StatusBadGateway, errors.Wrap(errors.New("invalid access_token=foo"), "cannot fetch recommendations"), http.StatusText(http.StatusBadGateway)},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			srv, err := New(fakeAuth{}, testConnector(nil, nil), NewMemoryStore())
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			w := httptest.NewRecorder()
+			srv.writeError(w, test.code, test.err)
+			if w.Code != test.code {
+				t.Errorf("got: <%v>, want: <%v>", w.Code, test.code)
+			}
+
+			var got map[string]string
+			if err := json.NewDecoder(w.Body).Decode(&got); err != nil {
+				t.Fatal(err)
+			}
+
+			if got["error"] != test.wantMsg {
+				t.Errorf("got: <%v>, want: <%v>", got["error"], test.wantMsg)
+			}
+		})
+	}
+}
+
+func TestServer_Registry(t *testing.T) {
+	var top int
+	conn := func(tok *oauth2.Token) (Client, error) {
+		return spotify.New(&fixtureClient{tok: tok, top: &top})
+	}
+
+	srv, err := New(fakeAuth{}, conn, NewMemoryStore())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	reg, err := user.New(user.Quota{Max: 2, Window: time.Hour}, user.NewMemoryHistory())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := srv.SetRegistry(reg); err != nil {
+		t.Fatal(err)
+	}
+	c := login(t, srv)
+
+	do := func(method string, target string, body string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(method, target, strings.NewReader(body))
+		r.AddCookie(c)
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, r)
+		return w
+	}
+
+	set := user.Settings{Mode: "limited", Total: 5}
+	w := do(http.MethodPut, "/settings", `{"mode": "limited", "n": 5}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("got: <%v>, want: <%v>: %s", w.Code, http.StatusOK, w.Body.String())
+	}
+
+	var got user.Settings
+	if err := json.NewDecoder(do(http.MethodGet, "/settings", "").Body).Decode(&got); err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(got, set) {
+		t.Errorf("got: <%v>, want: <%v>", got, set)
+	}
+
+	if w := do(http.MethodPut, "/settings", `{"mode": "radar", "n": 5}`); w.Code != http.StatusBadRequest {
+		t.Errorf("got: <%v>, want: <%v>", w.Code, http.StatusBadRequest)
+	}
+
+	for i := 0; i < 2; i++ {
+		if w := do(http.MethodPost, "/tracklist", ""); w.Code != http.StatusOK {
+			t.Fatalf("got: <%v>, want: <%v>: %s", w.Code, http.StatusOK, w.Body.String())
+		}
+	}
+
+	if top != 3 {
+		t.Errorf("got: <%v>, want: <%v>", top, 3)
+	}
+
+	w = do(http.MethodPost, "/tracklist", "")
+	if w.Code != http.StatusTooManyRequests {
+		t.Errorf("got: <%v>, want: <%v>", w.Code, http.StatusTooManyRequests)
+	}
+
+	// Logging out and in again starts a session of the same user, which
+	// keeps their settings and quota.
+	if w := do(http.MethodPost, "/logout", ""); w.Code != http.StatusOK {
+		t.Fatalf("got: <%v>, want: <%v>", w.Code, http.StatusOK)
+	}
+	c = login(t, srv)
+
+	got = user.Settings{}
+	if err := json.NewDecoder(do(http.MethodGet, "/settings", "").Body).Decode(&got); err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(got, set) {
+		t.Errorf("got: <%v>, want: <%v>", got, set)
+	}
+
+	w = do(http.MethodPost, "/tracklist", "")
+	if w.Code != http.StatusTooManyRequests {
+		t.Errorf("got: <%v>, want: <%v>", w.Code, http.StatusTooManyRequests)
+	}
+
+	var hist []user.Entry
+	if err := json.NewDecoder(do(http.MethodGet, "/history", "").Body).Decode(&hist); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(hist) != 2 {
+		t.Errorf("got: <%v>, want: <%v>", len(hist), 2)
+	}
+
+	if w := do(http.MethodPost, "/refresh", ""); w.Code != http.StatusOK {
+		t.Errorf("got: <%v>, want: <%v>", w.Code, http.StatusOK)
+	}
+}
+
+func TestStatusOf(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{"No session", errNoSession, http.StatusUnauthorized},
+		{"Expired token", errors.Wrap(&refind.APIError{Endpoint: "GET /me", Status: 401}, "cannot fetch user"), http.StatusUnauthorized},
+		{"Invalid settings", errors.Wrap(user.ErrSettingsInvalid, "mode"), http.StatusBadRequest},
+		{"Quota exceeded", user.ErrQuotaExceeded, http.StatusTooManyRequests},
+		{"Rate limited", errors.Wrap(&refind.APIError{Endpoint: "GET /me", Status: 429}, "cannot fetch user"), http.StatusTooManyRequests},
+		{"Other error", errors.New("cannot fetch user"), http.StatusBadGateway},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := statusOf(test.err, http.StatusBadGateway); got != test.want {
+				t.Errorf("got: <%v>, want: <%v>", got, test.want)
+			}
+		})
+	}
+}
+
+func TestServer_ConnectUnlocked(t *testing.T) {
+	release := make(chan struct{})
+	entered := make(chan struct{}, 1)
+	conn := testConnector(nil, nil)
+	slow := func(tok *oauth2.Token) (Client, error) {
+		if tok.AccessToken == "bar" {
+			entered <- struct{}{}
+			<-release
+		}
+		return conn(tok)
+	}
+
+	store := NewMemoryStore()
+	store.SetToken("foo", &oauth2.Token{AccessToken: "bar"})
+
+	srv, err := New(fakeAuth{}, slow, store)
+	if err != nil {
+		t.Fatal(err)
+	}
+	a, b := &http.Cookie{Name: sessionCookie, Value: "foo"}, login(t, srv)
+
+	done := make(chan int)
+	go func() {
+		r := httptest.NewRequest(http.MethodGet, "/history", nil)
+		r.AddCookie(a)
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, r)
+		done <- w.Code
+	}()
+	<-entered
+
+	// Another session is served while the first one is still connecting.
+	r := httptest.NewRequest(http.MethodGet, "/tracklist", nil)
+	r.AddCookie(b)
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, r)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("got: <%v>, want: <%v>", w.Code, http.StatusNotFound)
+	}
+
+	close(release)
+	if code := <-done; code != http.StatusOK {
+		t.Errorf("got: <%v>, want: <%v>", code, http.StatusOK)
+	}
+}
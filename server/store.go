@@ -0,0 +1,145 @@
+package server
+
+import (
+	"encoding/json"
+	"github.com/pkg/errors"
+	"golang.org/x/oauth2"
+	"io/ioutil"
+	"os"
+	"sort"
+	"sync"
+)
+
+var errTokenMissing = errors.New("no token stored for session")
+
+// TokenStore keeps the OAuth token of each session so that it can be used
+// again by later requests.
+type TokenStore interface {
+	Token(id string) (*oauth2.Token, error)
+	SetToken(id string, tok *oauth2.Token) error
+	DeleteToken(id string) error
+	IDs() ([]string, error)
+}
+
+type memoryStore struct {
+	mu     sync.Mutex
+	tokens map[string]*oauth2.Token
+}
+
+// NewMemoryStore returns a TokenStore that forgets every token when the
+// process exits.
+func NewMemoryStore() *memoryStore {
+	return &memoryStore{tokens: make(map[string]*oauth2.Token)}
+}
+
+func (m *memoryStore) Token(id string) (*oauth2.Token, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	tok, ok := m.tokens[id]
+	if !ok {
+		return nil, errTokenMissing
+	}
+
+	return tok, nil
+}
+
+func (m *memoryStore) SetToken(id string, tok *oauth2.Token) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.tokens[id] = tok
+	return nil
+}
+
+func (m *memoryStore) DeleteToken(id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.tokens, id)
+	return nil
+}
+
+// IDs returns the session ID of every stored token in sorted order.
+func (m *memoryStore) IDs() ([]string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	ids := make([]string, 0, len(m.tokens))
+	for id := range m.tokens {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	return ids, nil
+}
+
+type fileStore struct {
+	mu   sync.Mutex
+	mem  *memoryStore
+	name string
+}
+
+// NewFileStore returns a TokenStore that keeps every token in memory and
+// rewrites the named JSON file on each change so sessions survive restarts.
+// A missing file starts an empty store.
+func NewFileStore(name string) (*fileStore, error) {
+	f := &fileStore{mem: NewMemoryStore(), name: name}
+
+	b, err := ioutil.ReadFile(name)
+	if os.IsNotExist(err) {
+		return f, nil
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot read token file")
+	}
+
+	if err := json.Unmarshal(b, &f.mem.tokens); err != nil {
+		return nil, errors.Wrap(err, "cannot decode token file")
+	}
+
+	if f.mem.tokens == nil {
+		f.mem.tokens = make(map[string]*oauth2.Token)
+	}
+
+	return f, nil
+}
+
+func (f *fileStore) Token(id string) (*oauth2.Token, error) {
+	return f.mem.Token(id)
+}
+
+func (f *fileStore) SetToken(id string, tok *oauth2.Token) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.mem.SetToken(id, tok)
+	return f.save()
+}
+
+func (f *fileStore) DeleteToken(id string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.mem.DeleteToken(id)
+	return f.save()
+}
+
+func (f *fileStore) IDs() ([]string, error) {
+	return f.mem.IDs()
+}
+
+func (f *fileStore) save() error {
+	f.mem.mu.Lock()
+	b, err := json.Marshal(f.mem.tokens)
+	f.mem.mu.Unlock()
+	if err != nil {
+		return errors.Wrap(err, "cannot encode tokens")
+	}
+
+	if err := ioutil.WriteFile(f.name, b, 0600); err != nil {
+		return errors.Wrap(err, "cannot write token file")
+	}
+
+	return nil
+}
@@ -0,0 +1,66 @@
+package server
+
+import (
+	"golang.org/x/oauth2"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestFileStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "refind")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	name := filepath.Join(dir, "tokens.json")
+	s, err := NewFileStore(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := s.Token("a"); err != errTokenMissing {
+		t.Errorf("got: <%v>, want: <%v>", err, errTokenMissing)
+	}
+
+	want := &oauth2.Token{AccessToken: "foo", RefreshToken: "bar", TokenType: "Bearer"}
+	if err := s.SetToken("a", want); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.SetToken("b", &oauth2.Token{AccessToken: "baz"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.DeleteToken("b"); err != nil {
+		t.Fatal(err)
+	}
+
+	again, err := NewFileStore(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := again.Token("a")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got: <%v>, want: <%v>", got, want)
+	}
+
+	if _, err := again.Token("b"); err != errTokenMissing {
+		t.Errorf("got: <%v>, want: <%v>", err, errTokenMissing)
+	}
+
+	ids, err := again.IDs()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(ids, []string{"a"}) {
+		t.Errorf("got: <%v>, want: <%v>", ids, []string{"a"})
+	}
+}
@@ -0,0 +1,79 @@
+package spotify
+
+import (
+	"github.com/pkg/errors"
+	"github.com/zmb3/spotify"
+)
+
+var errAttribute = errors.New("unsupported track attribute")
+
+type floatSetter func(*spotify.TrackAttributes, float64) *spotify.TrackAttributes
+
+type intSetter func(*spotify.TrackAttributes, int) *spotify.TrackAttributes
+
+var floatAttributes = map[string]floatSetter{
+	"target_acousticness":     (*spotify.TrackAttributes).TargetAcousticness,
+	"min_acousticness":        (*spotify.TrackAttributes).MinAcousticness,
+	"max_acousticness":        (*spotify.TrackAttributes).MaxAcousticness,
+	"target_danceability":     (*spotify.TrackAttributes).TargetDanceability,
+	"min_danceability":        (*spotify.TrackAttributes).MinDanceability,
+	"max_danceability":        (*spotify.TrackAttributes).MaxDanceability,
+	"target_energy":           (*spotify.TrackAttributes).TargetEnergy,
+	"min_energy":              (*spotify.TrackAttributes).MinEnergy,
+	"max_energy":              (*spotify.TrackAttributes).MaxEnergy,
+	"target_instrumentalness": (*spotify.TrackAttributes).TargetInstrumentalness,
+	"min_instrumentalness":    (*spotify.TrackAttributes).MinInstrumentalness,
+	"max_instrumentalness":    (*spotify.TrackAttributes).MaxInstrumentalness,
+	"target_liveness":         (*spotify.TrackAttributes).TargetLiveness,
+	"min_liveness":            (*spotify.TrackAttributes).MinLiveness,
+	"max_liveness":            (*spotify.TrackAttributes).MaxLiveness,
+	"target_loudness":         (*spotify.TrackAttributes).TargetLoudness,
+	"min_loudness":            (*spotify.TrackAttributes).MinLoudness,
+	"max_loudness":            (*spotify.TrackAttributes).MaxLoudness,
+	"target_speechiness":      (*spotify.TrackAttributes).TargetSpeechiness,
+	"min_speechiness":         (*spotify.TrackAttributes).MinSpeechiness,
+	"max_speechiness":         (*spotify.TrackAttributes).MaxSpeechiness,
+	"target_tempo":            (*spotify.TrackAttributes).TargetTempo,
+	"min_tempo":               (*spotify.TrackAttributes).MinTempo,
+	"max_tempo":               (*spotify.TrackAttributes).MaxTempo,
+	"target_valence":          (*spotify.TrackAttributes).TargetValence,
+	"min_valence":             (*spotify.TrackAttributes).MinValence,
+	"max_valence":             (*spotify.TrackAttributes).MaxValence,
+}
+
+var intAttributes = map[string]intSetter{
+	"target_duration_ms":    (*spotify.TrackAttributes).TargetDuration,
+	"min_duration_ms":       (*spotify.TrackAttributes).MinDuration,
+	"max_duration_ms":       (*spotify.TrackAttributes).MaxDuration,
+	"target_key":            (*spotify.TrackAttributes).TargetKey,
+	"min_key":               (*spotify.TrackAttributes).MinKey,
+	"max_key":               (*spotify.TrackAttributes).MaxKey,
+	"target_mode":           (*spotify.TrackAttributes).TargetMode,
+	"min_mode":              (*spotify.TrackAttributes).MinMode,
+	"max_mode":              (*spotify.TrackAttributes).MaxMode,
+	"target_popularity":     (*spotify.TrackAttributes).TargetPopularity,
+	"min_popularity":        (*spotify.TrackAttributes).MinPopularity,
+	"max_popularity":        (*spotify.TrackAttributes).MaxPopularity,
+	"target_time_signature": (*spotify.TrackAttributes).TargetTimeSignature,
+	"min_time_signature":    (*spotify.TrackAttributes).MinTimeSignature,
+	"max_time_signature":    (*spotify.TrackAttributes).MaxTimeSignature,
+}
+
+func parseAttributes(attrs map[string]float64) (*spotify.TrackAttributes, error) {
+	ta := spotify.NewTrackAttributes()
+	for k, v := range attrs {
+		if set, ok := floatAttributes[k]; ok {
+			set(ta, v)
+			continue
+		}
+
+		if set, ok := intAttributes[k]; ok {
+			set(ta, int(v))
+			continue
+		}
+
+		return nil, errors.Wrap(errAttribute, k)
+	}
+
+	return ta, nil
+}
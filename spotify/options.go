@@ -0,0 +1,269 @@
+package spotify
+
+import (
+	"context"
+	"github.com/Henry-Sarabia/refind"
+	"github.com/pkg/errors"
+	"github.com/zmb3/spotify"
+	"golang.org/x/oauth2"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+var (
+	errNilOption   = errors.New("cannot apply nil option")
+	errNilLogger   = errors.New("cannot use nil logger")
+	errNilMetrics  = errors.New("cannot use nil metrics")
+	errNilContext  = errors.New("cannot use nil context")
+	errNilTracer   = errors.New("cannot use nil tracer")
+	errRetryPolicy = errors.New("retry policy needs at least one attempt and non-negative backoff")
+)
+
+// Option configures a service when it is created with New.
+type Option func(*service) error
+
+// WithParams replaces the service's default parameters.
+func WithParams(p Params) Option {
+	return func(s *service) error {
+		return s.SetParams(p)
+	}
+}
+
+// RetryPolicy retries idempotent requests that fail because of rate limiting
+// or a server error. Attempts counts the first request, so 1 never retries.
+// The delay starts at Backoff and doubles after every attempt up to
+// MaxBackoff. The client does not expose the Retry-After of rate limited
+// responses, so the backoff alone has to outlast the rate limit.
+type RetryPolicy struct {
+	Attempts   int
+	Backoff    time.Duration
+	MaxBackoff time.Duration
+}
+
+func (p RetryPolicy) Validate() error {
+	if p.Attempts <= 0 || p.Backoff < 0 || p.MaxBackoff < 0 {
+		return errRetryPolicy
+	}
+
+	return nil
+}
+
+// WithRetry retries failed requests according to p. Without it every request
+// is made once.
+func WithRetry(p RetryPolicy) Option {
+	return func(s *service) error {
+		if err := p.Validate(); err != nil {
+			return err
+		}
+
+		s.retry = p
+		return nil
+	}
+}
+
+// WithRand jitters the retry delays using a random source with the given
+// seed, so that clients that failed together do not retry together.
+func WithRand(seed int64) Option {
+	return func(s *service) error {
+		s.rnd = rand.New(rand.NewSource(seed))
+		return nil
+	}
+}
+
+// WithCancel stops waiting between retries once ctx is done, so that a
+// shutdown does not have to sit out a backoff.
+func WithCancel(ctx context.Context) Option {
+	return func(s *service) error {
+		if ctx == nil {
+			return errNilContext
+		}
+
+		s.ctx = ctx
+		return nil
+	}
+}
+
+// do calls f until it succeeds, fails with an error that is not worth
+// retrying, runs out of attempts or the service's context is done, logging
+// every attempt at the endpoint. Only idempotent requests may use do.
+func (s *service) do(endpoint string, f func() error) error {
+	return s.call(endpoint, s.retry.Attempts, f)
+}
+
+// once calls f a single time. Requests that are not idempotent, such as
+// creating a playlist or adding tracks to one, use once so that a request
+// that took effect despite failing is not repeated.
+func (s *service) once(endpoint string, f func() error) error {
+	return s.call(endpoint, 1, f)
+}
+
+func (s *service) call(endpoint string, attempts int, f func() error) error {
+	log := s.logger()
+
+	var err error
+	for i := 0; ; i++ {
+		start := time.Now()
+		err = f()
+		latency := time.Since(start)
+
+		if err == nil {
+			s.measure(endpoint, http.StatusOK, latency)
+			log.Debug("spotify request", "endpoint", endpoint, "status", http.StatusOK, "latency", latency, "attempt", i+1)
+			return nil
+		}
+
+		s.measure(endpoint, status(err), latency)
+		log.Warn("spotify request failed", "endpoint", endpoint, "status", status(err), "latency", latency, "attempt", i+1, "error", refind.Redact(err.Error()))
+		if i+1 >= attempts || !retryable(err) {
+			return apiError(endpoint, err)
+		}
+
+		d := s.delay(i)
+		log.Info("retrying spotify request", "endpoint", endpoint, "delay", d)
+		if werr := s.wait(d); werr != nil {
+			return errors.Wrapf(werr, "cannot retry %s", endpoint)
+		}
+	}
+}
+
+func (s *service) delay(attempt int) time.Duration {
+	d := s.retry.Backoff << uint(attempt)
+	if s.retry.MaxBackoff > 0 && (d > s.retry.MaxBackoff || d < 0) {
+		d = s.retry.MaxBackoff
+	}
+
+	if s.rnd != nil && d > 0 {
+		d = d/2 + time.Duration(s.rnd.Int63n(int64(d/2)+1))
+	}
+
+	return d
+}
+
+// wait sleeps for d or until the service's context is done, whichever comes
+// first.
+func (s *service) wait(d time.Duration) error {
+	ctx := s.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	if s.sleep != nil {
+		return s.sleep(ctx, d)
+	}
+
+	t := time.NewTimer(d)
+	defer t.Stop()
+
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// WithLogger logs every Spotify request to l, such as a *slog.Logger.
+// Successful requests are logged at debug level with status 200 because the
+// client does not expose the exact status code.
+func WithLogger(l refind.Logger) Option {
+	return func(s *service) error {
+		if l == nil {
+			return errNilLogger
+		}
+
+		s.log = l
+		return nil
+	}
+}
+
+func (s *service) logger() refind.Logger {
+	if s.log == nil {
+		return refind.NopLogger()
+	}
+
+	return s.log
+}
+
+// WithMetrics counts every Spotify request and observes its latency by
+// endpoint.
+func WithMetrics(m refind.Metrics) Option {
+	return func(s *service) error {
+		if m == nil {
+			return errNilMetrics
+		}
+
+		s.met = m
+		return nil
+	}
+}
+
+// WithTracer traces every recommendation chunk fetched by a service bound to
+// a traced context with WithContext.
+func WithTracer(t refind.Tracer) Option {
+	return func(s *service) error {
+		if t == nil {
+			return errNilTracer
+		}
+
+		s.tr = t
+		return nil
+	}
+}
+
+// WithContext returns a copy of the service whose traced requests are
+// children of the span in ctx.
+func (s *service) WithContext(ctx context.Context) refind.Recommender {
+	c := *s
+	c.ctx = ctx
+	return &c
+}
+
+func (s *service) measure(endpoint string, code int, latency time.Duration) {
+	if s.met == nil {
+		return
+	}
+
+	s.met.Inc(refind.MetricSpotifyRequests, "endpoint", endpoint, "status", strconv.Itoa(code))
+	s.met.Observe(refind.MetricSpotifyLatency, latency.Seconds(), "endpoint", endpoint)
+}
+
+// status returns the HTTP status of an error response, or 0 when the
+// request failed without one.
+func status(err error) int {
+	if e, ok := errors.Cause(err).(spotify.Error); ok {
+		return e.Status
+	}
+
+	return 0
+}
+
+// apiError converts an error response into a *refind.APIError for the
+// endpoint so that callers can inspect its status. A token that cannot be
+// refreshed is reported as unauthorized, whatever status the token endpoint
+// answered with, because the user has to log in again.
+func apiError(endpoint string, err error) error {
+	var re *oauth2.RetrieveError
+	if errors.As(err, &re) {
+		msg := "cannot refresh token"
+		if re.ErrorCode != "" {
+			msg += ": " + re.ErrorCode
+		}
+
+		return &refind.APIError{Endpoint: endpoint, Status: http.StatusUnauthorized, Message: msg, Err: err}
+	}
+
+	e, ok := errors.Cause(err).(spotify.Error)
+	if !ok {
+		return err
+	}
+
+	return &refind.APIError{Endpoint: endpoint, Status: e.Status, Message: e.Message, Err: err}
+}
+
+// retryable reports whether err is a rate limit or server error response.
+func retryable(err error) bool {
+	code := status(err)
+	return code == http.StatusTooManyRequests || code >= http.StatusInternalServerError
+}
@@ -0,0 +1,357 @@
+package spotify
+
+import (
+	"context"
+	"github.com/Henry-Sarabia/refind"
+	"github.com/pkg/errors"
+	"github.com/zmb3/spotify"
+	"golang.org/x/oauth2"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNew_Options(t *testing.T) {
+	retry := RetryPolicy{Attempts: 3, Backoff: time.Second}
+	var nilCtx context.Context
+
+	tests := []struct {
+		name      string
+		opts      []Option
+		wantRetry RetryPolicy
+		wantErr   error
+	}{
+		{"No options", nil, RetryPolicy{}, nil},
+		{"Retry", []Option{WithRetry(retry)}, retry, nil},
+		{"Params", []Option{WithParams(DefaultParams()), WithRand(1)}, RetryPolicy{}, nil},
+		{"Invalid params", []Option{WithParams(Params{})}, RetryPolicy{}, refind.ErrRangeInvalid},
+		{"No attempts", []Option{WithRetry(RetryPolicy{})}, RetryPolicy{}, errRetryPolicy},
+		{"Negative backoff", []Option{WithRetry(RetryPolicy{Attempts: 2, Backoff: -1})}, RetryPolicy{}, errRetryPolicy},
+		{"Nil option", []Option{nil}, RetryPolicy{}, errNilOption},
+		{"Nil context", []Option{WithCancel(nilCtx)}, RetryPolicy{}, errNilContext},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			s, err := New(&spotify.Client{}, test.opts...)
+			if errors.Cause(err) != test.wantErr {
+				t.Fatalf("got: <%v>, want: <%v>", errors.Cause(err), test.wantErr)
+			}
+
+			if err != nil {
+				return
+			}
+
+			if !reflect.DeepEqual(s.retry, test.wantRetry) {
+				t.Errorf("got: <%v>, want: <%v>", s.retry, test.wantRetry)
+			}
+		})
+	}
+}
+
+func TestService_Do(t *testing.T) {
+	rateLimited := spotify.Error{Message: "rate limited", Status: 429}
+	unavailable := spotify.Error{Message: "unavailable", Status: 503}
+	notFound := spotify.Error{Message: "not found", Status: 404}
+
+	tests := []struct {
+		name       string
+		retry      RetryPolicy
+		errs       []error
+		wantCalls  int
+		wantSleeps []time.Duration
+		wantErr    error
+	}{
+		{
+			name:       "No retry policy",
+			retry:      RetryPolicy{},
+			errs:       []error{rateLimited, nil},
+			wantCalls:  1,
+			wantSleeps: nil,
+			wantErr:    rateLimited,
+		},
+		{
+			name:       "Retried until success",
+			retry:      RetryPolicy{Attempts: 5, Backoff: time.Second},
+			errs:       []error{rateLimited, unavailable, nil},
+			wantCalls:  3,
+			wantSleeps: []time.Duration{time.Second, 2 * time.Second},
+			wantErr:    nil,
+		},
+		{
+			name:       "Backoff capped",
+			retry:      RetryPolicy{Attempts: 4, Backoff: time.Second, MaxBackoff: 3 * time.Second},
+			errs:       []error{unavailable, unavailable, unavailable, unavailable},
+			wantCalls:  4,
+			wantSleeps: []time.Duration{time.Second, 2 * time.Second, 3 * time.Second},
+			wantErr:    unavailable,
+		},
+		{
+			name:       "Client error not retried",
+			retry:      RetryPolicy{Attempts: 3, Backoff: time.Second},
+			errs:       []error{errors.Wrap(notFound, "cannot fetch"), nil},
+			wantCalls:  1,
+			wantSleeps: nil,
+			wantErr:    notFound,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var sleeps []time.Duration
+			s := &service{retry: test.retry, sleep: func(_ context.Context, d time.Duration) error {
+				sleeps = append(sleeps, d)
+				return nil
+			}}
+
+			calls := 0
+			err := s.do("GET /test", func() error {
+				err := test.errs[calls]
+				calls++
+				return err
+			})
+			if !errors.Is(err, test.wantErr) {
+				t.Errorf("got: <%v>, want: <%v>", err, test.wantErr)
+			}
+
+			if calls != test.wantCalls {
+				t.Errorf("got: <%v>, want: <%v>", calls, test.wantCalls)
+			}
+
+			if !reflect.DeepEqual(sleeps, test.wantSleeps) {
+				t.Errorf("got: <%v>, want: <%v>", sleeps, test.wantSleeps)
+			}
+		})
+	}
+}
+
+func TestService_OnceNotRetried(t *testing.T) {
+	s := &service{retry: RetryPolicy{Attempts: 3, Backoff: time.Second}, sleep: func(context.Context, time.Duration) error { return nil }}
+
+	unavailable := spotify.Error{Message: "unavailable", Status: 503}
+	calls := 0
+	err := s.once("POST /test", func() error {
+		calls++
+		return unavailable
+	})
+	if !errors.Is(err, unavailable) {
+		t.Errorf("got: <%v>, want: <%v>", err, unavailable)
+	}
+
+	if calls != 1 {
+		t.Errorf("got: <%v>, want: <%v>", calls, 1)
+	}
+}
+
+func TestService_WaitCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	s, err := New(&spotify.Client{}, WithRetry(RetryPolicy{Attempts: 3, Backoff: time.Hour}), WithCancel(ctx))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	calls := 0
+	done := make(chan error, 1)
+	go func() {
+		done <- s.do("GET /test", func() error {
+			calls++
+			return spotify.Error{Message: "unavailable", Status: 503}
+		})
+	}()
+
+	select {
+	case err := <-done:
+		if errors.Cause(err) != context.Canceled {
+			t.Errorf("got: <%v>, want: <%v>", errors.Cause(err), context.Canceled)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("got: <still waiting>, want: <cancelled wait>")
+	}
+
+	if calls != 1 {
+		t.Errorf("got: <%v>, want: <%v>", calls, 1)
+	}
+}
+
+func TestService_DoAPIError(t *testing.T) {
+	tests := []struct {
+		name       string
+		err        error
+		wantStatus int
+		wantIs     error
+	}{
+		{"Expired token", spotify.Error{Message: "The access token expired", Status: 401}, 401, refind.ErrUnauthorized},
+		{"Rate limited", errors.Wrap(spotify.Error{Message: "API rate limit exceeded", Status: 429}, "cannot fetch"), 429, refind.ErrRateLimited},
+		{"Not found", spotify.Error{Message: "not found", Status: 404}, 404, nil},
+		{"No response", testErrNoData, 0, nil},
+		{"Refresh rejected", &oauth2.RetrieveError{Response: &http.Response{Status: "400 Bad Request", StatusCode: 400}, ErrorCode: "invalid_grant"}, 401, refind.ErrUnauthorized},
+		{"Refresh rejected in request", &url.Error{Op: "Get", URL: "https://api.spotify.com/v1/me", Err: &oauth2.RetrieveError{Response: &http.Response{Status: "401 Unauthorized", StatusCode: 401}}}, 401, refind.ErrUnauthorized},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			s := &service{}
+			err := errors.Wrap(s.do("GET /test", func() error { return test.err }), "cannot test")
+
+			var apiErr *refind.APIError
+			if ok := errors.As(err, &apiErr); ok != (test.wantStatus != 0) {
+				t.Fatalf("got: <%v>, want APIError: <%v>", ok, test.wantStatus != 0)
+			}
+
+			if apiErr != nil && (apiErr.Status != test.wantStatus || apiErr.Endpoint != "GET /test") {
+				t.Errorf("got: <%v>, want: <%v>", apiErr, test.wantStatus)
+			}
+
+			for _, sentinel := range []error{refind.ErrUnauthorized, refind.ErrRateLimited} {
+				if got := errors.Is(err, sentinel); got != (sentinel == test.wantIs) {
+					t.Errorf("got: <%v>, want: <%v>", got, sentinel == test.wantIs)
+				}
+			}
+		})
+	}
+}
+
+type logRecord struct {
+	level string
+	msg   string
+	args  []interface{}
+}
+
+type recordingLogger struct {
+	records *[]logRecord
+}
+
+func (r recordingLogger) add(level, msg string, args []interface{}) {
+	*r.records = append(*r.records, logRecord{level: level, msg: msg, args: args})
+}
+
+func (r recordingLogger) Debug(msg string, args ...interface{}) { r.add("debug", msg, args) }
+func (r recordingLogger) Info(msg string, args ...interface{})  { r.add("info", msg, args) }
+func (r recordingLogger) Warn(msg string, args ...interface{})  { r.add("warn", msg, args) }
+func (r recordingLogger) Error(msg string, args ...interface{}) { r.add("error", msg, args) }
+
+func TestService_DoLogging(t *testing.T) {
+	var recs []logRecord
+	s, err := New(&spotify.Client{}, WithLogger(recordingLogger{records: &recs}), WithRetry(RetryPolicy{Attempts: 2}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.sleep = func(context.Context, time.Duration) error { return nil }
+
+	errs := []error{spotify.Error{Message: "rate limited for Bearer abc123", Status: 429}, nil}
+	calls := 0
+	err = s.do("GET /me", func() error {
+		err := errs[calls]
+		calls++
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []struct {
+		level  string
+		msg    string
+		status interface{}
+		err    interface{}
+	}{
+		{"warn", "spotify request failed", 429, "rate limited for Bearer [REDACTED]"},
+		{"info", "retrying spotify request", nil, nil},
+		{"debug", "spotify request", 200, nil},
+	}
+	if len(recs) != len(want) {
+		t.Fatalf("got: <%v>, want: <%v>", len(recs), len(want))
+	}
+
+	for i, r := range recs {
+		args := make(map[interface{}]interface{})
+		for j := 0; j+1 < len(r.args); j += 2 {
+			args[r.args[j]] = r.args[j+1]
+		}
+
+		if r.level != want[i].level || r.msg != want[i].msg {
+			t.Errorf("got: <%v %v>, want: <%v %v>", r.level, r.msg, want[i].level, want[i].msg)
+		}
+
+		if args["endpoint"] != "GET /me" || args["status"] != want[i].status || args["error"] != want[i].err {
+			t.Errorf("got: <%v>, want endpoint, status <%v> and error <%v>", r.args, want[i].status, want[i].err)
+		}
+	}
+}
+
+type recordingTracer struct {
+	spans *[]string
+}
+
+func (r recordingTracer) Start(ctx context.Context, name string) (context.Context, refind.Span) {
+	*r.spans = append(*r.spans, name)
+	return ctx, nopSpan{}
+}
+
+type nopSpan struct{}
+
+func (nopSpan) SetAttributes(...interface{}) {}
+func (nopSpan) RecordError(error)            {}
+func (nopSpan) End()                         {}
+
+type countingMetrics struct {
+	counts map[string]int
+}
+
+func (c countingMetrics) Inc(name string, labels ...string) {
+	c.counts[name+" "+strings.Join(labels, ",")]++
+}
+
+func (c countingMetrics) Observe(name string, v float64, labels ...string) {
+	c.counts[name+" "+strings.Join(labels, ",")]++
+}
+
+func TestService_Telemetry(t *testing.T) {
+	sds := []refind.Seed{
+		{Category: refind.ArtistSeed, ID: "4NHQUGzhtTLFvgF5SZesLK"},
+		{Category: refind.ArtistSeed, ID: "0OdUWJ0sBjDrqHygGUXeCF"},
+	}
+
+	tests := []struct {
+		name      string
+		bind      bool
+		wantSpans []string
+	}{
+		{"Unbound service", false, nil},
+		{"Bound to traced context", true, []string{"RecommendationChunk"}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var spans []string
+			met := countingMetrics{counts: make(map[string]int)}
+			s, err := New(&spotify.Client{}, WithTracer(recordingTracer{spans: &spans}), WithMetrics(met))
+			if err != nil {
+				t.Fatal(err)
+			}
+			s.recom = fakeRecommender{file: testFileRecommendations}
+
+			var rec refind.Recommender = s
+			if test.bind {
+				rec = s.WithContext(context.Background())
+			}
+
+			if _, err := rec.Recommendations(testTotal, sds[:1]); err != nil {
+				t.Fatal(err)
+			}
+
+			if !reflect.DeepEqual(spans, test.wantSpans) {
+				t.Errorf("got: <%v>, want: <%v>", spans, test.wantSpans)
+			}
+
+			want := map[string]int{
+				refind.MetricSpotifyRequests + " endpoint,GET /recommendations,status,200": 1,
+				refind.MetricSpotifyLatency + " endpoint,GET /recommendations":             1,
+			}
+			if !reflect.DeepEqual(met.counts, want) {
+				t.Errorf("got: <%v>, want: <%v>", met.counts, want)
+			}
+		})
+	}
+}
@@ -1,35 +1,67 @@
 package spotify
 
 import (
+	"github.com/Henry-Sarabia/blank"
 	"github.com/Henry-Sarabia/refind"
 	"github.com/zmb3/spotify"
+	"time"
 )
 
+const isrcKey string = "isrc"
+
 func parseArtist(prev spotify.SimpleArtist) refind.Artist {
 	return refind.Artist{
 		ID:   string(prev.ID),
 		Name: prev.Name,
+		URI:  string(prev.URI),
+		URLs: prev.ExternalURLs,
 	}
 }
 
+func parseFullArtist(prev spotify.FullArtist) refind.Artist {
+	curr := parseArtist(prev.SimpleArtist)
+	curr.Genres = prev.Genres
+	curr.Images = parseImages(prev.Images...)
+	curr.Followers = int(prev.Followers.Count)
+	curr.Popularity = prev.Popularity
+
+	return curr
+}
+
 func parseArtists(prev ...spotify.FullArtist) []refind.Artist {
 	var curr []refind.Artist
 
 	for _, p := range prev {
-		curr = append(curr, parseArtist(p.SimpleArtist))
+		curr = append(curr, parseFullArtist(p))
 	}
 
 	return curr
 }
 
+// parseTrack parses the simplified tracks returned by recommendations, the
+// recently played endpoint and album listings. These leave out the album,
+// ISRC and popularity, which the service's Tracks method fills in.
 func parseTrack(prev spotify.SimpleTrack) refind.Track {
 	return refind.Track{
-		ID:     string(prev.ID),
-		Name:   prev.Name,
-		Artist: parseArtist(prev.Artists[0]),
+		ID:       string(prev.ID),
+		Name:     prev.Name,
+		Artist:   parseArtist(prev.Artists[0]),
+		Duration: time.Duration(prev.Duration) * time.Millisecond,
+		Explicit: prev.Explicit,
+		URI:      string(prev.URI),
+		URLs:     prev.ExternalURLs,
 	}
 }
 
+func parseFullTrack(prev spotify.FullTrack) refind.Track {
+	curr := parseTrack(prev.SimpleTrack)
+	curr.Album = parseAlbum(prev.Album)
+	curr.Popularity = prev.Popularity
+	curr.ISRC = prev.ExternalIDs[isrcKey]
+
+	return curr
+}
+
 func parseSimpleTracks(prev ...spotify.SimpleTrack) []refind.Track {
 	var curr []refind.Track
 
@@ -38,4 +70,89 @@ func parseSimpleTracks(prev ...spotify.SimpleTrack) []refind.Track {
 	}
 
 	return curr
-}
\ No newline at end of file
+}
+
+func parseFullTracks(prev ...spotify.FullTrack) []refind.Track {
+	var curr []refind.Track
+
+	for _, p := range prev {
+		curr = append(curr, parseFullTrack(p))
+	}
+
+	return curr
+}
+
+func parseAlbum(prev spotify.SimpleAlbum) refind.Album {
+	curr := refind.Album{
+		ID:     string(prev.ID),
+		Name:   prev.Name,
+		Images: parseImages(prev.Images...),
+	}
+
+	if !blank.Is(prev.ReleaseDate) {
+		curr.ReleaseDate = prev.ReleaseDateTime()
+	}
+
+	return curr
+}
+
+func parseImages(prev ...spotify.Image) []refind.Image {
+	var curr []refind.Image
+
+	for _, p := range prev {
+		curr = append(curr, refind.Image{
+			URL:    p.URL,
+			Width:  p.Width,
+			Height: p.Height,
+		})
+	}
+
+	return curr
+}
+
+func parseFeatures(f spotify.AudioFeatures) refind.Features {
+	return refind.Features{
+		Acousticness:     float64(f.Acousticness),
+		Danceability:     float64(f.Danceability),
+		Energy:           float64(f.Energy),
+		Instrumentalness: float64(f.Instrumentalness),
+		Liveness:         float64(f.Liveness),
+		Loudness:         float64(f.Loudness),
+		Speechiness:      float64(f.Speechiness),
+		Tempo:            float64(f.Tempo),
+		Valence:          float64(f.Valence),
+		Key:              f.Key,
+		Mode:             f.Mode,
+	}
+}
+
+func parseRelease(prev spotify.SimpleAlbum) refind.Release {
+	curr := refind.Release{
+		Album: parseAlbum(prev),
+	}
+
+	if len(prev.Artists) > 0 {
+		curr.Artist = parseArtist(prev.Artists[0])
+	}
+
+	switch prev.AlbumType {
+	case "single":
+		curr.Type = refind.SingleRelease
+	case "compilation":
+		curr.Type = refind.CompilationRelease
+	default:
+		curr.Type = refind.AlbumRelease
+	}
+
+	return curr
+}
+
+func parseReleases(prev ...spotify.SimpleAlbum) []refind.Release {
+	var curr []refind.Release
+
+	for _, p := range prev {
+		curr = append(curr, parseRelease(p))
+	}
+
+	return curr
+}
@@ -67,3 +67,12 @@ func parseSeed(old refind.Seed, sd *spotify.Seeds) error {
 
 	return nil
 }
+
+func parseIDs(old ...spotify.ID) []string {
+	var IDs []string
+	for _, o := range old {
+		IDs = append(IDs, string(o))
+	}
+
+	return IDs
+}
@@ -1,40 +1,76 @@
 package spotify
 
 import (
+	"context"
+	"fmt"
+	"github.com/Henry-Sarabia/blank"
 	"github.com/Henry-Sarabia/refind"
+	"github.com/Henry-Sarabia/refind/match"
 	"github.com/pkg/errors"
 	"github.com/zmb3/spotify"
+	"golang.org/x/oauth2"
+	"math/rand"
+	"strings"
+	"time"
 )
 
 const (
-	popTarget      int    = 40
-	popMax         int    = 50
-	publicPlaylist bool   = true
-	fetchMax       int    = 50
-	timeShort      string = "short"
-	timeMed        string = "medium"
-	timeLong       string = "long"
+	popTarget      int     = 40
+	popMax         int     = 50
+	publicPlaylist bool    = true
+	fetchMax       int     = 50
+	searchMax      int     = 10
+	featureMax     int     = 100
+	matchMin       float64 = 0.8
+	timeShort      string  = "short"
+	timeMed        string  = "medium"
+	timeLong       string  = "long"
+	trackURI       string  = "spotify:track:"
+	market         string  = "from_token"
+	albumMax       int     = 20
+	playlistMax    int     = 100
+	catalogMax     int     = 50
 )
 
+var timeRanges = map[refind.TimeRange]string{
+	refind.ShortTerm:  timeShort,
+	refind.MediumTerm: timeMed,
+	refind.LongTerm:   timeLong,
+}
+
 var (
 	errClientNil     = errors.New("client pointer is nil")
-	errDataInvalid   = errors.New("invalid or empty data returned")
-	errSeedsMissing  = errors.New("missing seed input")
 	errTracksMissing = errors.New("playlist track list is missing")
-	errRangeInvalid  = errors.New("integer parameter is out of range")
+	errTimeRange     = errors.New("unexpected time range")
+	errArtistID      = errors.New("artist ID is missing or blank")
+	errPlaylistID    = errors.New("playlist ID is missing or blank")
+	errPopInvalid    = errors.New("popularity bounds must satisfy 0 <= target <= max <= 100")
+	errNoToken       = errors.New("client does not hold an OAuth token")
 )
 
 type clienter interface {
 	artister
+	tracker
 	recenter
 	recommender
 	playlister
+	searcher
+	featurer
+	relater
+	artistTracker
+	releaser
+	replacer
+	cataloger
 }
 
 type artister interface {
 	CurrentUsersTopArtistsOpt(*spotify.Options) (*spotify.FullArtistPage, error)
 }
 
+type tracker interface {
+	CurrentUsersTopTracksOpt(*spotify.Options) (*spotify.FullTrackPage, error)
+}
+
 type recenter interface {
 	PlayerRecentlyPlayedOpt(*spotify.RecentlyPlayedOptions) ([]spotify.RecentlyPlayedItem, error)
 }
@@ -49,81 +85,368 @@ type playlister interface {
 	CurrentUser() (*spotify.PrivateUser, error)
 }
 
+type searcher interface {
+	SearchOpt(string, spotify.SearchType, *spotify.Options) (*spotify.SearchResult, error)
+}
+
+type featurer interface {
+	GetAudioFeatures(...spotify.ID) ([]*spotify.AudioFeatures, error)
+}
+
+type relater interface {
+	GetRelatedArtists(spotify.ID) ([]spotify.FullArtist, error)
+}
+
+type artistTracker interface {
+	GetArtistsTopTracks(spotify.ID, string) ([]spotify.FullTrack, error)
+}
+
+type releaser interface {
+	NewReleasesOpt(*spotify.Options) (*spotify.SimpleAlbumPage, error)
+	GetArtistAlbumsOpt(spotify.ID, *spotify.Options, *spotify.AlbumType) (*spotify.SimpleAlbumPage, error)
+	GetAlbums(...spotify.ID) ([]*spotify.FullAlbum, error)
+}
+
+type replacer interface {
+	ReplacePlaylistTracks(spotify.ID, ...spotify.ID) error
+}
+
+type cataloger interface {
+	GetTracks(...spotify.ID) ([]*spotify.FullTrack, error)
+	GetArtists(...spotify.ID) ([]*spotify.FullArtist, error)
+}
+
+// tokener is implemented by clients that authorize requests with an OAuth
+// token they refresh on their own.
+type tokener interface {
+	Token() (*oauth2.Token, error)
+}
+
 type service struct {
 	art   artister
+	trk   tracker
 	rec   recenter
 	recom recommender
 	play  playlister
+	srch  searcher
+	feat  featurer
+	rel   relater
+	atrk  artistTracker
+	albs  releaser
+	repl  replacer
+	cat   cataloger
+	tok   tokener
+	par   *Params
+	retry RetryPolicy
+	rnd   *rand.Rand
+	sleep func(context.Context, time.Duration) error
+	log   refind.Logger
+	met   refind.Metrics
+	tr    refind.Tracer
+	ctx   context.Context
 }
 
-func New(c clienter) (*service, error) {
+// Params are the tunable parameters of a service. Fetch is how many items a
+// single top or recently played request asks for, the popularity bounds
+// apply to every recommendation and Ranges are the time ranges merged by
+// TopArtists and TopTracks.
+type Params struct {
+	Fetch     int
+	PopTarget int
+	PopMax    int
+	Public    bool
+	Ranges    []refind.TimeRange
+}
+
+// DefaultParams returns the parameters a new service starts with.
+func DefaultParams() Params {
+	return Params{
+		Fetch:     fetchMax,
+		PopTarget: popTarget,
+		PopMax:    popMax,
+		Public:    publicPlaylist,
+		Ranges:    []refind.TimeRange{refind.ShortTerm, refind.MediumTerm, refind.LongTerm},
+	}
+}
+
+// Validate reports whether the parameters are within the limits of the
+// Spotify API.
+func (p Params) Validate() error {
+	if p.Fetch <= 0 || p.Fetch > fetchMax {
+		return refind.ErrRangeInvalid
+	}
+
+	if p.PopTarget < 0 || p.PopMax > 100 || p.PopTarget > p.PopMax {
+		return errPopInvalid
+	}
+
+	if len(p.Ranges) == 0 {
+		return errTimeRange
+	}
+
+	for _, r := range p.Ranges {
+		if _, ok := timeRanges[r]; !ok {
+			return errTimeRange
+		}
+	}
+
+	return nil
+}
+
+// ParseTimeRange returns the time range of the top list the Spotify API
+// names short, medium or long.
+func ParseTimeRange(name string) (refind.TimeRange, error) {
+	for r, n := range timeRanges {
+		if n == name {
+			return r, nil
+		}
+	}
+
+	return 0, errTimeRange
+}
+
+// New returns a service backed by the Spotify client c, configured by the
+// options in order.
+func New(c clienter, opts ...Option) (*service, error) {
 	if c == nil {
 		return nil, errClientNil
 	}
 	s := &service{
 		art:   c,
+		trk:   c,
 		rec:   c,
 		recom: c,
 		play:  c,
+		srch:  c,
+		feat:  c,
+		rel:   c,
+		atrk:  c,
+		albs:  c,
+		repl:  c,
+		cat:   c,
+	}
+
+	if t, ok := c.(tokener); ok {
+		s.tok = t
+	}
+
+	for _, opt := range opts {
+		if opt == nil {
+			return nil, errNilOption
+		}
+
+		if err := opt(s); err != nil {
+			return nil, err
+		}
 	}
 
 	return s, nil
 }
 
+// Token returns the OAuth token the client currently holds, which differs
+// from the one it started with once the client has refreshed it.
+func (s *service) Token() (*oauth2.Token, error) {
+	if s.tok == nil {
+		return nil, errNoToken
+	}
+
+	return s.tok.Token()
+}
+
+// SetParams replaces the service's default parameters.
+func (s *service) SetParams(p Params) error {
+	if err := p.Validate(); err != nil {
+		return err
+	}
+
+	s.par = &p
+	return nil
+}
+
+// params returns the parameters set with SetParams or else the defaults.
+func (s *service) params() Params {
+	if s.par == nil {
+		return DefaultParams()
+	}
+
+	return *s.par
+}
+
 func (s *service) TopArtists() ([]refind.Artist, error) {
 	var top []refind.Artist
+	idx := make(map[string]int)
 
-	short, err := s.topArtists(fetchMax, timeShort)
-	if err != nil {
-		return nil, err
+	p := s.params()
+	for _, r := range p.Ranges {
+		arts, err := s.TopArtistsRange(r, p.Fetch)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, a := range arts {
+			if j, ok := idx[a.ID]; ok {
+				top[j].Rankings = append(top[j].Rankings, a.Rankings...)
+				continue
+			}
+
+			idx[a.ID] = len(top)
+			top = append(top, a)
+		}
 	}
-	top = append(top, short...)
 
-	med, err := s.topArtists(fetchMax, timeMed)
-	if err != nil {
-		return nil, err
+	return top, nil
+}
+
+func (s *service) TopArtistsRange(r refind.TimeRange, limit int) ([]refind.Artist, error) {
+	if limit <= 0 {
+		return nil, refind.ErrRangeInvalid
 	}
-	top = append(top, med...)
 
-	long, err := s.topArtists(fetchMax, timeLong)
-	if err != nil {
-		return nil, err
+	time, ok := timeRanges[r]
+	if !ok {
+		return nil, errTimeRange
+	}
+
+	var top []refind.Artist
+	for len(top) < limit {
+		n := limit - len(top)
+		if n > fetchMax {
+			n = fetchMax
+		}
+
+		arts, err := s.topArtists(n, len(top), time)
+		if err != nil {
+			return nil, err
+		}
+
+		for i := range arts {
+			arts[i].Rankings = []refind.Ranking{{Range: r, Rank: len(top) + i + 1}}
+		}
+		top = append(top, arts...)
+
+		if len(arts) < n {
+			break
+		}
 	}
-	top = append(top, long...)
 
 	return top, nil
 }
 
-func (s *service) topArtists(limit int, time string) ([]refind.Artist, error) {
+func (s *service) topArtists(limit int, offset int, time string) ([]refind.Artist, error) {
 	opt := &spotify.Options{
 		Limit:     &limit,
+		Offset:    &offset,
 		Timerange: &time,
 	}
 
-	top, err := s.art.CurrentUsersTopArtistsOpt(opt)
+	var top *spotify.FullArtistPage
+	err := s.do("GET /me/top/artists", func() (err error) {
+		top, err = s.art.CurrentUsersTopArtistsOpt(opt)
+		return err
+	})
 	if err != nil {
 		return nil, errors.Wrap(err, "cannot fetch top artists")
 	}
 
 	if top == nil {
-		return nil, errDataInvalid
+		return nil, refind.ErrDataInvalid
 	}
 
 	return parseArtists(top.Artists...), nil
 }
 
+func (s *service) TopTracks() ([]refind.Track, error) {
+	var top []refind.Track
+	seen := make(map[string]bool)
+
+	p := s.params()
+	for _, r := range p.Ranges {
+		tracks, err := s.TopTracksRange(r, p.Fetch)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, t := range tracks {
+			if !seen[t.ID] {
+				seen[t.ID] = true
+				top = append(top, t)
+			}
+		}
+	}
+
+	return top, nil
+}
+
+func (s *service) TopTracksRange(r refind.TimeRange, limit int) ([]refind.Track, error) {
+	if limit <= 0 {
+		return nil, refind.ErrRangeInvalid
+	}
+
+	time, ok := timeRanges[r]
+	if !ok {
+		return nil, errTimeRange
+	}
+
+	var top []refind.Track
+	for len(top) < limit {
+		n := limit - len(top)
+		if n > fetchMax {
+			n = fetchMax
+		}
+
+		t, err := s.topTracks(n, len(top), time)
+		if err != nil {
+			return nil, err
+		}
+		top = append(top, t...)
+
+		if len(t) < n {
+			break
+		}
+	}
+
+	return top, nil
+}
+
+func (s *service) topTracks(limit int, offset int, time string) ([]refind.Track, error) {
+	opt := &spotify.Options{
+		Limit:     &limit,
+		Offset:    &offset,
+		Timerange: &time,
+	}
+
+	var top *spotify.FullTrackPage
+	err := s.do("GET /me/top/tracks", func() (err error) {
+		top, err = s.trk.CurrentUsersTopTracksOpt(opt)
+		return err
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot fetch top tracks")
+	}
+
+	if top == nil {
+		return nil, refind.ErrDataInvalid
+	}
+
+	return parseFullTracks(top.Tracks...), nil
+}
+
 func (s *service) RecentTracks() ([]refind.Track, error) {
 	opt := &spotify.RecentlyPlayedOptions{
-		Limit: fetchMax,
+		Limit: s.params().Fetch,
 	}
 
-	rec, err := s.rec.PlayerRecentlyPlayedOpt(opt)
+	var rec []spotify.RecentlyPlayedItem
+	err := s.do("GET /me/player/recently-played", func() (err error) {
+		rec, err = s.rec.PlayerRecentlyPlayedOpt(opt)
+		return err
+	})
 	if err != nil {
 		return nil, errors.Wrap(err, "cannot fetch recently played tracks")
 	}
 
 	if len(rec) <= 0 {
-		return nil, errDataInvalid
+		return nil, refind.ErrNoHistory
 	}
 
 	var t []refind.Track
@@ -135,24 +458,39 @@ func (s *service) RecentTracks() ([]refind.Track, error) {
 }
 
 func (s *service) Recommendations(total int, seeds []refind.Seed) ([]refind.Track, error) {
+	return s.TunedRecommendations(total, seeds, refind.Tuning{})
+}
+
+// TunedRecommendations fetches recommendations narrowed by the tuning's
+// attributes. Its genres take one seed slot in every request.
+func (s *service) TunedRecommendations(total int, seeds []refind.Seed, t refind.Tuning) ([]refind.Track, error) {
 	if len(seeds) <= 0 {
-		return nil, errSeedsMissing
+		return nil, refind.ErrSeedsMissing
 	}
 
 	if total <= 0 {
-		return nil, errRangeInvalid
+		return nil, refind.ErrRangeInvalid
 	}
 
-	sds, err := parseSeeds(seeds)
+	sds, err := seedGroups(seeds, t)
 	if err != nil {
 		return nil, err
 	}
 
+	p := s.params()
+	attrs := map[string]float64{
+		"target_popularity": float64(p.PopTarget),
+		"max_popularity":    float64(p.PopMax),
+	}
+	for k, v := range t.Attributes {
+		attrs[k] = v
+	}
+
 	var list []refind.Track
 	n := total / len(sds)
 
-	for _, sd := range sds {
-		recs, err := s.recommendation(n, sd)
+	for i, sd := range sds {
+		recs, err := s.chunk(n, i, sd, attrs)
 		if err != nil {
 			return nil, err
 		}
@@ -163,48 +501,141 @@ func (s *service) Recommendations(total int, seeds []refind.Seed) ([]refind.Trac
 	return list, nil
 }
 
-func (s *service) recommendation(n int, sd spotify.Seeds) ([]refind.Track, error) {
+// SeedGroups returns how many recommendation requests the seeds are split
+// into when tuned by t.
+func (s *service) SeedGroups(seeds []refind.Seed, t refind.Tuning) int {
+	sds, err := seedGroups(seeds, t)
+	if err != nil {
+		return 0
+	}
+
+	return len(sds)
+}
+
+// seedGroups splits the seeds into groups of at most five. When the tuning
+// prefers genres, every group gives one of its slots to a genre so that the
+// genres narrow every request, taking turns when there are several.
+func seedGroups(seeds []refind.Seed, t refind.Tuning) ([]spotify.Seeds, error) {
+	if len(t.Genres) == 0 {
+		return parseSeeds(seeds)
+	}
+
+	size := spotify.MaxNumberOfSeeds - 1
+	var sds []spotify.Seeds
+	for i := 0; i < len(seeds); i += size {
+		j := i + size
+		if j > len(seeds) {
+			j = len(seeds)
+		}
+
+		g := refind.Seed{Category: refind.GenreSeed, ID: t.Genres[len(sds)%len(t.Genres)]}
+		sd, err := parseMaxSeeds(append(append([]refind.Seed{}, seeds[i:j]...), g))
+		if err != nil {
+			return nil, err
+		}
+		sds = append(sds, sd)
+	}
+
+	return sds, nil
+}
+
+// chunk fetches the recommendations of a single seed group, tracing the
+// request when the service is bound to a traced context.
+func (s *service) chunk(n int, group int, sd spotify.Seeds, attrs map[string]float64) ([]refind.Track, error) {
+	if s.tr == nil || s.ctx == nil {
+		return s.recommendation(n, group, sd, attrs)
+	}
+
+	_, sp := s.tr.Start(s.ctx, "RecommendationChunk")
+	defer sp.End()
+
+	sp.SetAttributes("group", group, "limit", n, "seeds", len(sd.Artists)+len(sd.Tracks)+len(sd.Genres))
+	recs, err := s.recommendation(n, group, sd, attrs)
+	if err != nil {
+		sp.RecordError(err)
+	}
+
+	return recs, err
+}
+
+func (s *service) recommendation(n int, group int, sd spotify.Seeds, attrs map[string]float64) ([]refind.Track, error) {
 	opt := &spotify.Options{
 		Limit: &n,
 	}
 
-	attr := spotify.NewTrackAttributes().TargetPopularity(popTarget).MaxPopularity(popMax)
+	attr, err := parseAttributes(attrs)
+	if err != nil {
+		return nil, err
+	}
 
-	recs, err := s.recom.GetRecommendations(sd, attr, opt)
+	var recs *spotify.Recommendations
+	err = s.do("GET /recommendations", func() (err error) {
+		recs, err = s.recom.GetRecommendations(sd, attr, opt)
+		return err
+	})
 	if err != nil {
 		return nil, errors.Wrap(err, "cannot fetch recommendations")
 	}
 
 	if recs == nil {
-		return nil, errDataInvalid
+		return nil, refind.ErrDataInvalid
+	}
+
+	prov := &refind.Provenance{
+		Group:      group,
+		Tracks:     parseIDs(sd.Tracks...),
+		Artists:    parseIDs(sd.Artists...),
+		Genres:     sd.Genres,
+		Attributes: attrs,
 	}
 
 	t := parseSimpleTracks(recs.Tracks...)
+	for i := range t {
+		t[i].Provenance = prov
+	}
 
 	return t, nil
 }
 
+// UserID returns the Spotify ID of the user who authorized the client.
+func (s *service) UserID() (string, error) {
+	var u *spotify.PrivateUser
+	err := s.do("GET /me", func() (err error) {
+		u, err = s.play.CurrentUser()
+		return err
+	})
+	if err != nil {
+		return "", errors.Wrap(err, "cannot fetch user")
+	}
+
+	if u == nil || blank.Is(u.ID) {
+		return "", refind.ErrDataInvalid
+	}
+
+	return u.ID, nil
+}
+
 func (s *service) Playlist(name string, info string, list []refind.Track) (*spotify.FullPlaylist, error) {
 	if len(list) <= 0 {
 		return nil, errTracksMissing
 	}
 
-	u, err := s.play.CurrentUser()
+	uid, err := s.UserID()
 	if err != nil {
-		return nil, errors.Wrap(err, "cannot fetch user")
-	}
-
-	if u == nil {
-		return nil, errDataInvalid
+		return nil, err
 	}
 
-	pl, err := s.play.CreatePlaylistForUser(u.ID, name, info, publicPlaylist)
+	var pl *spotify.FullPlaylist
+	err = s.once("POST /users/{id}/playlists", func() (err error) {
+		pl, err = s.play.CreatePlaylistForUser(uid, name, info, s.params().Public)
+		return err
+	})
 	if err != nil {
 		return nil, errors.Wrap(err, "cannot create playlist")
 	}
 
 	if pl == nil {
-		return nil, errDataInvalid
+		return nil, refind.ErrDataInvalid
 	}
 
 	var IDs []spotify.ID
@@ -212,10 +643,361 @@ func (s *service) Playlist(name string, info string, list []refind.Track) (*spot
 		IDs = append(IDs, spotify.ID(t.ID))
 	}
 
-	_, err = s.play.AddTracksToPlaylist(pl.ID, IDs...)
+	err = s.once("POST /playlists/{id}/tracks", func() error {
+		_, err := s.play.AddTracksToPlaylist(pl.ID, IDs...)
+		return err
+	})
 	if err != nil {
 		return nil, errors.Wrap(err, "cannot add tracks to playlist")
 	}
 
 	return pl, nil
 }
+
+// ReplacePlaylist replaces every track of the playlist with the given ID by
+// the tracks in list.
+func (s *service) ReplacePlaylist(id string, list []refind.Track) error {
+	if blank.Is(id) {
+		return errPlaylistID
+	}
+
+	if len(list) <= 0 {
+		return errTracksMissing
+	}
+
+	var IDs []spotify.ID
+	for _, t := range list {
+		IDs = append(IDs, spotify.ID(t.ID))
+	}
+
+	first := IDs
+	if len(first) > playlistMax {
+		first = first[:playlistMax]
+	}
+
+	err := s.do("PUT /playlists/{id}/tracks", func() error {
+		return s.repl.ReplacePlaylistTracks(spotify.ID(id), first...)
+	})
+	if err != nil {
+		return errors.Wrap(err, "cannot replace playlist tracks")
+	}
+
+	for i := playlistMax; i < len(IDs); i += playlistMax {
+		end := i + playlistMax
+		if end > len(IDs) {
+			end = len(IDs)
+		}
+
+		batch := IDs[i:end]
+		err := s.once("POST /playlists/{id}/tracks", func() error {
+			_, err := s.play.AddTracksToPlaylist(spotify.ID(id), batch...)
+			return err
+		})
+		if err != nil {
+			return errors.Wrap(err, "cannot add tracks to playlist")
+		}
+	}
+
+	return nil
+}
+
+// Search looks up candidate Spotify tracks for a track that may come from
+// another provider, first by ISRC and then by title and artist.
+func (s *service) Search(t refind.Track) ([]refind.Track, error) {
+	var queries []string
+	if !blank.Is(t.ISRC) {
+		queries = append(queries, "isrc:"+t.ISRC)
+	}
+	queries = append(queries, fmt.Sprintf("track:%q artist:%q", t.Name, t.Artist.Name))
+
+	for _, q := range queries {
+		tracks, err := s.search(q)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(tracks) > 0 {
+			return tracks, nil
+		}
+	}
+
+	return nil, nil
+}
+
+func (s *service) search(query string) ([]refind.Track, error) {
+	limit := searchMax
+	opt := &spotify.Options{
+		Limit: &limit,
+	}
+
+	var res *spotify.SearchResult
+	err := s.do("GET /search", func() (err error) {
+		res, err = s.srch.SearchOpt(query, spotify.SearchTypeTrack, opt)
+		return err
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot search tracks")
+	}
+
+	if res == nil || res.Tracks == nil {
+		return nil, refind.ErrDataInvalid
+	}
+
+	return parseFullTracks(res.Tracks.Tracks...), nil
+}
+
+// MatchedPlaylist creates a playlist from tracks that may come from other
+// providers. Tracks without a Spotify URI are replaced by their closest
+// Spotify match and the tracks that could not be matched are returned.
+func (s *service) MatchedPlaylist(name string, info string, list []refind.Track) (*spotify.FullPlaylist, []refind.Track, error) {
+	m, err := match.New(s, matchMin)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var curr []refind.Track
+	var missing []refind.Track
+	for _, t := range list {
+		if strings.HasPrefix(t.URI, trackURI) {
+			curr = append(curr, t)
+			continue
+		}
+
+		res, ok, err := m.Find(t)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if !ok {
+			missing = append(missing, t)
+			continue
+		}
+		curr = append(curr, res.Track)
+	}
+
+	pl, err := s.Playlist(name, info, curr)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return pl, missing, nil
+}
+
+// AudioFeatures fetches the audio features of the given tracks in batches
+// of at most 100 IDs. Tracks that Spotify has no features for are left out.
+func (s *service) AudioFeatures(ids []string) (map[string]refind.Features, error) {
+	feats := make(map[string]refind.Features)
+	for _, batch := range batchIDs(ids, featureMax) {
+		var af []*spotify.AudioFeatures
+		err := s.do("GET /audio-features", func() (err error) {
+			af, err = s.feat.GetAudioFeatures(batch...)
+			return err
+		})
+		if err != nil {
+			return nil, errors.Wrap(err, "cannot fetch audio features")
+		}
+
+		for _, f := range af {
+			if f == nil {
+				continue
+			}
+			feats[f.ID.String()] = parseFeatures(*f)
+		}
+	}
+
+	return feats, nil
+}
+
+func (s *service) RelatedArtists(id string) ([]refind.Artist, error) {
+	if blank.Is(id) {
+		return nil, errArtistID
+	}
+
+	var arts []spotify.FullArtist
+	err := s.do("GET /artists/{id}/related-artists", func() (err error) {
+		arts, err = s.rel.GetRelatedArtists(spotify.ID(id))
+		return err
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot fetch related artists")
+	}
+
+	return parseArtists(arts...), nil
+}
+
+// ArtistTopTracks fetches an artist's most popular tracks in the user's
+// market.
+func (s *service) ArtistTopTracks(id string) ([]refind.Track, error) {
+	if blank.Is(id) {
+		return nil, errArtistID
+	}
+
+	var top []spotify.FullTrack
+	err := s.do("GET /artists/{id}/top-tracks", func() (err error) {
+		top, err = s.atrk.GetArtistsTopTracks(spotify.ID(id), market)
+		return err
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot fetch artist top tracks")
+	}
+
+	return parseFullTracks(top...), nil
+}
+
+var releaseTypes = map[refind.ReleaseType]spotify.AlbumType{
+	refind.AlbumRelease:       spotify.AlbumTypeAlbum,
+	refind.SingleRelease:      spotify.AlbumTypeSingle,
+	refind.CompilationRelease: spotify.AlbumTypeCompilation,
+}
+
+func (s *service) NewReleases() ([]refind.Release, error) {
+	limit := fetchMax
+	opt := &spotify.Options{
+		Limit: &limit,
+	}
+
+	var page *spotify.SimpleAlbumPage
+	err := s.do("GET /browse/new-releases", func() (err error) {
+		page, err = s.albs.NewReleasesOpt(opt)
+		return err
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot fetch new releases")
+	}
+
+	if page == nil {
+		return nil, refind.ErrDataInvalid
+	}
+
+	return parseReleases(page.Albums...), nil
+}
+
+// ArtistReleases fetches an artist's releases of the given types, or of
+// every type when types is empty.
+func (s *service) ArtistReleases(id string, types []refind.ReleaseType) ([]refind.Release, error) {
+	if blank.Is(id) {
+		return nil, errArtistID
+	}
+
+	var at spotify.AlbumType
+	for _, t := range types {
+		at |= releaseTypes[t]
+	}
+	if at == 0 {
+		at = spotify.AlbumTypeAlbum | spotify.AlbumTypeSingle | spotify.AlbumTypeCompilation
+	}
+
+	limit := fetchMax
+	opt := &spotify.Options{
+		Limit: &limit,
+	}
+
+	var page *spotify.SimpleAlbumPage
+	err := s.do("GET /artists/{id}/albums", func() (err error) {
+		page, err = s.albs.GetArtistAlbumsOpt(spotify.ID(id), opt, &at)
+		return err
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot fetch artist albums")
+	}
+
+	if page == nil {
+		return nil, refind.ErrDataInvalid
+	}
+
+	return parseReleases(page.Albums...), nil
+}
+
+// LeadTracks fetches the first track of each album in batches of at most 20
+// albums. Albums without tracks are left out.
+func (s *service) LeadTracks(albumIDs []string) (map[string]refind.Track, error) {
+	leads := make(map[string]refind.Track)
+	for _, batch := range batchIDs(albumIDs, albumMax) {
+		var albs []*spotify.FullAlbum
+		err := s.do("GET /albums", func() (err error) {
+			albs, err = s.albs.GetAlbums(batch...)
+			return err
+		})
+		if err != nil {
+			return nil, errors.Wrap(err, "cannot fetch albums")
+		}
+
+		for _, a := range albs {
+			if a == nil || len(a.Tracks.Tracks) == 0 {
+				continue
+			}
+
+			t := parseTrack(a.Tracks.Tracks[0])
+			t.Album = parseAlbum(a.SimpleAlbum)
+			leads[string(a.ID)] = t
+		}
+	}
+
+	return leads, nil
+}
+
+// Tracks fetches the full catalog data of the given tracks in batches of at
+// most 50 IDs. Tracks that Spotify cannot find are left out.
+func (s *service) Tracks(ids []string) ([]refind.Track, error) {
+	var tracks []refind.Track
+	for _, batch := range batchIDs(ids, catalogMax) {
+		var full []*spotify.FullTrack
+		err := s.do("GET /tracks", func() (err error) {
+			full, err = s.cat.GetTracks(batch...)
+			return err
+		})
+		if err != nil {
+			return nil, errors.Wrap(err, "cannot fetch tracks")
+		}
+
+		for _, f := range full {
+			if f != nil {
+				tracks = append(tracks, parseFullTrack(*f))
+			}
+		}
+	}
+
+	return tracks, nil
+}
+
+// Artists fetches the full catalog data of the given artists in batches of
+// at most 50 IDs. Artists that Spotify cannot find are left out.
+func (s *service) Artists(ids []string) ([]refind.Artist, error) {
+	var arts []refind.Artist
+	for _, batch := range batchIDs(ids, catalogMax) {
+		var full []*spotify.FullArtist
+		err := s.do("GET /artists", func() (err error) {
+			full, err = s.cat.GetArtists(batch...)
+			return err
+		})
+		if err != nil {
+			return nil, errors.Wrap(err, "cannot fetch artists")
+		}
+
+		for _, f := range full {
+			if f != nil {
+				arts = append(arts, parseFullArtist(*f))
+			}
+		}
+	}
+
+	return arts, nil
+}
+
+func batchIDs(ids []string, size int) [][]spotify.ID {
+	var batches [][]spotify.ID
+	for i := 0; i < len(ids); i += size {
+		j := i + size
+		if j > len(ids) {
+			j = len(ids)
+		}
+
+		var batch []spotify.ID
+		for _, id := range ids[i:j] {
+			batch = append(batch, spotify.ID(id))
+		}
+		batches = append(batches, batch)
+	}
+
+	return batches
+}
@@ -5,31 +5,42 @@ import (
 	"github.com/Henry-Sarabia/refind"
 	"github.com/pkg/errors"
 	"github.com/zmb3/spotify"
+	"golang.org/x/oauth2"
 	"io/ioutil"
 	"reflect"
+	"strconv"
 	"testing"
+	"time"
 )
 
 const (
 	testFileEmpty           string = "test_data/empty.json"
 	testFileTopArtists      string = "test_data/current_users_top_artists.json"
+	testFileTopTracks       string = "test_data/current_users_top_tracks.json"
 	testFileRecentTracks    string = "test_data/player_recently_played.json"
 	testFileRecommendations string = "test_data/get_recommendations.json"
 	testFileCurrentUser     string = "test_data/current_user.json"
 	testFileCreatePlaylist  string = "test_data/create_playlist_for_user.json"
+	testFileSearchTracks    string = "test_data/search_tracks.json"
+	testFileAudioFeatures   string = "test_data/audio_features.json"
+	testFileRelatedArtists  string = "test_data/get_related_artists.json"
+	testFileArtistTopTracks string = "test_data/get_artists_top_tracks.json"
+	testFileNewReleases     string = "test_data/new_releases.json"
+	testFileArtistAlbums    string = "test_data/get_artist_albums.json"
+	testFileAlbums          string = "test_data/get_albums.json"
 )
 
 var testErrNoData = errors.New("no data")
 
 func TestAuthenticator(t *testing.T) {
 	tests := []struct {
-		name string
-		uri string
+		name    string
+		uri     string
 		wantErr error
 	}{
 		{
-			name: "Valid URI",
-			uri: "some_valid_uri",
+			name:    "Valid URI",
+			uri:     "some_valid_uri",
 			wantErr: nil,
 		},
 		{
@@ -72,9 +83,18 @@ func TestNew(t *testing.T) {
 			c:    &spotify.Client{},
 			wantServ: &service{
 				art:   &spotify.Client{},
+				trk:   &spotify.Client{},
 				rec:   &spotify.Client{},
 				recom: &spotify.Client{},
 				play:  &spotify.Client{},
+				srch:  &spotify.Client{},
+				feat:  &spotify.Client{},
+				rel:   &spotify.Client{},
+				atrk:  &spotify.Client{},
+				albs:  &spotify.Client{},
+				repl:  &spotify.Client{},
+				cat:   &spotify.Client{},
+				tok:   &spotify.Client{},
 			},
 			wantErr: nil,
 		},
@@ -112,6 +132,22 @@ func (f fakeArtister) CurrentUsersTopArtistsOpt(opt *spotify.Options) (*spotify.
 	return artist, f.err
 }
 
+func testURLs(category string, id string) map[string]string {
+	return map[string]string{"spotify": "https://open.spotify.com/" + category + "/" + id}
+}
+
+func testArtist(id string, name string) refind.Artist {
+	return refind.Artist{ID: id, Name: name, URI: "spotify:artist:" + id, URLs: testURLs("artist", id)}
+}
+
+func testRankings(rank int) []refind.Ranking {
+	return []refind.Ranking{
+		{Range: refind.ShortTerm, Rank: rank},
+		{Range: refind.MediumTerm, Rank: rank},
+		{Range: refind.LongTerm, Rank: rank},
+	}
+}
+
 func TestService_TopArtists(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -126,36 +162,160 @@ func TestService_TopArtists(t *testing.T) {
 				err:  nil,
 			},
 			wantArts: []refind.Artist{
-				{ID: "4Z8W4fKeB5YxbusRsdQVPb", Name: "Radiohead"},
-				{ID: "3yY2gUcIsjMr8hjo51PoJ8", Name: "The Smiths"},
-				{ID: "3iTsJGG39nMg9YiolUgLMQ", Name: "Morrissey"},
-				{ID: "4BO8wK4OAaFsi6PSzs366S", Name: "Ricky Eat Acid"},
-				{ID: "4uSftVc3FPWe6RJuMZNEe9", Name: "Andrew Bird"},
-				{ID: "19I4tYiChJoxEO5EuviXpz", Name: "AFI"},
-				{ID: "0Y6dVaC9DZtPNH4591M42W", Name: "TV Girl"},
-				{ID: "7bu3H8JO7d0UbMoVzbo70s", Name: "The Cure"},
-				{ID: "0Q2Tc5yZFJpumLMc7Yz4e4", Name: "Tomppabeats"},
-				{ID: "19zqV9DV3txjMUjHvltl2D", Name: "Motion City Soundtrack"},
-				{ID: "4Z8W4fKeB5YxbusRsdQVPb", Name: "Radiohead"},
-				{ID: "3yY2gUcIsjMr8hjo51PoJ8", Name: "The Smiths"},
-				{ID: "3iTsJGG39nMg9YiolUgLMQ", Name: "Morrissey"},
-				{ID: "4BO8wK4OAaFsi6PSzs366S", Name: "Ricky Eat Acid"},
-				{ID: "4uSftVc3FPWe6RJuMZNEe9", Name: "Andrew Bird"},
-				{ID: "19I4tYiChJoxEO5EuviXpz", Name: "AFI"},
-				{ID: "0Y6dVaC9DZtPNH4591M42W", Name: "TV Girl"},
-				{ID: "7bu3H8JO7d0UbMoVzbo70s", Name: "The Cure"},
-				{ID: "0Q2Tc5yZFJpumLMc7Yz4e4", Name: "Tomppabeats"},
-				{ID: "19zqV9DV3txjMUjHvltl2D", Name: "Motion City Soundtrack"},
-				{ID: "4Z8W4fKeB5YxbusRsdQVPb", Name: "Radiohead"},
-				{ID: "3yY2gUcIsjMr8hjo51PoJ8", Name: "The Smiths"},
-				{ID: "3iTsJGG39nMg9YiolUgLMQ", Name: "Morrissey"},
-				{ID: "4BO8wK4OAaFsi6PSzs366S", Name: "Ricky Eat Acid"},
-				{ID: "4uSftVc3FPWe6RJuMZNEe9", Name: "Andrew Bird"},
-				{ID: "19I4tYiChJoxEO5EuviXpz", Name: "AFI"},
-				{ID: "0Y6dVaC9DZtPNH4591M42W", Name: "TV Girl"},
-				{ID: "7bu3H8JO7d0UbMoVzbo70s", Name: "The Cure"},
-				{ID: "0Q2Tc5yZFJpumLMc7Yz4e4", Name: "Tomppabeats"},
-				{ID: "19zqV9DV3txjMUjHvltl2D", Name: "Motion City Soundtrack"},
+				{
+					ID:     "4Z8W4fKeB5YxbusRsdQVPb",
+					Name:   "Radiohead",
+					Genres: []string{"alternative rock", "art rock", "indie rock", "melancholia", "modern rock", "permanent wave", "rock"},
+					Images: []refind.Image{
+						{URL: "https://i.scdn.co/image/afcd616e1ef2d2786f47b3b4a8a6aeea24a72adc", Width: 640, Height: 640},
+						{URL: "https://i.scdn.co/image/563754af10b3d9f9f62a3458e699f58c4a02870f", Width: 320, Height: 320},
+						{URL: "https://i.scdn.co/image/4067ea225d8b42fa6951857d3af27dd07d60f3c6", Width: 160, Height: 160},
+					},
+					Followers:  2777894,
+					Popularity: 80,
+					URI:        "spotify:artist:4Z8W4fKeB5YxbusRsdQVPb",
+					URLs:       testURLs("artist", "4Z8W4fKeB5YxbusRsdQVPb"),
+					Rankings:   testRankings(1),
+				},
+				{
+					ID:     "3yY2gUcIsjMr8hjo51PoJ8",
+					Name:   "The Smiths",
+					Genres: []string{"alternative rock", "art rock", "dance rock", "indie rock", "modern rock", "new wave", "permanent wave", "rock", "uk post-punk"},
+					Images: []refind.Image{
+						{URL: "https://i.scdn.co/image/481b980af463122013e4578c08fb8c5cbfaed1e9", Width: 1000, Height: 1516},
+						{URL: "https://i.scdn.co/image/4bf08a9e6eea088b20d4092d1322bbd3f39ff9af", Width: 640, Height: 970},
+						{URL: "https://i.scdn.co/image/bd4c7f5ff2c5c4385604e60c71eac1dd498ddbd9", Width: 200, Height: 303},
+						{URL: "https://i.scdn.co/image/d3a2542f2811b5b01ee3483ec7c193f72a882ea1", Width: 64, Height: 97},
+					},
+					Followers:  1344165,
+					Popularity: 74,
+					URI:        "spotify:artist:3yY2gUcIsjMr8hjo51PoJ8",
+					URLs:       testURLs("artist", "3yY2gUcIsjMr8hjo51PoJ8"),
+					Rankings:   testRankings(2),
+				},
+				{
+					ID:     "3iTsJGG39nMg9YiolUgLMQ",
+					Name:   "Morrissey",
+					Genres: []string{"dance rock", "indie rock", "madchester", "new romantic", "new wave", "permanent wave", "rock"},
+					Images: []refind.Image{
+						{URL: "https://i.scdn.co/image/cdbc12a6b10dbab32ed73d4979183e95f46ebeb2", Width: 1000, Height: 1173},
+						{URL: "https://i.scdn.co/image/75416e947bf832f9181500e2da9ebd5feed53d93", Width: 640, Height: 750},
+						{URL: "https://i.scdn.co/image/7df0fc8e174a1359c868e0f3ae4b5b21554d8e20", Width: 200, Height: 235},
+						{URL: "https://i.scdn.co/image/98ff6d3734980aed9fea853eb439a1ba282c67c9", Width: 64, Height: 75},
+					},
+					Followers:  408163,
+					Popularity: 65,
+					URI:        "spotify:artist:3iTsJGG39nMg9YiolUgLMQ",
+					URLs:       testURLs("artist", "3iTsJGG39nMg9YiolUgLMQ"),
+					Rankings:   testRankings(3),
+				},
+				{
+					ID:     "4BO8wK4OAaFsi6PSzs366S",
+					Name:   "Ricky Eat Acid",
+					Genres: []string{"indie garage rock", "indie psych-pop", "vaporwave"},
+					Images: []refind.Image{
+						{URL: "https://i.scdn.co/image/0ccb9e3f7eea2098e9132cf667ddcaa3485a34d6", Width: 1000, Height: 667},
+						{URL: "https://i.scdn.co/image/d9eb05a501ab75a50a2565e99aefabf527db8099", Width: 640, Height: 427},
+						{URL: "https://i.scdn.co/image/985de27c45fb2da37426139f33d862135a629267", Width: 199, Height: 133},
+						{URL: "https://i.scdn.co/image/136a47550aef934dd32a52910e617320977d91d4", Width: 64, Height: 43},
+					},
+					Followers:  14167,
+					Popularity: 44,
+					URI:        "spotify:artist:4BO8wK4OAaFsi6PSzs366S",
+					URLs:       testURLs("artist", "4BO8wK4OAaFsi6PSzs366S"),
+					Rankings:   testRankings(4),
+				},
+				{
+					ID:     "4uSftVc3FPWe6RJuMZNEe9",
+					Name:   "Andrew Bird",
+					Genres: []string{"anti-folk", "art pop", "chamber pop", "chicago indie", "folk christmas", "folk-pop", "freak folk", "indie christmas", "indie folk", "indie pop", "indie rock", "lo-fi", "melancholia", "modern rock", "neo-psychedelic", "new americana", "shimmer pop", "singer-songwriter", "stomp and holler"},
+					Images: []refind.Image{
+						{URL: "https://i.scdn.co/image/55df15aef6f7ca01a9bc2a862252b187bbc7e571", Width: 1000, Height: 1000},
+						{URL: "https://i.scdn.co/image/2f48e31f21aab46c4d0403f7bb041b0d96b2beae", Width: 640, Height: 640},
+						{URL: "https://i.scdn.co/image/4903445cdaaf580728546267f4575705a77dfb7e", Width: 200, Height: 200},
+						{URL: "https://i.scdn.co/image/e255ad94d8d57cd7083328d2ffe466004c8fd58b", Width: 64, Height: 64},
+					},
+					Followers:  264665,
+					Popularity: 61,
+					URI:        "spotify:artist:4uSftVc3FPWe6RJuMZNEe9",
+					URLs:       testURLs("artist", "4uSftVc3FPWe6RJuMZNEe9"),
+					Rankings:   testRankings(5),
+				},
+				{
+					ID:     "19I4tYiChJoxEO5EuviXpz",
+					Name:   "AFI",
+					Genres: []string{"alternative metal", "emo", "modern rock", "nu metal", "pop punk", "post-grunge", "punk", "rap metal", "screamo", "skate punk"},
+					Images: []refind.Image{
+						{URL: "https://i.scdn.co/image/0d52007dc61774fd0495f7d74a1ae0babcf3da38", Width: 640, Height: 640},
+						{URL: "https://i.scdn.co/image/29b796d8acf912c399a6e409a6b8864a18d0f9a0", Width: 320, Height: 320},
+						{URL: "https://i.scdn.co/image/73cf98a5df499094ccd72d29cc47880adffdf5f1", Width: 160, Height: 160},
+					},
+					Followers:  345149,
+					Popularity: 65,
+					URI:        "spotify:artist:19I4tYiChJoxEO5EuviXpz",
+					URLs:       testURLs("artist", "19I4tYiChJoxEO5EuviXpz"),
+					Rankings:   testRankings(6),
+				},
+				{
+					ID:     "0Y6dVaC9DZtPNH4591M42W",
+					Name:   "TV Girl",
+					Genres: []string{"chillwave", "indie dream pop", "indie garage rock", "indie pop", "indie psych-rock", "indietronica", "modern rock", "neo-psychedelic", "noise pop", "preverb", "shimmer pop", "shimmer psych", "vapor soul"},
+					Images: []refind.Image{
+						{URL: "https://i.scdn.co/image/13e14c6278c44bb7df796a875c2b533aad90bbd7", Width: 640, Height: 640},
+						{URL: "https://i.scdn.co/image/aa99890d55ef2405be4024a47f796b4eea28f450", Width: 300, Height: 300},
+						{URL: "https://i.scdn.co/image/6df70b14484269c6160c68707de684aefee63247", Width: 64, Height: 64},
+					},
+					Followers:  33017,
+					Popularity: 56,
+					URI:        "spotify:artist:0Y6dVaC9DZtPNH4591M42W",
+					URLs:       testURLs("artist", "0Y6dVaC9DZtPNH4591M42W"),
+					Rankings:   testRankings(7),
+				},
+				{
+					ID:     "7bu3H8JO7d0UbMoVzbo70s",
+					Name:   "The Cure",
+					Genres: []string{"alternative rock", "art rock", "dance rock", "new romantic", "new wave", "permanent wave", "pop rock", "rock"},
+					Images: []refind.Image{
+						{URL: "https://i.scdn.co/image/7ca743e822b80133971ccf5c70fcbd77a4f4f508", Width: 640, Height: 640},
+						{URL: "https://i.scdn.co/image/2cb2e14783685fd3d27006891aaaa35fc53cd82d", Width: 200, Height: 200},
+						{URL: "https://i.scdn.co/image/9e1ed6613ac0ef103fbfca3c11ff35fec8d9c6b1", Width: 64, Height: 64},
+					},
+					Followers:  1317927,
+					Popularity: 74,
+					URI:        "spotify:artist:7bu3H8JO7d0UbMoVzbo70s",
+					URLs:       testURLs("artist", "7bu3H8JO7d0UbMoVzbo70s"),
+					Rankings:   testRankings(8),
+				},
+				{
+					ID:     "0Q2Tc5yZFJpumLMc7Yz4e4",
+					Name:   "Tomppabeats",
+					Genres: []string{"chillhop"},
+					Images: []refind.Image{
+						{URL: "https://i.scdn.co/image/afc1f34ffaddc6d4634f3ee32c5aba85d22136db", Width: 640, Height: 640},
+						{URL: "https://i.scdn.co/image/aa33dc4717daf909f4ae102c75600937ea7d023b", Width: 320, Height: 320},
+						{URL: "https://i.scdn.co/image/c11300645f8ae6e95477b5a2db833dcee154d0b2", Width: 160, Height: 160},
+					},
+					Followers:  104021,
+					Popularity: 69,
+					URI:        "spotify:artist:0Q2Tc5yZFJpumLMc7Yz4e4",
+					URLs:       testURLs("artist", "0Q2Tc5yZFJpumLMc7Yz4e4"),
+					Rankings:   testRankings(9),
+				},
+				{
+					ID:     "19zqV9DV3txjMUjHvltl2D",
+					Name:   "Motion City Soundtrack",
+					Genres: []string{"emo", "modern rock", "pop punk", "pop rock", "screamo"},
+					Images: []refind.Image{
+						{URL: "https://i.scdn.co/image/2c52ae453b38814926b6f276e4f0207eb0657366", Width: 640, Height: 640},
+						{URL: "https://i.scdn.co/image/ac55e0dec6dd5b3a509a498742fe57d0e2a81f93", Width: 320, Height: 320},
+						{URL: "https://i.scdn.co/image/c1a713c3e6e5445b6f6e141f0f1e0e0fa0419f97", Width: 160, Height: 160},
+					},
+					Followers:  148551,
+					Popularity: 59,
+					URI:        "spotify:artist:19zqV9DV3txjMUjHvltl2D",
+					URLs:       testURLs("artist", "19zqV9DV3txjMUjHvltl2D"),
+					Rankings:   testRankings(10),
+				},
 			},
 			wantErr: nil,
 		},
@@ -175,7 +335,7 @@ func TestService_TopArtists(t *testing.T) {
 				err:  nil,
 			},
 			wantArts: nil,
-			wantErr:  errDataInvalid,
+			wantErr:  refind.ErrDataInvalid,
 		},
 		{
 			name: "No data, error",
@@ -203,6 +363,435 @@ func TestService_TopArtists(t *testing.T) {
 	}
 }
 
+type fakeArtistPager struct {
+	total int
+}
+
+func (f fakeArtistPager) CurrentUsersTopArtistsOpt(opt *spotify.Options) (*spotify.FullArtistPage, error) {
+	var page spotify.FullArtistPage
+	for i := *opt.Offset; i < *opt.Offset+*opt.Limit && i < f.total; i++ {
+		page.Artists = append(page.Artists, spotify.FullArtist{
+			SimpleArtist: spotify.SimpleArtist{ID: spotify.ID(strconv.Itoa(i)), Name: "artist" + strconv.Itoa(i)},
+		})
+	}
+
+	return &page, nil
+}
+
+func testRangedArtists(n int, r refind.TimeRange) []refind.Artist {
+	var arts []refind.Artist
+	for i := 0; i < n; i++ {
+		arts = append(arts, refind.Artist{
+			ID:       strconv.Itoa(i),
+			Name:     "artist" + strconv.Itoa(i),
+			Rankings: []refind.Ranking{{Range: r, Rank: i + 1}},
+		})
+	}
+
+	return arts
+}
+
+func TestService_TopArtistsRange(t *testing.T) {
+	tests := []struct {
+		name     string
+		art      artister
+		r        refind.TimeRange
+		limit    int
+		wantArts []refind.Artist
+		wantErr  error
+	}{
+		{
+			name:     "Limit within single page",
+			art:      fakeArtistPager{total: 100},
+			r:        refind.ShortTerm,
+			limit:    20,
+			wantArts: testRangedArtists(20, refind.ShortTerm),
+			wantErr:  nil,
+		},
+		{
+			name:     "Limit across multiple pages",
+			art:      fakeArtistPager{total: 100},
+			r:        refind.MediumTerm,
+			limit:    75,
+			wantArts: testRangedArtists(75, refind.MediumTerm),
+			wantErr:  nil,
+		},
+		{
+			name:     "Limit larger than available artists",
+			art:      fakeArtistPager{total: 60},
+			r:        refind.LongTerm,
+			limit:    120,
+			wantArts: testRangedArtists(60, refind.LongTerm),
+			wantErr:  nil,
+		},
+		{
+			name:     "Limit out of range",
+			art:      fakeArtistPager{total: 100},
+			r:        refind.ShortTerm,
+			limit:    0,
+			wantArts: nil,
+			wantErr:  refind.ErrRangeInvalid,
+		},
+		{
+			name:     "Unexpected time range",
+			art:      fakeArtistPager{total: 100},
+			r:        -1,
+			limit:    20,
+			wantArts: nil,
+			wantErr:  errTimeRange,
+		},
+		{
+			name: "Valid data, error",
+			art: fakeArtister{
+				file: testFileTopArtists,
+				err:  testErrNoData,
+			},
+			r:        refind.ShortTerm,
+			limit:    20,
+			wantArts: nil,
+			wantErr:  testErrNoData,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			serv := service{art: test.art}
+
+			got, err := serv.TopArtistsRange(test.r, test.limit)
+			if errors.Cause(err) != test.wantErr {
+				t.Errorf("got: <%v>, want: <%v>", errors.Cause(err), test.wantErr)
+			}
+
+			if !reflect.DeepEqual(got, test.wantArts) {
+				t.Errorf("got: <%v>, want: <%v>", got, test.wantArts)
+			}
+		})
+	}
+}
+
+type fakeTracker struct {
+	file string
+	err  error
+}
+
+func (f fakeTracker) CurrentUsersTopTracksOpt(opt *spotify.Options) (*spotify.FullTrackPage, error) {
+	b, err := ioutil.ReadFile(f.file)
+	if err != nil {
+		return nil, err
+	}
+
+	var tracks *spotify.FullTrackPage
+	if err := json.Unmarshal(b, &tracks); err != nil {
+		return nil, f.err
+	}
+
+	return tracks, f.err
+}
+
+func TestService_TopTracks(t *testing.T) {
+	tests := []struct {
+		name       string
+		trk        tracker
+		wantTracks []refind.Track
+		wantErr    error
+	}{
+		{
+			name: "Valid data, nil error",
+			trk: fakeTracker{
+				file: testFileTopTracks,
+				err:  nil,
+			},
+			wantTracks: []refind.Track{
+				{
+					ID:     "6LgJvl0Xdtc73RJ1mmpotq",
+					Name:   "Reckoner",
+					Artist: testArtist("4Z8W4fKeB5YxbusRsdQVPb", "Radiohead"),
+					Album: refind.Album{
+						ID:          "7eyQXxuf2nGj9d2367Gi5f",
+						Name:        "In Rainbows",
+						ReleaseDate: time.Date(2007, 12, 28, 0, 0, 0, 0, time.UTC),
+						Images: []refind.Image{
+							{URL: "https://i.scdn.co/image/de3c04b5fc750b68899b20a7a6e8e6f4d9b3f5c1", Width: 640, Height: 640},
+							{URL: "https://i.scdn.co/image/6a8c3b1e9a2d27ba1ef8d0dfd4c3bde2a6a0e1f2", Width: 300, Height: 300},
+							{URL: "https://i.scdn.co/image/2f2d3e3b2b0e5aa4c8a6c2b1ec0d1b8b9f8e7d6c", Width: 64, Height: 64},
+						},
+					},
+					Duration:   290213 * time.Millisecond,
+					Popularity: 66,
+					ISRC:       "GBSTK0700057",
+					URI:        "spotify:track:6LgJvl0Xdtc73RJ1mmpotq",
+					URLs:       testURLs("track", "6LgJvl0Xdtc73RJ1mmpotq"),
+				},
+				{
+					ID:     "0WQiDwKJclirSYG9v5tayI",
+					Name:   "There Is a Light That Never Goes Out - 2011 Remaster",
+					Artist: testArtist("3yY2gUcIsjMr8hjo51PoJ8", "The Smiths"),
+					Album: refind.Album{
+						ID:          "5Y0p2XCgRRIjna91aQE8q7",
+						Name:        "The Queen Is Dead",
+						ReleaseDate: time.Date(1986, 6, 16, 0, 0, 0, 0, time.UTC),
+						Images: []refind.Image{
+							{URL: "https://i.scdn.co/image/f3b2f5b4c0c9e8b1a5e2d4c3b6a7f8e9d0c1b2a3", Width: 640, Height: 640},
+							{URL: "https://i.scdn.co/image/a1b2c3d4e5f60718293a4b5c6d7e8f9012345678", Width: 300, Height: 300},
+							{URL: "https://i.scdn.co/image/0f1e2d3c4b5a69788796a5b4c3d2e1f0a9b8c7d6", Width: 64, Height: 64},
+						},
+					},
+					Duration:   244826 * time.Millisecond,
+					Popularity: 72,
+					ISRC:       "GBAHT1100131",
+					URI:        "spotify:track:0WQiDwKJclirSYG9v5tayI",
+					URLs:       testURLs("track", "0WQiDwKJclirSYG9v5tayI"),
+				},
+				{
+					ID:     "1hfFpTcqNVRPKVpqlXkZE8",
+					Name:   "Everyday Is Like Sunday",
+					Artist: testArtist("3iTsJGG39nMg9YiolUgLMQ", "Morrissey"),
+					Album: refind.Album{
+						ID:          "0dWaUJtp6vIuWvbfuk07Xc",
+						Name:        "Viva Hate",
+						ReleaseDate: time.Date(1988, 1, 1, 0, 0, 0, 0, time.UTC),
+						Images: []refind.Image{
+							{URL: "https://i.scdn.co/image/9e8d7c6b5a4f3e2d1c0b9a8f7e6d5c4b3a2f1e0d", Width: 640, Height: 640},
+							{URL: "https://i.scdn.co/image/1a2b3c4d5e6f7a8b9c0d1e2f3a4b5c6d7e8f9a0b", Width: 300, Height: 300},
+							{URL: "https://i.scdn.co/image/c0ffee00c0ffee00c0ffee00c0ffee00c0ffee00", Width: 64, Height: 64},
+						},
+					},
+					Duration:   214000 * time.Millisecond,
+					Popularity: 58,
+					ISRC:       "GBAYE8800005",
+					URI:        "spotify:track:1hfFpTcqNVRPKVpqlXkZE8",
+					URLs:       testURLs("track", "1hfFpTcqNVRPKVpqlXkZE8"),
+				},
+				{
+					ID:     "3eGCBXu58ISDL6OJdLKKSe",
+					Name:   "Just Like Heaven",
+					Artist: testArtist("7bu3H8JO7d0UbMoVzbo70s", "The Cure"),
+					Album: refind.Album{
+						ID:          "3yX2x9m0JmJM7bnqT9Akpm",
+						Name:        "Kiss Me, Kiss Me, Kiss Me",
+						ReleaseDate: time.Date(1987, 5, 1, 0, 0, 0, 0, time.UTC),
+						Images: []refind.Image{
+							{URL: "https://i.scdn.co/image/b0b0b0b0a1a1a1a1c2c2c2c2d3d3d3d3e4e4e4e4", Width: 640, Height: 640},
+							{URL: "https://i.scdn.co/image/f5f5f5f5a6a6a6a6b7b7b7b7c8c8c8c8d9d9d9d9", Width: 300, Height: 300},
+							{URL: "https://i.scdn.co/image/1234567890abcdef1234567890abcdef12345678", Width: 64, Height: 64},
+						},
+					},
+					Duration:   212560 * time.Millisecond,
+					Explicit:   true,
+					Popularity: 71,
+					ISRC:       "GBALB8700012",
+					URI:        "spotify:track:3eGCBXu58ISDL6OJdLKKSe",
+					URLs:       testURLs("track", "3eGCBXu58ISDL6OJdLKKSe"),
+				},
+			},
+			wantErr: nil,
+		},
+		{
+			name: "Valid data, error",
+			trk: fakeTracker{
+				file: testFileTopTracks,
+				err:  testErrNoData,
+			},
+			wantTracks: nil,
+			wantErr:    testErrNoData,
+		},
+		{
+			name: "No data, nil error",
+			trk: fakeTracker{
+				file: testFileEmpty,
+				err:  nil,
+			},
+			wantTracks: nil,
+			wantErr:    refind.ErrDataInvalid,
+		},
+		{
+			name: "No data, error",
+			trk: fakeTracker{
+				file: testFileEmpty,
+				err:  testErrNoData,
+			},
+			wantTracks: nil,
+			wantErr:    testErrNoData,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			serv := service{trk: test.trk}
+
+			got, err := serv.TopTracks()
+			if errors.Cause(err) != test.wantErr {
+				t.Errorf("got: <%v>, want: <%v>", errors.Cause(err), test.wantErr)
+			}
+
+			if !reflect.DeepEqual(got, test.wantTracks) {
+				t.Errorf("\ngot:  <%v>, \nwant: <%v>", got, test.wantTracks)
+			}
+		})
+	}
+}
+
+func TestService_TopTracksRange(t *testing.T) {
+	tests := []struct {
+		name       string
+		trk        tracker
+		r          refind.TimeRange
+		limit      int
+		wantTracks []refind.Track
+		wantErr    error
+	}{
+		{
+			name: "Valid data, nil error",
+			trk: fakeTracker{
+				file: testFileTopTracks,
+				err:  nil,
+			},
+			r:     refind.ShortTerm,
+			limit: 10,
+			wantTracks: []refind.Track{
+				{
+					ID:     "6LgJvl0Xdtc73RJ1mmpotq",
+					Name:   "Reckoner",
+					Artist: testArtist("4Z8W4fKeB5YxbusRsdQVPb", "Radiohead"),
+					Album: refind.Album{
+						ID:          "7eyQXxuf2nGj9d2367Gi5f",
+						Name:        "In Rainbows",
+						ReleaseDate: time.Date(2007, 12, 28, 0, 0, 0, 0, time.UTC),
+						Images: []refind.Image{
+							{URL: "https://i.scdn.co/image/de3c04b5fc750b68899b20a7a6e8e6f4d9b3f5c1", Width: 640, Height: 640},
+							{URL: "https://i.scdn.co/image/6a8c3b1e9a2d27ba1ef8d0dfd4c3bde2a6a0e1f2", Width: 300, Height: 300},
+							{URL: "https://i.scdn.co/image/2f2d3e3b2b0e5aa4c8a6c2b1ec0d1b8b9f8e7d6c", Width: 64, Height: 64},
+						},
+					},
+					Duration:   290213 * time.Millisecond,
+					Popularity: 66,
+					ISRC:       "GBSTK0700057",
+					URI:        "spotify:track:6LgJvl0Xdtc73RJ1mmpotq",
+					URLs:       testURLs("track", "6LgJvl0Xdtc73RJ1mmpotq"),
+				},
+				{
+					ID:     "0WQiDwKJclirSYG9v5tayI",
+					Name:   "There Is a Light That Never Goes Out - 2011 Remaster",
+					Artist: testArtist("3yY2gUcIsjMr8hjo51PoJ8", "The Smiths"),
+					Album: refind.Album{
+						ID:          "5Y0p2XCgRRIjna91aQE8q7",
+						Name:        "The Queen Is Dead",
+						ReleaseDate: time.Date(1986, 6, 16, 0, 0, 0, 0, time.UTC),
+						Images: []refind.Image{
+							{URL: "https://i.scdn.co/image/f3b2f5b4c0c9e8b1a5e2d4c3b6a7f8e9d0c1b2a3", Width: 640, Height: 640},
+							{URL: "https://i.scdn.co/image/a1b2c3d4e5f60718293a4b5c6d7e8f9012345678", Width: 300, Height: 300},
+							{URL: "https://i.scdn.co/image/0f1e2d3c4b5a69788796a5b4c3d2e1f0a9b8c7d6", Width: 64, Height: 64},
+						},
+					},
+					Duration:   244826 * time.Millisecond,
+					Popularity: 72,
+					ISRC:       "GBAHT1100131",
+					URI:        "spotify:track:0WQiDwKJclirSYG9v5tayI",
+					URLs:       testURLs("track", "0WQiDwKJclirSYG9v5tayI"),
+				},
+				{
+					ID:     "1hfFpTcqNVRPKVpqlXkZE8",
+					Name:   "Everyday Is Like Sunday",
+					Artist: testArtist("3iTsJGG39nMg9YiolUgLMQ", "Morrissey"),
+					Album: refind.Album{
+						ID:          "0dWaUJtp6vIuWvbfuk07Xc",
+						Name:        "Viva Hate",
+						ReleaseDate: time.Date(1988, 1, 1, 0, 0, 0, 0, time.UTC),
+						Images: []refind.Image{
+							{URL: "https://i.scdn.co/image/9e8d7c6b5a4f3e2d1c0b9a8f7e6d5c4b3a2f1e0d", Width: 640, Height: 640},
+							{URL: "https://i.scdn.co/image/1a2b3c4d5e6f7a8b9c0d1e2f3a4b5c6d7e8f9a0b", Width: 300, Height: 300},
+							{URL: "https://i.scdn.co/image/c0ffee00c0ffee00c0ffee00c0ffee00c0ffee00", Width: 64, Height: 64},
+						},
+					},
+					Duration:   214000 * time.Millisecond,
+					Popularity: 58,
+					ISRC:       "GBAYE8800005",
+					URI:        "spotify:track:1hfFpTcqNVRPKVpqlXkZE8",
+					URLs:       testURLs("track", "1hfFpTcqNVRPKVpqlXkZE8"),
+				},
+				{
+					ID:     "3eGCBXu58ISDL6OJdLKKSe",
+					Name:   "Just Like Heaven",
+					Artist: testArtist("7bu3H8JO7d0UbMoVzbo70s", "The Cure"),
+					Album: refind.Album{
+						ID:          "3yX2x9m0JmJM7bnqT9Akpm",
+						Name:        "Kiss Me, Kiss Me, Kiss Me",
+						ReleaseDate: time.Date(1987, 5, 1, 0, 0, 0, 0, time.UTC),
+						Images: []refind.Image{
+							{URL: "https://i.scdn.co/image/b0b0b0b0a1a1a1a1c2c2c2c2d3d3d3d3e4e4e4e4", Width: 640, Height: 640},
+							{URL: "https://i.scdn.co/image/f5f5f5f5a6a6a6a6b7b7b7b7c8c8c8c8d9d9d9d9", Width: 300, Height: 300},
+							{URL: "https://i.scdn.co/image/1234567890abcdef1234567890abcdef12345678", Width: 64, Height: 64},
+						},
+					},
+					Duration:   212560 * time.Millisecond,
+					Explicit:   true,
+					Popularity: 71,
+					ISRC:       "GBALB8700012",
+					URI:        "spotify:track:3eGCBXu58ISDL6OJdLKKSe",
+					URLs:       testURLs("track", "3eGCBXu58ISDL6OJdLKKSe"),
+				},
+			},
+			wantErr: nil,
+		},
+		{
+			name: "Valid data, error",
+			trk: fakeTracker{
+				file: testFileTopTracks,
+				err:  testErrNoData,
+			},
+			r:          refind.ShortTerm,
+			limit:      10,
+			wantTracks: nil,
+			wantErr:    testErrNoData,
+		},
+		{
+			name: "No data, nil error",
+			trk: fakeTracker{
+				file: testFileEmpty,
+				err:  nil,
+			},
+			r:          refind.ShortTerm,
+			limit:      10,
+			wantTracks: nil,
+			wantErr:    refind.ErrDataInvalid,
+		},
+		{
+			name: "Limit out of range",
+			trk: fakeTracker{
+				file: testFileTopTracks,
+				err:  nil,
+			},
+			r:          refind.ShortTerm,
+			limit:      -1,
+			wantTracks: nil,
+			wantErr:    refind.ErrRangeInvalid,
+		},
+		{
+			name: "Unexpected time range",
+			trk: fakeTracker{
+				file: testFileTopTracks,
+				err:  nil,
+			},
+			r:          99,
+			limit:      10,
+			wantTracks: nil,
+			wantErr:    errTimeRange,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			serv := service{trk: test.trk}
+
+			got, err := serv.TopTracksRange(test.r, test.limit)
+			if errors.Cause(err) != test.wantErr {
+				t.Errorf("got: <%v>, want: <%v>", errors.Cause(err), test.wantErr)
+			}
+
+			if !reflect.DeepEqual(got, test.wantTracks) {
+				t.Errorf("\ngot:  <%v>, \nwant: <%v>", got, test.wantTracks)
+			}
+		})
+	}
+}
+
 type fakeRecenter struct {
 	file string
 	err  error
@@ -236,16 +825,88 @@ func TestService_RecentTracks(t *testing.T) {
 				err:  nil,
 			},
 			wantTracks: []refind.Track{
-				{ID: "5ETM3aBrDf45TWg9AgnWQD", Name: "Black Nostaljack AKA Come On", Artist: refind.Artist{ID: "4oLZx5FplbgfM8DEe9U8LB", Name: "Camp Lo"}},
-				{ID: "1s92LwFTivD2f9o0s2hb78", Name: "Naturally Born", Artist: refind.Artist{ID: "099tLNCZZvtjC7myKD0mFp", Name: "Kool G Rap"}},
-				{ID: "0FcAIIz4Ti87cFBwyD3iCE", Name: "Little Darlin Seize the Sun", Artist: refind.Artist{ID: "4CMC2nnStv4EENjKBSDpKR", Name: "Christina Vantzou"}},
-				{ID: "0brnyKRZKnNngbH444p8cn", Name: "Prince of the Sea", Artist: refind.Artist{ID: "4G1ZsxfEEztbE1VcnNInPg", Name: "Chihei Hatakeyama"}},
-				{ID: "5nP1e5QSwT07XR2zpTVJGc", Name: "Ninteen Seventy Something", Artist: refind.Artist{ID: "1wo9h8DP7M0M1orKuGZgWv", Name: "Masta Ace"}},
-				{ID: "53aUYPTwJe6YrbSs8lQCEF", Name: "Buck Em Down", Artist: refind.Artist{ID: "2yN6bq26wynQcRuPkBYTDb", Name: "Black Moon"}},
-				{ID: "1qKsRg2PvBzhWkMOpanQq3", Name: "Hiatus", Artist: refind.Artist{ID: "6AdRO941ZEDh4GHcCUdEs4", Name: "Rafael Anton Irisarri"}},
-				{ID: "6qK7CuehGu2DVwL8UgaEhV", Name: "Days - Remastered", Artist: refind.Artist{ID: "0S7Zur2g8YhqlzqtlYStli", Name: "Television"}},
-				{ID: "2kL584Ddb8dVjAbga456kZ", Name: "Bells Bleed & Bloom", Artist: refind.Artist{ID: "4K7elTMrmeEYTE9w1zGP5e", Name: "ef"}},
-				{ID: "6XGLiFTNkatlSjGimT0tGU", Name: "Omens And Portents 1: The Driver", Artist: refind.Artist{ID: "4mTFQE6aiehScgvreB9llC", Name: "Earth"}},
+				{
+					ID:       "5ETM3aBrDf45TWg9AgnWQD",
+					Name:     "Black Nostaljack AKA Come On",
+					Artist:   testArtist("4oLZx5FplbgfM8DEe9U8LB", "Camp Lo"),
+					Duration: 251960 * time.Millisecond,
+					URI:      "spotify:track:5ETM3aBrDf45TWg9AgnWQD",
+					URLs:     testURLs("track", "5ETM3aBrDf45TWg9AgnWQD"),
+				},
+				{
+					ID:       "1s92LwFTivD2f9o0s2hb78",
+					Name:     "Naturally Born",
+					Artist:   testArtist("099tLNCZZvtjC7myKD0mFp", "Kool G Rap"),
+					Duration: 264537 * time.Millisecond,
+					Explicit: true,
+					URI:      "spotify:track:1s92LwFTivD2f9o0s2hb78",
+					URLs:     testURLs("track", "1s92LwFTivD2f9o0s2hb78"),
+				},
+				{
+					ID:       "0FcAIIz4Ti87cFBwyD3iCE",
+					Name:     "Little Darlin Seize the Sun",
+					Artist:   testArtist("4CMC2nnStv4EENjKBSDpKR", "Christina Vantzou"),
+					Duration: 122680 * time.Millisecond,
+					URI:      "spotify:track:0FcAIIz4Ti87cFBwyD3iCE",
+					URLs:     testURLs("track", "0FcAIIz4Ti87cFBwyD3iCE"),
+				},
+				{
+					ID:       "0brnyKRZKnNngbH444p8cn",
+					Name:     "Prince of the Sea",
+					Artist:   testArtist("4G1ZsxfEEztbE1VcnNInPg", "Chihei Hatakeyama"),
+					Duration: 459635 * time.Millisecond,
+					URI:      "spotify:track:0brnyKRZKnNngbH444p8cn",
+					URLs:     testURLs("track", "0brnyKRZKnNngbH444p8cn"),
+				},
+				{
+					ID:       "5nP1e5QSwT07XR2zpTVJGc",
+					Name:     "Ninteen Seventy Something",
+					Artist:   testArtist("1wo9h8DP7M0M1orKuGZgWv", "Masta Ace"),
+					Duration: 172506 * time.Millisecond,
+					URI:      "spotify:track:5nP1e5QSwT07XR2zpTVJGc",
+					URLs:     testURLs("track", "5nP1e5QSwT07XR2zpTVJGc"),
+				},
+				{
+					ID:       "53aUYPTwJe6YrbSs8lQCEF",
+					Name:     "Buck Em Down",
+					Artist:   testArtist("2yN6bq26wynQcRuPkBYTDb", "Black Moon"),
+					Duration: 279106 * time.Millisecond,
+					Explicit: true,
+					URI:      "spotify:track:53aUYPTwJe6YrbSs8lQCEF",
+					URLs:     testURLs("track", "53aUYPTwJe6YrbSs8lQCEF"),
+				},
+				{
+					ID:       "1qKsRg2PvBzhWkMOpanQq3",
+					Name:     "Hiatus",
+					Artist:   testArtist("6AdRO941ZEDh4GHcCUdEs4", "Rafael Anton Irisarri"),
+					Duration: 158506 * time.Millisecond,
+					URI:      "spotify:track:1qKsRg2PvBzhWkMOpanQq3",
+					URLs:     testURLs("track", "1qKsRg2PvBzhWkMOpanQq3"),
+				},
+				{
+					ID:       "6qK7CuehGu2DVwL8UgaEhV",
+					Name:     "Days - Remastered",
+					Artist:   testArtist("0S7Zur2g8YhqlzqtlYStli", "Television"),
+					Duration: 194320 * time.Millisecond,
+					URI:      "spotify:track:6qK7CuehGu2DVwL8UgaEhV",
+					URLs:     testURLs("track", "6qK7CuehGu2DVwL8UgaEhV"),
+				},
+				{
+					ID:       "2kL584Ddb8dVjAbga456kZ",
+					Name:     "Bells Bleed & Bloom",
+					Artist:   testArtist("4K7elTMrmeEYTE9w1zGP5e", "ef"),
+					Duration: 516255 * time.Millisecond,
+					URI:      "spotify:track:2kL584Ddb8dVjAbga456kZ",
+					URLs:     testURLs("track", "2kL584Ddb8dVjAbga456kZ"),
+				},
+				{
+					ID:       "6XGLiFTNkatlSjGimT0tGU",
+					Name:     "Omens And Portents 1: The Driver",
+					Artist:   testArtist("4mTFQE6aiehScgvreB9llC", "Earth"),
+					Duration: 547426 * time.Millisecond,
+					URI:      "spotify:track:6XGLiFTNkatlSjGimT0tGU",
+					URLs:     testURLs("track", "6XGLiFTNkatlSjGimT0tGU"),
+				},
 			},
 			wantErr: nil,
 		},
@@ -265,7 +926,7 @@ func TestService_RecentTracks(t *testing.T) {
 				err:  nil,
 			},
 			wantTracks: nil,
-			wantErr:    errDataInvalid,
+			wantErr:    refind.ErrNoHistory,
 		},
 		{
 			name: "No data, error",
@@ -314,11 +975,22 @@ func (f fakeRecommender) GetRecommendations(sds spotify.Seeds, attr *spotify.Tra
 
 const testTotal int = 30
 
+var testProvenance = &refind.Provenance{
+	Group:   0,
+	Tracks:  []string{"0c6xIDDpzE81m2q797ordA"},
+	Artists: []string{"4NHQUGzhtTLFvgF5SZesLK"},
+	Genres:  []string{"classical", "country"},
+	Attributes: map[string]float64{
+		"target_popularity": 40,
+		"max_popularity":    50,
+	},
+}
+
 func TestService_Recommendations(t *testing.T) {
 	tests := []struct {
 		name       string
 		recom      recommender
-		total int
+		total      int
 		sds        []refind.Seed
 		wantTracks []refind.Track
 		wantErr    error
@@ -337,16 +1009,96 @@ func TestService_Recommendations(t *testing.T) {
 				{Category: refind.GenreSeed, ID: "country"},
 			},
 			wantTracks: []refind.Track{
-				{ID: "7cgi6lRggiLAAzsuJOBBeW", Name: "Innsbruck, ich muß dich lassen", Artist: refind.Artist{ID: "1G6jUCigH2z7oGk7jm6OhS", Name: "Heinrich Isaac"}},
-				{ID: "0T02WlrUAK45ApAVVixmcc", Name: "La Bohème / Act 1: \"Che gelida manina\"", Artist: refind.Artist{ID: "0OzxPXyowUEQ532c9AmHUR", Name: "Giacomo Puccini"}},
-				{ID: "0GaVkII433PqC4EkMSjWEV", Name: "Moments - Seeb Remix", Artist: refind.Artist{ID: "4NHQUGzhtTLFvgF5SZesLK", Name: "Tove Lo"}},
-				{ID: "1H9rGpQ1Xqh45Y13mzfJvU", Name: "Clarinet Concerto in B-Flat Major (reconstructed R. Meylan): I. Andante sostenuto", Artist: refind.Artist{ID: "2jCGEMSZXMSOImpD8sqo56", Name: "Gaetano Donizetti"}},
-				{ID: "4BNUJM7oEYNPtXDzvZjcRQ", Name: "The Scene", Artist: refind.Artist{ID: "4FJPplt1JOVw8Q7NiwFmLv", Name: "Friend Within"}},
-				{ID: "3lO38SiB2WAQRqTAHN7WTC", Name: "Borderline - Vanic Remix", Artist: refind.Artist{ID: "2QSPrJfYeRXaltEEiriXN9", Name: "Tove Styrke"}},
-				{ID: "6zzZPhrTwS84pOkuqCwI5B", Name: "I Didn’t Just Come Here To Dance", Artist: refind.Artist{ID: "6sFIWsNpZYqfjUpaCgueju", Name: "Carly Rae Jepsen"}},
-				{ID: "4dGJf1SER1T6ooX46vwzRB", Name: "Chicken Fried", Artist: refind.Artist{ID: "6yJCxee7QumYr820xdIsjo", Name: "Zac Brown Band"}},
-				{ID: "25I4pBnup7EeerWd61G61i", Name: "Timebomb", Artist: refind.Artist{ID: "4NHQUGzhtTLFvgF5SZesLK", Name: "Tove Lo"}},
-				{ID: "2URjwQulkDiDmFdjSPrcSc", Name: "Appalachian Spring: Moderato - Coda", Artist: refind.Artist{ID: "0nJvyjVTb8sAULPYyA1bqU", Name: "Aaron Copland"}},
+				{
+					ID:         "7cgi6lRggiLAAzsuJOBBeW",
+					Name:       "Innsbruck, ich muß dich lassen",
+					Artist:     testArtist("1G6jUCigH2z7oGk7jm6OhS", "Heinrich Isaac"),
+					Duration:   165786 * time.Millisecond,
+					URI:        "spotify:track:7cgi6lRggiLAAzsuJOBBeW",
+					URLs:       testURLs("track", "7cgi6lRggiLAAzsuJOBBeW"),
+					Provenance: testProvenance,
+				},
+				{
+					ID:         "0T02WlrUAK45ApAVVixmcc",
+					Name:       "La Bohème / Act 1: \"Che gelida manina\"",
+					Artist:     testArtist("0OzxPXyowUEQ532c9AmHUR", "Giacomo Puccini"),
+					Duration:   311973 * time.Millisecond,
+					URI:        "spotify:track:0T02WlrUAK45ApAVVixmcc",
+					URLs:       testURLs("track", "0T02WlrUAK45ApAVVixmcc"),
+					Provenance: testProvenance,
+				},
+				{
+					ID:         "0GaVkII433PqC4EkMSjWEV",
+					Name:       "Moments - Seeb Remix",
+					Artist:     testArtist("4NHQUGzhtTLFvgF5SZesLK", "Tove Lo"),
+					Duration:   178947 * time.Millisecond,
+					URI:        "spotify:track:0GaVkII433PqC4EkMSjWEV",
+					URLs:       testURLs("track", "0GaVkII433PqC4EkMSjWEV"),
+					Provenance: testProvenance,
+				},
+				{
+					ID:         "1H9rGpQ1Xqh45Y13mzfJvU",
+					Name:       "Clarinet Concerto in B-Flat Major (reconstructed R. Meylan): I. Andante sostenuto",
+					Artist:     testArtist("2jCGEMSZXMSOImpD8sqo56", "Gaetano Donizetti"),
+					Duration:   250000 * time.Millisecond,
+					URI:        "spotify:track:1H9rGpQ1Xqh45Y13mzfJvU",
+					URLs:       testURLs("track", "1H9rGpQ1Xqh45Y13mzfJvU"),
+					Provenance: testProvenance,
+				},
+				{
+					ID:         "4BNUJM7oEYNPtXDzvZjcRQ",
+					Name:       "The Scene",
+					Artist:     testArtist("4FJPplt1JOVw8Q7NiwFmLv", "Friend Within"),
+					Duration:   394901 * time.Millisecond,
+					URI:        "spotify:track:4BNUJM7oEYNPtXDzvZjcRQ",
+					URLs:       testURLs("track", "4BNUJM7oEYNPtXDzvZjcRQ"),
+					Provenance: testProvenance,
+				},
+				{
+					ID:         "3lO38SiB2WAQRqTAHN7WTC",
+					Name:       "Borderline - Vanic Remix",
+					Artist:     testArtist("2QSPrJfYeRXaltEEiriXN9", "Tove Styrke"),
+					Duration:   256500 * time.Millisecond,
+					URI:        "spotify:track:3lO38SiB2WAQRqTAHN7WTC",
+					URLs:       testURLs("track", "3lO38SiB2WAQRqTAHN7WTC"),
+					Provenance: testProvenance,
+				},
+				{
+					ID:         "6zzZPhrTwS84pOkuqCwI5B",
+					Name:       "I Didn’t Just Come Here To Dance",
+					Artist:     testArtist("6sFIWsNpZYqfjUpaCgueju", "Carly Rae Jepsen"),
+					Duration:   219892 * time.Millisecond,
+					URI:        "spotify:track:6zzZPhrTwS84pOkuqCwI5B",
+					URLs:       testURLs("track", "6zzZPhrTwS84pOkuqCwI5B"),
+					Provenance: testProvenance,
+				},
+				{
+					ID:         "4dGJf1SER1T6ooX46vwzRB",
+					Name:       "Chicken Fried",
+					Artist:     testArtist("6yJCxee7QumYr820xdIsjo", "Zac Brown Band"),
+					Duration:   238146 * time.Millisecond,
+					URI:        "spotify:track:4dGJf1SER1T6ooX46vwzRB",
+					URLs:       testURLs("track", "4dGJf1SER1T6ooX46vwzRB"),
+					Provenance: testProvenance,
+				},
+				{
+					ID:         "25I4pBnup7EeerWd61G61i",
+					Name:       "Timebomb",
+					Artist:     testArtist("4NHQUGzhtTLFvgF5SZesLK", "Tove Lo"),
+					Duration:   214866 * time.Millisecond,
+					URI:        "spotify:track:25I4pBnup7EeerWd61G61i",
+					URLs:       testURLs("track", "25I4pBnup7EeerWd61G61i"),
+					Provenance: testProvenance,
+				},
+				{
+					ID:         "2URjwQulkDiDmFdjSPrcSc",
+					Name:       "Appalachian Spring: Moderato - Coda",
+					Artist:     testArtist("0nJvyjVTb8sAULPYyA1bqU", "Aaron Copland"),
+					Duration:   205533 * time.Millisecond,
+					URI:        "spotify:track:2URjwQulkDiDmFdjSPrcSc",
+					URLs:       testURLs("track", "2URjwQulkDiDmFdjSPrcSc"),
+					Provenance: testProvenance,
+				},
 			},
 			wantErr: nil,
 		},
@@ -372,10 +1124,10 @@ func TestService_Recommendations(t *testing.T) {
 				file: testFileRecommendations,
 				err:  nil,
 			},
-			total: testTotal,
+			total:      testTotal,
 			sds:        nil,
 			wantTracks: nil,
-			wantErr:    errSeedsMissing,
+			wantErr:    refind.ErrSeedsMissing,
 		},
 		{
 			name: "Valid data, valid total, no seeds, error",
@@ -383,10 +1135,10 @@ func TestService_Recommendations(t *testing.T) {
 				file: testFileRecommendations,
 				err:  testErrNoData,
 			},
-			total: testTotal,
+			total:      testTotal,
 			sds:        nil,
 			wantTracks: nil,
-			wantErr:    errSeedsMissing,
+			wantErr:    refind.ErrSeedsMissing,
 		},
 		{
 			name: "Valid data, valid total, invalid seed ID, nil error",
@@ -454,7 +1206,7 @@ func TestService_Recommendations(t *testing.T) {
 				{Category: refind.GenreSeed, ID: "country"},
 			},
 			wantTracks: nil,
-			wantErr:    errRangeInvalid,
+			wantErr:    refind.ErrRangeInvalid,
 		},
 		{
 			name: "Valid data, invalid total, valid seeds, error",
@@ -470,7 +1222,7 @@ func TestService_Recommendations(t *testing.T) {
 				{Category: refind.GenreSeed, ID: "country"},
 			},
 			wantTracks: nil,
-			wantErr:    errRangeInvalid,
+			wantErr:    refind.ErrRangeInvalid,
 		},
 		{
 			name: "No data, valid total, valid seeds, nil error",
@@ -486,7 +1238,7 @@ func TestService_Recommendations(t *testing.T) {
 				{Category: refind.GenreSeed, ID: "country"},
 			},
 			wantTracks: nil,
-			wantErr:    errDataInvalid,
+			wantErr:    refind.ErrDataInvalid,
 		},
 		{
 			name: "No data, valid total, valid seeds, error",
@@ -510,10 +1262,10 @@ func TestService_Recommendations(t *testing.T) {
 				file: testFileEmpty,
 				err:  nil,
 			},
-			total: testTotal,
+			total:      testTotal,
 			sds:        nil,
 			wantTracks: nil,
-			wantErr:    errSeedsMissing,
+			wantErr:    refind.ErrSeedsMissing,
 		},
 		{
 			name: "No data, valid total, no seeds, error",
@@ -521,10 +1273,10 @@ func TestService_Recommendations(t *testing.T) {
 				file: testFileEmpty,
 				err:  testErrNoData,
 			},
-			total: testTotal,
+			total:      testTotal,
 			sds:        nil,
 			wantTracks: nil,
-			wantErr:    errSeedsMissing,
+			wantErr:    refind.ErrSeedsMissing,
 		},
 		{
 			name: "No data, valid total, invalid seed ID, nil error",
@@ -642,7 +1394,7 @@ var testFullPlaylist = &spotify.FullPlaylist{
 			"spotify": "http://open.spotify.com/user/someone/playlist/7I6yjOAxMq4qsvzgqxw7aU",
 		},
 		Endpoint: "https://api.spotify.com/v1/users/someone/playlists/7I6yjOAxMq4qsvzgqxw7aU?fields=fields=href,name,owner(!href,external_urls),tracks.items(added_by.id,track(name,href,album(name,href)))",
-		ID: "7I6yjOAxMq4qsvzgqxw7aU",
+		ID:       "7I6yjOAxMq4qsvzgqxw7aU",
 		Images: []spotify.Image{
 			{Height: 640, Width: 640, URL: "https://i.scdn.co/image/449156e8f2458c247ea9a668e498c598b159f12f"},
 		},
@@ -652,12 +1404,12 @@ var testFullPlaylist = &spotify.FullPlaylist{
 				"spotify": "http://open.spotify.com/user/someone",
 			},
 			Endpoint: "https://api.spotify.com/v1/users/someone",
-			ID: "someone",
-			URI: "spotify:user:someone",
+			ID:       "someone",
+			URI:      "spotify:user:someone",
 		},
-		IsPublic: true,
+		IsPublic:   true,
 		SnapshotID: "Yo+BthwRfySLE498r55BaKSJNw0/3ZDUzVYBcRxVtMReZ3joqyhIlBoMJKif2OWJ",
-		URI: "spotify:user:someone:playlist:7I6yjOAxMq4qsvzgqxw7aU",
+		URI:        "spotify:user:someone:playlist:7I6yjOAxMq4qsvzgqxw7aU",
 	},
 	Followers: spotify.Followers{},
 	Tracks: spotify.PlaylistTrackPage{
@@ -669,8 +1421,8 @@ var testFullPlaylist = &spotify.FullPlaylist{
 						"spotify": "http://open.spotify.com/user/someone",
 					},
 					Endpoint: "https://api.spotify.com/v1/users/someone",
-					ID: "someone",
-					URI: "spotify:user:someone",
+					ID:       "someone",
+					URI:      "spotify:user:someone",
 				},
 				Track: spotify.FullTrack{
 					SimpleTrack: spotify.SimpleTrack{
@@ -680,17 +1432,17 @@ var testFullPlaylist = &spotify.FullPlaylist{
 									"spotify": "https://open.spotify.com/artist/1Cq0LAHFfvUTBEtMPXUidI",
 								},
 								Endpoint: "https://api.spotify.com/v1/artists/1Cq0LAHFfvUTBEtMPXUidI",
-								ID: "1Cq0LAHFfvUTBEtMPXUidI",
-								Name: "O.A.R.",
-								URI: "spotify:artist:1Cq0LAHFfvUTBEtMPXUidI",
+								ID:       "1Cq0LAHFfvUTBEtMPXUidI",
+								Name:     "O.A.R.",
+								URI:      "spotify:artist:1Cq0LAHFfvUTBEtMPXUidI",
 							},
 						},
-						Endpoint: "https://api.spotify.com/v1/tracks/1l7E6PxXL78DscO7YEDSBc",
-						ID: "1l7E6PxXL78DscO7YEDSBc",
-						Name: "We'll Pick Up Where We Left Off",
-						PreviewURL: "https://p.scdn.co/mp3-preview/e107d8f17f036868d8389de406bae057bb609afa",
+						Endpoint:    "https://api.spotify.com/v1/tracks/1l7E6PxXL78DscO7YEDSBc",
+						ID:          "1l7E6PxXL78DscO7YEDSBc",
+						Name:        "We'll Pick Up Where We Left Off",
+						PreviewURL:  "https://p.scdn.co/mp3-preview/e107d8f17f036868d8389de406bae057bb609afa",
 						TrackNumber: 2,
-						URI: "spotify:track:1l7E6PxXL78DscO7YEDSBc",
+						URI:         "spotify:track:1l7E6PxXL78DscO7YEDSBc",
 					},
 					Popularity: 43,
 				},
@@ -699,21 +1451,48 @@ var testFullPlaylist = &spotify.FullPlaylist{
 	},
 }
 
-func TestService_Playlist(t *testing.T) {
+func TestService_UserID(t *testing.T) {
 	tests := []struct {
-		name     string
-		play     playlister
-		tracks []refind.Track
-		wantPlaylist *spotify.FullPlaylist
-		wantErr  error
+		name    string
+		play    playlister
+		wantID  string
+		wantErr error
+	}{
+		{"Valid user with nil error", fakePlaylister{userFile: testFileCurrentUser}, "someone", nil},
+		{"Valid user with error", fakePlaylister{userFile: testFileCurrentUser, userErr: testErrNoData}, "", testErrNoData},
+		{"No user with nil error", fakePlaylister{userFile: testFileEmpty}, "", refind.ErrDataInvalid},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			serv := service{play: test.play}
+
+			got, err := serv.UserID()
+			if errors.Cause(err) != test.wantErr {
+				t.Errorf("got: <%v>, want: <%v>", errors.Cause(err), test.wantErr)
+			}
+
+			if got != test.wantID {
+				t.Errorf("got: <%v>, want: <%v>", got, test.wantID)
+			}
+		})
+	}
+}
+
+func TestService_Playlist(t *testing.T) {
+	tests := []struct {
+		name         string
+		play         playlister
+		tracks       []refind.Track
+		wantPlaylist *spotify.FullPlaylist
+		wantErr      error
 	}{
 		{
 			name: "Valid user with nil error, valid playlist with nil error, valid tracks with nil error",
 			play: fakePlaylister{
-				userFile: testFileCurrentUser,
-				userErr: nil,
+				userFile:     testFileCurrentUser,
+				userErr:      nil,
 				playlistFile: testFileCreatePlaylist,
-				playlistErr: nil,
+				playlistErr:  nil,
 				addTracksErr: nil,
 			},
 			tracks: []refind.Track{
@@ -727,10 +1506,10 @@ func TestService_Playlist(t *testing.T) {
 		{
 			name: "Valid user with error, valid playlist with nil error, valid tracks with nil error",
 			play: fakePlaylister{
-				userFile: testFileCurrentUser,
-				userErr: testErrNoData,
+				userFile:     testFileCurrentUser,
+				userErr:      testErrNoData,
 				playlistFile: testFileCreatePlaylist,
-				playlistErr: nil,
+				playlistErr:  nil,
 				addTracksErr: nil,
 			},
 			tracks: []refind.Track{
@@ -739,15 +1518,15 @@ func TestService_Playlist(t *testing.T) {
 				{ID: "6XGLiFTNkatlSjGimT0tGU", Name: "Omens And Portents 1: The Driver", Artist: refind.Artist{ID: "4mTFQE6aiehScgvreB9llC", Name: "Earth"}},
 			},
 			wantPlaylist: nil,
-			wantErr: testErrNoData,
+			wantErr:      testErrNoData,
 		},
 		{
 			name: "No user with nil error, valid playlist with nil error, valid tracks with nil error",
 			play: fakePlaylister{
-				userFile: testFileEmpty,
-				userErr: nil,
+				userFile:     testFileEmpty,
+				userErr:      nil,
 				playlistFile: testFileCreatePlaylist,
-				playlistErr: nil,
+				playlistErr:  nil,
 				addTracksErr: nil,
 			},
 			tracks: []refind.Track{
@@ -756,15 +1535,15 @@ func TestService_Playlist(t *testing.T) {
 				{ID: "6XGLiFTNkatlSjGimT0tGU", Name: "Omens And Portents 1: The Driver", Artist: refind.Artist{ID: "4mTFQE6aiehScgvreB9llC", Name: "Earth"}},
 			},
 			wantPlaylist: nil,
-			wantErr: errDataInvalid,
+			wantErr:      refind.ErrDataInvalid,
 		},
 		{
 			name: "No user with error, valid playlist with nil error, valid tracks with nil error",
 			play: fakePlaylister{
-				userFile: testFileEmpty,
-				userErr: testErrNoData,
+				userFile:     testFileEmpty,
+				userErr:      testErrNoData,
 				playlistFile: testFileCreatePlaylist,
-				playlistErr: nil,
+				playlistErr:  nil,
 				addTracksErr: nil,
 			},
 			tracks: []refind.Track{
@@ -773,15 +1552,15 @@ func TestService_Playlist(t *testing.T) {
 				{ID: "6XGLiFTNkatlSjGimT0tGU", Name: "Omens And Portents 1: The Driver", Artist: refind.Artist{ID: "4mTFQE6aiehScgvreB9llC", Name: "Earth"}},
 			},
 			wantPlaylist: nil,
-			wantErr: testErrNoData,
+			wantErr:      testErrNoData,
 		},
 		{
 			name: "Valid user with nil error, valid playlist with error, valid tracks with nil error",
 			play: fakePlaylister{
-				userFile: testFileCurrentUser,
-				userErr: nil,
+				userFile:     testFileCurrentUser,
+				userErr:      nil,
 				playlistFile: testFileCreatePlaylist,
-				playlistErr: testErrNoData,
+				playlistErr:  testErrNoData,
 				addTracksErr: nil,
 			},
 			tracks: []refind.Track{
@@ -795,10 +1574,10 @@ func TestService_Playlist(t *testing.T) {
 		{
 			name: "Valid user with nil error, no playlist with nil error, valid tracks with nil error",
 			play: fakePlaylister{
-				userFile: testFileCurrentUser,
-				userErr: nil,
+				userFile:     testFileCurrentUser,
+				userErr:      nil,
 				playlistFile: testFileEmpty,
-				playlistErr: nil,
+				playlistErr:  nil,
 				addTracksErr: nil,
 			},
 			tracks: []refind.Track{
@@ -807,15 +1586,15 @@ func TestService_Playlist(t *testing.T) {
 				{ID: "6XGLiFTNkatlSjGimT0tGU", Name: "Omens And Portents 1: The Driver", Artist: refind.Artist{ID: "4mTFQE6aiehScgvreB9llC", Name: "Earth"}},
 			},
 			wantPlaylist: nil,
-			wantErr: errDataInvalid,
+			wantErr:      refind.ErrDataInvalid,
 		},
 		{
 			name: "Valid user with nil error, no playlist with error, valid tracks with nil error",
 			play: fakePlaylister{
-				userFile: testFileCurrentUser,
-				userErr: nil,
+				userFile:     testFileCurrentUser,
+				userErr:      nil,
 				playlistFile: testFileEmpty,
-				playlistErr: testErrNoData,
+				playlistErr:  testErrNoData,
 				addTracksErr: nil,
 			},
 			tracks: []refind.Track{
@@ -824,15 +1603,15 @@ func TestService_Playlist(t *testing.T) {
 				{ID: "6XGLiFTNkatlSjGimT0tGU", Name: "Omens And Portents 1: The Driver", Artist: refind.Artist{ID: "4mTFQE6aiehScgvreB9llC", Name: "Earth"}},
 			},
 			wantPlaylist: nil,
-			wantErr: testErrNoData,
+			wantErr:      testErrNoData,
 		},
 		{
 			name: "Valid user with nil error, valid playlist with nil error, valid tracks with error",
 			play: fakePlaylister{
-				userFile: testFileCurrentUser,
-				userErr: nil,
+				userFile:     testFileCurrentUser,
+				userErr:      nil,
 				playlistFile: testFileCreatePlaylist,
-				playlistErr: nil,
+				playlistErr:  nil,
 				addTracksErr: testErrNoData,
 			},
 			tracks: []refind.Track{
@@ -846,26 +1625,26 @@ func TestService_Playlist(t *testing.T) {
 		{
 			name: "Valid user with nil error, valid playlist with nil error, no tracks with nil error",
 			play: fakePlaylister{
-				userFile: testFileCurrentUser,
-				userErr: nil,
+				userFile:     testFileCurrentUser,
+				userErr:      nil,
 				playlistFile: testFileCreatePlaylist,
-				playlistErr: nil,
+				playlistErr:  nil,
 				addTracksErr: nil,
 			},
-			tracks: nil,
+			tracks:       nil,
 			wantPlaylist: nil,
 			wantErr:      errTracksMissing,
 		},
 		{
 			name: "Valid user with nil error, valid playlist with nil error, no tracks with error",
 			play: fakePlaylister{
-				userFile: testFileCurrentUser,
-				userErr: nil,
+				userFile:     testFileCurrentUser,
+				userErr:      nil,
 				playlistFile: testFileCreatePlaylist,
-				playlistErr: nil,
+				playlistErr:  nil,
 				addTracksErr: testErrNoData,
 			},
-			tracks: nil,
+			tracks:       nil,
 			wantPlaylist: nil,
 			wantErr:      errTracksMissing,
 		},
@@ -885,3 +1664,1156 @@ func TestService_Playlist(t *testing.T) {
 		})
 	}
 }
+
+type fakeSearcher struct {
+	file string
+	err  error
+}
+
+func (f fakeSearcher) SearchOpt(string, spotify.SearchType, *spotify.Options) (*spotify.SearchResult, error) {
+	b, err := ioutil.ReadFile(f.file)
+	if err != nil {
+		return nil, err
+	}
+
+	var res *spotify.SearchResult
+	if err := json.Unmarshal(b, &res); err != nil {
+		return nil, f.err
+	}
+
+	return res, f.err
+}
+
+var testReckoner = refind.Track{
+	ID:     "6LgJvl0Xdtc73RJ1mmpotq",
+	Name:   "Reckoner",
+	Artist: testArtist("4Z8W4fKeB5YxbusRsdQVPb", "Radiohead"),
+	Album: refind.Album{
+		ID:          "7eyQXxuf2nGj9d2367Gi5f",
+		Name:        "In Rainbows",
+		ReleaseDate: time.Date(2007, 12, 28, 0, 0, 0, 0, time.UTC),
+		Images: []refind.Image{
+			{URL: "https://i.scdn.co/image/de3c04b5fc750b68899b20a7a6e8e6f4d9b3f5c1", Width: 640, Height: 640},
+			{URL: "https://i.scdn.co/image/6a8c3b1e9a2d27ba1ef8d0dfd4c3bde2a6a0e1f2", Width: 300, Height: 300},
+			{URL: "https://i.scdn.co/image/2f2d3e3b2b0e5aa4c8a6c2b1ec0d1b8b9f8e7d6c", Width: 64, Height: 64},
+		},
+	},
+	Duration:   290213 * time.Millisecond,
+	Popularity: 66,
+	ISRC:       "GBSTK0700057",
+	URI:        "spotify:track:6LgJvl0Xdtc73RJ1mmpotq",
+	URLs:       testURLs("track", "6LgJvl0Xdtc73RJ1mmpotq"),
+}
+
+func TestService_Search(t *testing.T) {
+	tests := []struct {
+		name       string
+		srch       searcher
+		track      refind.Track
+		wantTracks []refind.Track
+		wantErr    error
+	}{
+		{
+			name: "Valid data, nil error",
+			srch: fakeSearcher{
+				file: testFileSearchTracks,
+				err:  nil,
+			},
+			track:      refind.Track{Name: "Reckoner", Artist: refind.Artist{Name: "Radiohead"}, ISRC: "GBSTK0700057"},
+			wantTracks: []refind.Track{testReckoner},
+			wantErr:    nil,
+		},
+		{
+			name: "Valid data, error",
+			srch: fakeSearcher{
+				file: testFileSearchTracks,
+				err:  testErrNoData,
+			},
+			track:      refind.Track{Name: "Reckoner", Artist: refind.Artist{Name: "Radiohead"}},
+			wantTracks: nil,
+			wantErr:    testErrNoData,
+		},
+		{
+			name: "No data, nil error",
+			srch: fakeSearcher{
+				file: testFileEmpty,
+				err:  nil,
+			},
+			track:      refind.Track{Name: "Reckoner", Artist: refind.Artist{Name: "Radiohead"}},
+			wantTracks: nil,
+			wantErr:    refind.ErrDataInvalid,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			serv := service{srch: test.srch}
+
+			got, err := serv.Search(test.track)
+			if errors.Cause(err) != test.wantErr {
+				t.Errorf("got: <%v>, want: <%v>", errors.Cause(err), test.wantErr)
+			}
+
+			if !reflect.DeepEqual(got, test.wantTracks) {
+				t.Errorf("\ngot:  <%v>, \nwant: <%v>", got, test.wantTracks)
+			}
+		})
+	}
+}
+
+type recordingPlaylister struct {
+	fakePlaylister
+	added *[]spotify.ID
+}
+
+func (r recordingPlaylister) AddTracksToPlaylist(id spotify.ID, IDs ...spotify.ID) (string, error) {
+	*r.added = append(*r.added, IDs...)
+	return r.fakePlaylister.AddTracksToPlaylist(id, IDs...)
+}
+
+func TestService_MatchedPlaylist(t *testing.T) {
+	tests := []struct {
+		name        string
+		srch        searcher
+		tracks      []refind.Track
+		wantAdded   []spotify.ID
+		wantMissing []refind.Track
+		wantErr     error
+	}{
+		{
+			name: "Native and foreign tracks",
+			srch: fakeSearcher{
+				file: testFileSearchTracks,
+				err:  nil,
+			},
+			tracks: []refind.Track{
+				{ID: "6qK7CuehGu2DVwL8UgaEhV", Name: "Days - Remastered", URI: "spotify:track:6qK7CuehGu2DVwL8UgaEhV"},
+				{ID: "lastfm-1", Name: "Reckoner", Artist: refind.Artist{Name: "Radiohead"}, Duration: 290 * time.Second},
+				{ID: "lastfm-2", Name: "Omens And Portents 1: The Driver", Artist: refind.Artist{Name: "Earth"}},
+			},
+			wantAdded: []spotify.ID{"6qK7CuehGu2DVwL8UgaEhV", "6LgJvl0Xdtc73RJ1mmpotq"},
+			wantMissing: []refind.Track{
+				{ID: "lastfm-2", Name: "Omens And Portents 1: The Driver", Artist: refind.Artist{Name: "Earth"}},
+			},
+			wantErr: nil,
+		},
+		{
+			name: "Search error",
+			srch: fakeSearcher{
+				file: testFileSearchTracks,
+				err:  testErrNoData,
+			},
+			tracks: []refind.Track{
+				{ID: "lastfm-1", Name: "Reckoner", Artist: refind.Artist{Name: "Radiohead"}},
+			},
+			wantAdded:   nil,
+			wantMissing: nil,
+			wantErr:     testErrNoData,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var added []spotify.ID
+			play := recordingPlaylister{
+				fakePlaylister: fakePlaylister{
+					userFile:     testFileCurrentUser,
+					playlistFile: testFileCreatePlaylist,
+				},
+				added: &added,
+			}
+			serv := service{srch: test.srch, play: play}
+
+			_, missing, err := serv.MatchedPlaylist(test.name, "info", test.tracks)
+			if errors.Cause(err) != test.wantErr {
+				t.Errorf("got: <%v>, want: <%v>", errors.Cause(err), test.wantErr)
+			}
+
+			if !reflect.DeepEqual(added, test.wantAdded) {
+				t.Errorf("got: <%v>, want: <%v>", added, test.wantAdded)
+			}
+
+			if !reflect.DeepEqual(missing, test.wantMissing) {
+				t.Errorf("got: <%v>, want: <%v>", missing, test.wantMissing)
+			}
+		})
+	}
+}
+
+type fakeFeaturer struct {
+	file    string
+	err     error
+	batches *[]int
+}
+
+func (f fakeFeaturer) GetAudioFeatures(ids ...spotify.ID) ([]*spotify.AudioFeatures, error) {
+	if f.batches != nil {
+		*f.batches = append(*f.batches, len(ids))
+	}
+
+	if f.err != nil {
+		return nil, f.err
+	}
+
+	b, err := ioutil.ReadFile(f.file)
+	if err != nil {
+		return nil, err
+	}
+
+	var res struct {
+		F []*spotify.AudioFeatures `json:"audio_features"`
+	}
+	if err := json.Unmarshal(b, &res); err != nil {
+		return nil, err
+	}
+
+	return res.F, nil
+}
+
+func TestService_AudioFeatures(t *testing.T) {
+	tests := []struct {
+		name        string
+		feat        fakeFeaturer
+		ids         int
+		wantFeats   map[string]refind.Features
+		wantBatches []int
+		wantErr     error
+	}{
+		{
+			name: "Happy path",
+			feat: fakeFeaturer{file: testFileAudioFeatures},
+			ids:  3,
+			wantFeats: map[string]refind.Features{
+				"6LgJvl0Xdtc73RJ1mmpotq": {
+					Acousticness:     float64(float32(0.465)),
+					Danceability:     float64(float32(0.54)),
+					Energy:           float64(float32(0.423)),
+					Instrumentalness: float64(float32(0.679)),
+					Liveness:         float64(float32(0.108)),
+					Loudness:         float64(float32(-8.93)),
+					Speechiness:      float64(float32(0.0289)),
+					Tempo:            float64(float32(105.877)),
+					Valence:          float64(float32(0.128)),
+					Key:              9,
+					Mode:             0,
+				},
+				"3PIDciSFdrQxSQSihim3hN": {
+					Acousticness:     float64(float32(0.00265)),
+					Danceability:     float64(float32(0.479)),
+					Energy:           float64(float32(0.89)),
+					Instrumentalness: float64(float32(0.0118)),
+					Liveness:         float64(float32(0.0954)),
+					Loudness:         float64(float32(-6.207)),
+					Speechiness:      float64(float32(0.0334)),
+					Tempo:            float64(float32(153.032)),
+					Valence:          float64(float32(0.699)),
+					Key:              4,
+					Mode:             1,
+				},
+			},
+			wantBatches: []int{3},
+			wantErr:     nil,
+		},
+		{
+			name:        "Batched requests",
+			feat:        fakeFeaturer{file: testFileAudioFeatures},
+			ids:         250,
+			wantBatches: []int{100, 100, 50},
+			wantErr:     nil,
+		},
+		{
+			name:        "No IDs",
+			feat:        fakeFeaturer{file: testFileAudioFeatures},
+			ids:         0,
+			wantFeats:   map[string]refind.Features{},
+			wantBatches: nil,
+			wantErr:     nil,
+		},
+		{
+			name:        "Error response",
+			feat:        fakeFeaturer{file: testFileEmpty, err: testErrNoData},
+			ids:         3,
+			wantBatches: []int{3},
+			wantErr:     testErrNoData,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var batches []int
+			test.feat.batches = &batches
+			s := &service{feat: test.feat}
+
+			ids := make([]string, test.ids)
+			for i := range ids {
+				ids[i] = strconv.Itoa(i)
+			}
+
+			got, err := s.AudioFeatures(ids)
+			if errors.Cause(err) != test.wantErr {
+				t.Errorf("got: <%v>, want: <%v>", errors.Cause(err), test.wantErr)
+			}
+
+			if !reflect.DeepEqual(batches, test.wantBatches) {
+				t.Errorf("got: <%v>, want: <%v>", batches, test.wantBatches)
+			}
+
+			if test.wantFeats != nil && !reflect.DeepEqual(got, test.wantFeats) {
+				t.Errorf("got: <%v>, want: <%v>", got, test.wantFeats)
+			}
+		})
+	}
+}
+
+type recordingRecommender struct {
+	file  string
+	seeds *[]spotify.Seeds
+	attrs *[]*spotify.TrackAttributes
+}
+
+func (r recordingRecommender) GetRecommendations(sds spotify.Seeds, attr *spotify.TrackAttributes, opt *spotify.Options) (*spotify.Recommendations, error) {
+	*r.seeds = append(*r.seeds, sds)
+	*r.attrs = append(*r.attrs, attr)
+	return fakeRecommender{file: r.file}.GetRecommendations(sds, attr, opt)
+}
+
+func TestService_TunedRecommendations(t *testing.T) {
+	tests := []struct {
+		name      string
+		tune      refind.Tuning
+		wantSeeds []spotify.Seeds
+		wantAttrs []*spotify.TrackAttributes
+		wantProv  map[string]float64
+		wantErr   error
+	}{
+		{
+			name:      "Empty tuning",
+			tune:      refind.Tuning{},
+			wantSeeds: []spotify.Seeds{{Artists: []spotify.ID{"4NHQUGzhtTLFvgF5SZesLK"}}},
+			wantAttrs: []*spotify.TrackAttributes{
+				spotify.NewTrackAttributes().TargetPopularity(popTarget).MaxPopularity(popMax),
+			},
+			wantProv: map[string]float64{"target_popularity": 40, "max_popularity": 50},
+			wantErr:  nil,
+		},
+		{
+			name: "Attributes and genres",
+			tune: refind.Tuning{
+				Attributes: map[string]float64{"target_energy": 0.8, "max_popularity": 70},
+				Genres:     []string{"party"},
+			},
+			wantSeeds: []spotify.Seeds{
+				{Artists: []spotify.ID{"4NHQUGzhtTLFvgF5SZesLK"}, Genres: []string{"party"}},
+			},
+			wantAttrs: []*spotify.TrackAttributes{
+				spotify.NewTrackAttributes().TargetPopularity(popTarget).MaxPopularity(70).TargetEnergy(0.8),
+			},
+			wantProv: map[string]float64{"target_popularity": 40, "max_popularity": 70, "target_energy": 0.8},
+			wantErr:  nil,
+		},
+		{
+			name: "Unsupported attribute",
+			tune: refind.Tuning{
+				Attributes: map[string]float64{"target_mood": 1},
+			},
+			wantErr: errAttribute,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var seeds []spotify.Seeds
+			var attrs []*spotify.TrackAttributes
+			s := &service{recom: recordingRecommender{file: testFileRecommendations, seeds: &seeds, attrs: &attrs}}
+
+			sds := []refind.Seed{{Category: refind.ArtistSeed, ID: "4NHQUGzhtTLFvgF5SZesLK"}}
+			list, err := s.TunedRecommendations(testTotal, sds, test.tune)
+			if errors.Cause(err) != test.wantErr {
+				t.Fatalf("got: <%v>, want: <%v>", errors.Cause(err), test.wantErr)
+			}
+
+			if !reflect.DeepEqual(seeds, test.wantSeeds) {
+				t.Errorf("got: <%v>, want: <%v>", seeds, test.wantSeeds)
+			}
+
+			if !reflect.DeepEqual(attrs, test.wantAttrs) {
+				t.Errorf("got: <%v>, want: <%v>", attrs, test.wantAttrs)
+			}
+
+			if len(list) > 0 && !reflect.DeepEqual(list[0].Provenance.Attributes, test.wantProv) {
+				t.Errorf("got: <%v>, want: <%v>", list[0].Provenance.Attributes, test.wantProv)
+			}
+		})
+	}
+}
+
+type fakeRelater struct {
+	file string
+	err  error
+}
+
+func (f fakeRelater) GetRelatedArtists(spotify.ID) ([]spotify.FullArtist, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+
+	b, err := ioutil.ReadFile(f.file)
+	if err != nil {
+		return nil, err
+	}
+
+	var res struct {
+		Artists []spotify.FullArtist `json:"artists"`
+	}
+	if err := json.Unmarshal(b, &res); err != nil {
+		return nil, err
+	}
+
+	return res.Artists, nil
+}
+
+func TestService_RelatedArtists(t *testing.T) {
+	tests := []struct {
+		name     string
+		rel      relater
+		id       string
+		wantArts []refind.Artist
+		wantErr  error
+	}{
+		{
+			name: "Valid data, nil error",
+			rel:  fakeRelater{file: testFileRelatedArtists},
+			id:   "1KP6TWI40m7p3QBTU6u2xo",
+			wantArts: []refind.Artist{
+				{
+					ID:     "3yY2gUcIsjMr8hjo51PoJ8",
+					Name:   "The Smiths",
+					Genres: []string{"dance rock", "madchester", "new wave", "post-punk", "rock"},
+					Images: []refind.Image{
+						{URL: "https://i.scdn.co/image/ab6761610000e5eb7a3a9b1e3c0e31b1a5b5e8c4", Width: 640, Height: 640},
+					},
+					Followers:  1563721,
+					Popularity: 74,
+					URI:        "spotify:artist:3yY2gUcIsjMr8hjo51PoJ8",
+					URLs:       testURLs("artist", "3yY2gUcIsjMr8hjo51PoJ8"),
+				},
+				{
+					ID:         "0GbEOBuHNjwLN4B6jnUzt0",
+					Name:       "The Pastels",
+					Genres:     []string{"c86", "indie pop", "jangle pop"},
+					Followers:  212033,
+					Popularity: 31,
+					URI:        "spotify:artist:0GbEOBuHNjwLN4B6jnUzt0",
+					URLs:       testURLs("artist", "0GbEOBuHNjwLN4B6jnUzt0"),
+				},
+			},
+			wantErr: nil,
+		},
+		{
+			name:     "Blank ID",
+			rel:      fakeRelater{file: testFileRelatedArtists},
+			id:       " ",
+			wantArts: nil,
+			wantErr:  errArtistID,
+		},
+		{
+			name:     "Error response",
+			rel:      fakeRelater{err: testErrNoData},
+			id:       "1KP6TWI40m7p3QBTU6u2xo",
+			wantArts: nil,
+			wantErr:  testErrNoData,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			s := &service{rel: test.rel}
+			arts, err := s.RelatedArtists(test.id)
+			if errors.Cause(err) != test.wantErr {
+				t.Errorf("got: <%v>, want: <%v>", errors.Cause(err), test.wantErr)
+			}
+
+			if !reflect.DeepEqual(arts, test.wantArts) {
+				t.Errorf("got: <%v>, want: <%v>", arts, test.wantArts)
+			}
+		})
+	}
+}
+
+type fakeArtistTracker struct {
+	file   string
+	err    error
+	market *string
+}
+
+func (f fakeArtistTracker) GetArtistsTopTracks(id spotify.ID, market string) ([]spotify.FullTrack, error) {
+	*f.market = market
+	if f.err != nil {
+		return nil, f.err
+	}
+
+	b, err := ioutil.ReadFile(f.file)
+	if err != nil {
+		return nil, err
+	}
+
+	var res struct {
+		Tracks []spotify.FullTrack `json:"tracks"`
+	}
+	if err := json.Unmarshal(b, &res); err != nil {
+		return nil, err
+	}
+
+	return res.Tracks, nil
+}
+
+func TestService_ArtistTopTracks(t *testing.T) {
+	tests := []struct {
+		name       string
+		atrk       fakeArtistTracker
+		id         string
+		wantTracks []refind.Track
+		wantErr    error
+	}{
+		{
+			name:       "Valid data, nil error",
+			atrk:       fakeArtistTracker{file: testFileArtistTopTracks},
+			id:         "4Z8W4fKeB5YxbusRsdQVPb",
+			wantTracks: []refind.Track{testReckoner},
+			wantErr:    nil,
+		},
+		{
+			name:       "Blank ID",
+			atrk:       fakeArtistTracker{file: testFileArtistTopTracks},
+			id:         "",
+			wantTracks: nil,
+			wantErr:    errArtistID,
+		},
+		{
+			name:       "Error response",
+			atrk:       fakeArtistTracker{err: testErrNoData},
+			id:         "4Z8W4fKeB5YxbusRsdQVPb",
+			wantTracks: nil,
+			wantErr:    testErrNoData,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var market string
+			test.atrk.market = &market
+			s := &service{atrk: test.atrk}
+
+			list, err := s.ArtistTopTracks(test.id)
+			if errors.Cause(err) != test.wantErr {
+				t.Errorf("got: <%v>, want: <%v>", errors.Cause(err), test.wantErr)
+			}
+
+			if !reflect.DeepEqual(list, test.wantTracks) {
+				t.Errorf("got: <%v>, want: <%v>", list, test.wantTracks)
+			}
+
+			if test.wantErr == nil && market != "from_token" {
+				t.Errorf("got: <%v>, want: <%v>", market, "from_token")
+			}
+		})
+	}
+}
+
+type fakeReleaser struct {
+	file    string
+	err     error
+	types   *spotify.AlbumType
+	batches *[]int
+}
+
+func (f fakeReleaser) page() (*spotify.SimpleAlbumPage, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+
+	b, err := ioutil.ReadFile(f.file)
+	if err != nil {
+		return nil, err
+	}
+
+	var page *spotify.SimpleAlbumPage
+	if err := json.Unmarshal(b, &page); err != nil {
+		return nil, err
+	}
+
+	return page, nil
+}
+
+func (f fakeReleaser) NewReleasesOpt(*spotify.Options) (*spotify.SimpleAlbumPage, error) {
+	return f.page()
+}
+
+func (f fakeReleaser) GetArtistAlbumsOpt(id spotify.ID, opt *spotify.Options, t *spotify.AlbumType) (*spotify.SimpleAlbumPage, error) {
+	*f.types = *t
+	return f.page()
+}
+
+func (f fakeReleaser) GetAlbums(ids ...spotify.ID) ([]*spotify.FullAlbum, error) {
+	*f.batches = append(*f.batches, len(ids))
+	if f.err != nil {
+		return nil, f.err
+	}
+
+	b, err := ioutil.ReadFile(f.file)
+	if err != nil {
+		return nil, err
+	}
+
+	var res struct {
+		Albums []*spotify.FullAlbum `json:"albums"`
+	}
+	if err := json.Unmarshal(b, &res); err != nil {
+		return nil, err
+	}
+
+	return res.Albums, nil
+}
+
+func testRelease(id string, name string, artist refind.Artist, date time.Time, typ refind.ReleaseType) refind.Release {
+	return refind.Release{
+		Album: refind.Album{
+			ID:          id,
+			Name:        name,
+			ReleaseDate: date,
+			Images:      []refind.Image{{URL: "https://i.scdn.co/image/" + id, Width: 640, Height: 640}},
+		},
+		Artist: artist,
+		Type:   typ,
+	}
+}
+
+var (
+	testPastels     = testArtist("0GbEOBuHNjwLN4B6jnUzt0", "The Pastels")
+	testSlowSummits = testRelease("2Ub4ciSRPNvHRd3fFXQkTM", "Slow Summits", testPastels, time.Date(2013, 5, 27, 0, 0, 0, 0, time.UTC), refind.AlbumRelease)
+	testCheckMy     = testRelease("5yx1yqzDkE4K9Zg2bnxHYT", "Check My Heart", testPastels, time.Date(2013, 4, 15, 0, 0, 0, 0, time.UTC), refind.SingleRelease)
+	testStrangeways = testRelease("6vDbbtD0cKXHRPMO9G6nMC", "Strangeways, Here We Come", testArtist("3yY2gUcIsjMr8hjo51PoJ8", "The Smiths"), time.Date(1987, 9, 28, 0, 0, 0, 0, time.UTC), refind.CompilationRelease)
+)
+
+func TestService_NewReleases(t *testing.T) {
+	tests := []struct {
+		name    string
+		albs    fakeReleaser
+		want    []refind.Release
+		wantErr error
+	}{
+		{
+			name:    "Valid data, nil error",
+			albs:    fakeReleaser{file: testFileNewReleases},
+			want:    []refind.Release{testSlowSummits, testStrangeways},
+			wantErr: nil,
+		},
+		{
+			name:    "Error response",
+			albs:    fakeReleaser{err: testErrNoData},
+			want:    nil,
+			wantErr: testErrNoData,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			s := &service{albs: test.albs}
+			got, err := s.NewReleases()
+			if errors.Cause(err) != test.wantErr {
+				t.Errorf("got: <%v>, want: <%v>", errors.Cause(err), test.wantErr)
+			}
+
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("got: <%v>, want: <%v>", got, test.want)
+			}
+		})
+	}
+}
+
+func TestService_ArtistReleases(t *testing.T) {
+	tests := []struct {
+		name      string
+		albs      fakeReleaser
+		id        string
+		types     []refind.ReleaseType
+		wantTypes spotify.AlbumType
+		want      []refind.Release
+		wantErr   error
+	}{
+		{
+			name:      "Every release type",
+			albs:      fakeReleaser{file: testFileArtistAlbums},
+			id:        "0GbEOBuHNjwLN4B6jnUzt0",
+			types:     nil,
+			wantTypes: spotify.AlbumTypeAlbum | spotify.AlbumTypeSingle | spotify.AlbumTypeCompilation,
+			want:      []refind.Release{testSlowSummits, testCheckMy},
+			wantErr:   nil,
+		},
+		{
+			name:      "Singles and compilations",
+			albs:      fakeReleaser{file: testFileArtistAlbums},
+			id:        "0GbEOBuHNjwLN4B6jnUzt0",
+			types:     []refind.ReleaseType{refind.SingleRelease, refind.CompilationRelease},
+			wantTypes: spotify.AlbumTypeSingle | spotify.AlbumTypeCompilation,
+			want:      []refind.Release{testSlowSummits, testCheckMy},
+			wantErr:   nil,
+		},
+		{
+			name:    "Blank ID",
+			albs:    fakeReleaser{file: testFileArtistAlbums},
+			id:      "",
+			want:    nil,
+			wantErr: errArtistID,
+		},
+		{
+			name:      "Error response",
+			albs:      fakeReleaser{err: testErrNoData},
+			id:        "0GbEOBuHNjwLN4B6jnUzt0",
+			wantTypes: spotify.AlbumTypeAlbum | spotify.AlbumTypeSingle | spotify.AlbumTypeCompilation,
+			want:      nil,
+			wantErr:   testErrNoData,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var types spotify.AlbumType
+			test.albs.types = &types
+			s := &service{albs: test.albs}
+
+			got, err := s.ArtistReleases(test.id, test.types)
+			if errors.Cause(err) != test.wantErr {
+				t.Errorf("got: <%v>, want: <%v>", errors.Cause(err), test.wantErr)
+			}
+
+			if types != test.wantTypes {
+				t.Errorf("got: <%v>, want: <%v>", types, test.wantTypes)
+			}
+
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("got: <%v>, want: <%v>", got, test.want)
+			}
+		})
+	}
+}
+
+func TestService_LeadTracks(t *testing.T) {
+	tests := []struct {
+		name        string
+		albs        fakeReleaser
+		ids         int
+		want        map[string]refind.Track
+		wantBatches []int
+		wantErr     error
+	}{
+		{
+			name: "Valid data, nil error",
+			albs: fakeReleaser{file: testFileAlbums},
+			ids:  3,
+			want: map[string]refind.Track{
+				"2Ub4ciSRPNvHRd3fFXQkTM": {
+					ID:       "4ZqDJXGyr2zXY5MAtmPj3P",
+					Name:     "Secret Music",
+					Artist:   testPastels,
+					Album:    testSlowSummits.Album,
+					Duration: 213000 * time.Millisecond,
+					URI:      "spotify:track:4ZqDJXGyr2zXY5MAtmPj3P",
+					URLs:     testURLs("track", "4ZqDJXGyr2zXY5MAtmPj3P"),
+				},
+			},
+			wantBatches: []int{3},
+			wantErr:     nil,
+		},
+		{
+			name:        "Batched requests",
+			albs:        fakeReleaser{file: testFileAlbums},
+			ids:         45,
+			wantBatches: []int{20, 20, 5},
+			wantErr:     nil,
+		},
+		{
+			name:        "Error response",
+			albs:        fakeReleaser{err: testErrNoData},
+			ids:         3,
+			wantBatches: []int{3},
+			wantErr:     testErrNoData,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var batches []int
+			test.albs.batches = &batches
+			s := &service{albs: test.albs}
+
+			ids := make([]string, test.ids)
+			for i := range ids {
+				ids[i] = strconv.Itoa(i)
+			}
+
+			got, err := s.LeadTracks(ids)
+			if errors.Cause(err) != test.wantErr {
+				t.Errorf("got: <%v>, want: <%v>", errors.Cause(err), test.wantErr)
+			}
+
+			if !reflect.DeepEqual(batches, test.wantBatches) {
+				t.Errorf("got: <%v>, want: <%v>", batches, test.wantBatches)
+			}
+
+			if test.want != nil && !reflect.DeepEqual(got, test.want) {
+				t.Errorf("got: <%v>, want: <%v>", got, test.want)
+			}
+		})
+	}
+}
+
+type fakeReplacer struct {
+	err      error
+	replaced *[]int
+}
+
+func (f fakeReplacer) ReplacePlaylistTracks(id spotify.ID, ids ...spotify.ID) error {
+	*f.replaced = append(*f.replaced, len(ids))
+	return f.err
+}
+
+type countingPlaylister struct {
+	fakePlaylister
+	added *[]int
+}
+
+func (c countingPlaylister) AddTracksToPlaylist(id spotify.ID, ids ...spotify.ID) (string, error) {
+	*c.added = append(*c.added, len(ids))
+	return "", nil
+}
+
+func TestService_ReplacePlaylist(t *testing.T) {
+	tests := []struct {
+		name         string
+		id           string
+		tracks       int
+		err          error
+		wantReplaced []int
+		wantAdded    []int
+		wantErr      error
+	}{
+		{"Single batch", "7I6yjOAxMq4qsvzgqxw7aU", 30, nil, []int{30}, nil, nil},
+		{"Multiple batches", "7I6yjOAxMq4qsvzgqxw7aU", 230, nil, []int{100}, []int{100, 30}, nil},
+		{"Blank ID", "", 30, nil, nil, nil, errPlaylistID},
+		{"Missing tracks", "7I6yjOAxMq4qsvzgqxw7aU", 0, nil, nil, nil, errTracksMissing},
+		{"Error response", "7I6yjOAxMq4qsvzgqxw7aU", 30, testErrNoData, []int{30}, nil, testErrNoData},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var replaced, added []int
+			s := &service{
+				repl: fakeReplacer{err: test.err, replaced: &replaced},
+				play: countingPlaylister{added: &added},
+			}
+
+			list := make([]refind.Track, test.tracks)
+			for i := range list {
+				list[i].ID = strconv.Itoa(i)
+			}
+
+			err := s.ReplacePlaylist(test.id, list)
+			if errors.Cause(err) != test.wantErr {
+				t.Errorf("got: <%v>, want: <%v>", errors.Cause(err), test.wantErr)
+			}
+
+			if !reflect.DeepEqual(replaced, test.wantReplaced) {
+				t.Errorf("got: <%v>, want: <%v>", replaced, test.wantReplaced)
+			}
+
+			if !reflect.DeepEqual(added, test.wantAdded) {
+				t.Errorf("got: <%v>, want: <%v>", added, test.wantAdded)
+			}
+		})
+	}
+}
+
+func TestService_SetParams(t *testing.T) {
+	tests := []struct {
+		name      string
+		par       Params
+		wantAttrs []*spotify.TrackAttributes
+		wantErr   error
+	}{
+		{
+			name: "Custom popularity",
+			par:  Params{Fetch: 20, PopTarget: 10, PopMax: 30, Ranges: []refind.TimeRange{refind.LongTerm}},
+			wantAttrs: []*spotify.TrackAttributes{
+				spotify.NewTrackAttributes().TargetPopularity(10).MaxPopularity(30),
+			},
+			wantErr: nil,
+		},
+		{
+			name:    "Fetch above API limit",
+			par:     Params{Fetch: fetchMax + 1, PopTarget: popTarget, PopMax: popMax, Ranges: []refind.TimeRange{refind.LongTerm}},
+			wantErr: refind.ErrRangeInvalid,
+		},
+		{
+			name:    "Target above max",
+			par:     Params{Fetch: fetchMax, PopTarget: 60, PopMax: 50, Ranges: []refind.TimeRange{refind.LongTerm}},
+			wantErr: errPopInvalid,
+		},
+		{
+			name:    "No time ranges",
+			par:     Params{Fetch: fetchMax, PopTarget: popTarget, PopMax: popMax},
+			wantErr: errTimeRange,
+		},
+		{
+			name:    "Unknown time range",
+			par:     Params{Fetch: fetchMax, PopTarget: popTarget, PopMax: popMax, Ranges: []refind.TimeRange{-1}},
+			wantErr: errTimeRange,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var seeds []spotify.Seeds
+			var attrs []*spotify.TrackAttributes
+			s := &service{recom: recordingRecommender{file: testFileRecommendations, seeds: &seeds, attrs: &attrs}}
+
+			err := s.SetParams(test.par)
+			if errors.Cause(err) != test.wantErr {
+				t.Fatalf("got: <%v>, want: <%v>", errors.Cause(err), test.wantErr)
+			}
+
+			if err != nil {
+				return
+			}
+
+			sds := []refind.Seed{{Category: refind.ArtistSeed, ID: "4NHQUGzhtTLFvgF5SZesLK"}}
+			if _, err := s.Recommendations(testTotal, sds); err != nil {
+				t.Fatal(err)
+			}
+
+			if !reflect.DeepEqual(attrs, test.wantAttrs) {
+				t.Errorf("got: <%v>, want: <%v>", attrs, test.wantAttrs)
+			}
+		})
+	}
+}
+
+const (
+	testFileTracks  string = "test_data/get_tracks.json"
+	testFileArtists string = "test_data/get_artists.json"
+)
+
+type fakeCataloger struct {
+	err     error
+	batches *[]int
+}
+
+func (f fakeCataloger) load(file string, ids []spotify.ID, v interface{}) error {
+	if f.batches != nil {
+		*f.batches = append(*f.batches, len(ids))
+	}
+
+	if f.err != nil {
+		return f.err
+	}
+
+	b, err := ioutil.ReadFile(file)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(b, v)
+}
+
+func (f fakeCataloger) GetTracks(ids ...spotify.ID) ([]*spotify.FullTrack, error) {
+	var res struct {
+		Tracks []*spotify.FullTrack `json:"tracks"`
+	}
+	if err := f.load(testFileTracks, ids, &res); err != nil {
+		return nil, err
+	}
+
+	return res.Tracks, nil
+}
+
+func (f fakeCataloger) GetArtists(ids ...spotify.ID) ([]*spotify.FullArtist, error) {
+	var res struct {
+		Artists []*spotify.FullArtist `json:"artists"`
+	}
+	if err := f.load(testFileArtists, ids, &res); err != nil {
+		return nil, err
+	}
+
+	return res.Artists, nil
+}
+
+func TestService_Tracks(t *testing.T) {
+	tests := []struct {
+		name        string
+		cat         fakeCataloger
+		ids         int
+		wantIDs     []string
+		wantBatches []int
+		wantErr     error
+	}{
+		{"Happy path", fakeCataloger{}, 2, []string{"6LgJvl0Xdtc73RJ1mmpotq"}, []int{2}, nil},
+		{"Batched requests", fakeCataloger{}, 120, []string{"6LgJvl0Xdtc73RJ1mmpotq", "6LgJvl0Xdtc73RJ1mmpotq", "6LgJvl0Xdtc73RJ1mmpotq"}, []int{50, 50, 20}, nil},
+		{"No IDs", fakeCataloger{}, 0, nil, nil, nil},
+		{"Error response", fakeCataloger{err: testErrNoData}, 2, nil, []int{2}, testErrNoData},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var batches []int
+			test.cat.batches = &batches
+			s := &service{cat: test.cat}
+
+			ids := make([]string, test.ids)
+			for i := range ids {
+				ids[i] = strconv.Itoa(i)
+			}
+
+			got, err := s.Tracks(ids)
+			if errors.Cause(err) != test.wantErr {
+				t.Fatalf("got: <%v>, want: <%v>", errors.Cause(err), test.wantErr)
+			}
+
+			var gotIDs []string
+			for _, tr := range got {
+				gotIDs = append(gotIDs, tr.ID)
+			}
+
+			if !reflect.DeepEqual(gotIDs, test.wantIDs) {
+				t.Errorf("got: <%v>, want: <%v>", gotIDs, test.wantIDs)
+			}
+
+			if !reflect.DeepEqual(batches, test.wantBatches) {
+				t.Errorf("got: <%v>, want: <%v>", batches, test.wantBatches)
+			}
+
+			if len(got) > 0 && (got[0].Album.Name != "In Rainbows" || got[0].ISRC != "GBSTK0700057" || got[0].Popularity != 66) {
+				t.Errorf("got: <%v>, want album, ISRC and popularity", got[0])
+			}
+		})
+	}
+}
+
+func TestService_Artists(t *testing.T) {
+	s := &service{cat: fakeCataloger{}}
+
+	got, err := s.Artists([]string{"4Z8W4fKeB5YxbusRsdQVPb", "missing", "3yY2gUcIsjMr8hjo51PoJ8"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(got) != 2 {
+		t.Fatalf("got: <%v>, want: <%v>", len(got), 2)
+	}
+
+	if got[0].Name != "Radiohead" || len(got[0].Genres) == 0 || got[0].Popularity != 80 {
+		t.Errorf("got: <%v>, want genres and popularity", got[0])
+	}
+
+	if _, err := (&service{cat: fakeCataloger{err: testErrNoData}}).Artists([]string{"1"}); errors.Cause(err) != testErrNoData {
+		t.Errorf("got: <%v>, want: <%v>", errors.Cause(err), testErrNoData)
+	}
+}
+
+func TestService_SeedGroups(t *testing.T) {
+	tests := []struct {
+		name   string
+		seeds  int
+		genres []string
+		want   int
+	}{
+		{"No seeds", 0, nil, 0},
+		{"Single group", 5, nil, 1},
+		{"Partial last group", 7, nil, 2},
+		{"Genre slot in every group", 5, []string{"party"}, 2},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var sds []refind.Seed
+			for i := 0; i < test.seeds; i++ {
+				sds = append(sds, refind.Seed{Category: refind.ArtistSeed, ID: strconv.Itoa(i)})
+			}
+
+			s := &service{}
+			if got := s.SeedGroups(sds, refind.Tuning{Genres: test.genres}); got != test.want {
+				t.Errorf("got: <%v>, want: <%v>", got, test.want)
+			}
+		})
+	}
+}
+
+func TestService_TunedRecommendationsGenres(t *testing.T) {
+	var seeds []spotify.Seeds
+	var attrs []*spotify.TrackAttributes
+	s := &service{recom: recordingRecommender{file: testFileRecommendations, seeds: &seeds, attrs: &attrs}}
+
+	var sds []refind.Seed
+	for _, id := range []string{"a0", "a1", "a2", "a3", "a4", "a5"} {
+		sds = append(sds, refind.Seed{Category: refind.ArtistSeed, ID: id})
+	}
+
+	if _, err := s.TunedRecommendations(testTotal, sds, refind.Tuning{Genres: []string{"party", "dance"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []spotify.Seeds{
+		{Artists: []spotify.ID{"a0", "a1", "a2", "a3"}, Genres: []string{"party"}},
+		{Artists: []spotify.ID{"a4", "a5"}, Genres: []string{"dance"}},
+	}
+	if !reflect.DeepEqual(seeds, want) {
+		t.Errorf("got: <%v>, want: <%v>", seeds, want)
+	}
+}
+
+type fakeTokener struct {
+	tok *oauth2.Token
+}
+
+func (f fakeTokener) Token() (*oauth2.Token, error) {
+	return f.tok, nil
+}
+
+func TestService_Token(t *testing.T) {
+	tok := &oauth2.Token{AccessToken: "foo", RefreshToken: "bar"}
+	tests := []struct {
+		name    string
+		tok     tokener
+		want    *oauth2.Token
+		wantErr error
+	}{
+		{"Client with token", fakeTokener{tok: tok}, tok, nil},
+		{"Client without token", nil, nil, errNoToken},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			s := &service{tok: test.tok}
+
+			got, err := s.Token()
+			if err != test.wantErr {
+				t.Errorf("got: <%v>, want: <%v>", err, test.wantErr)
+			}
+
+			if got != test.want {
+				t.Errorf("got: <%v>, want: <%v>", got, test.want)
+			}
+		})
+	}
+}
+
+func TestParseTimeRange(t *testing.T) {
+	tests := []struct {
+		name    string
+		in      string
+		want    refind.TimeRange
+		wantErr error
+	}{
+		{"Short", "short", refind.ShortTerm, nil},
+		{"Medium", "medium", refind.MediumTerm, nil},
+		{"Long", "long", refind.LongTerm, nil},
+		{"Unknown", "week", 0, errTimeRange},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := ParseTimeRange(test.in)
+			if err != test.wantErr {
+				t.Fatalf("got: <%v>, want: <%v>", err, test.wantErr)
+			}
+
+			if got != test.want {
+				t.Errorf("got: <%v>, want: <%v>", got, test.want)
+			}
+		})
+	}
+}
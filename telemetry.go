@@ -0,0 +1,153 @@
+package refind
+
+import (
+	"context"
+	"time"
+)
+
+// Names of the metrics recorded by refind and the labels of each.
+const (
+	// MetricSpotifyRequests counts Spotify requests by endpoint and status.
+	MetricSpotifyRequests string = "refind_spotify_requests_total"
+	// MetricSpotifyLatency observes Spotify request seconds by endpoint.
+	MetricSpotifyLatency string = "refind_spotify_request_duration_seconds"
+	// MetricBufferRequests counts buffered calls by method and result, which
+	// is either hit or miss.
+	MetricBufferRequests string = "refind_buffer_requests_total"
+	// MetricGenerations counts tracklist generations by mode and result,
+	// which is either ok or error.
+	MetricGenerations string = "refind_generations_total"
+	// MetricGenerationDuration observes generation seconds by mode and
+	// result, so that slow failures show up as well as slow successes.
+	MetricGenerationDuration string = "refind_generation_duration_seconds"
+	// MetricGenerationTracks observes the tracks in each tracklist by mode.
+	MetricGenerationTracks string = "refind_generation_tracks"
+	// MetricGenerationYield observes the fraction of recommendations that
+	// survive filtering by mode.
+	MetricGenerationYield string = "refind_generation_yield"
+)
+
+// Metrics records counters and histograms. Labels alternate between label
+// names and values.
+type Metrics interface {
+	Inc(name string, labels ...string)
+	Observe(name string, value float64, labels ...string)
+}
+
+// Tracer starts spans as children of the span in ctx, if any. The values of
+// SetAttributes alternate between keys and values.
+type Tracer interface {
+	Start(ctx context.Context, name string) (context.Context, Span)
+}
+
+type Span interface {
+	SetAttributes(kv ...interface{})
+	RecordError(err error)
+	End()
+}
+
+// ContextRecommender is implemented by recommenders that can trace their
+// requests as children of the span in ctx.
+type ContextRecommender interface {
+	WithContext(ctx context.Context) Recommender
+}
+
+// SetMetrics makes the generator record the duration and yield of every
+// tracklist it generates.
+func (g *generator) SetMetrics(m Metrics) error {
+	if m == nil {
+		return errNilMetrics
+	}
+
+	g.met = m
+	return nil
+}
+
+// SetTracer makes the generator emit a trace for every tracklist, with spans
+// for the calls to the music service and recommender.
+func (g *generator) SetTracer(t Tracer) error {
+	if t == nil {
+		return errNilTracer
+	}
+
+	g.tr = t
+	return nil
+}
+
+// run is a single traced and measured generation.
+type run struct {
+	mode  string
+	start time.Time
+	span  Span
+	met   Metrics
+}
+
+// begin starts a generation in the given mode. It returns a copy of g that
+// traces its calls as part of the generation, or g itself when neither
+// tracing nor metrics are enabled.
+func (g generator) begin(mode string) (generator, *run) {
+	if g.tr == nil && g.met == nil {
+		return g, nil
+	}
+
+	r := &run{mode: mode, start: time.Now(), met: g.met}
+	if g.tr != nil {
+		g.ctx, r.span = g.tr.Start(context.Background(), "refind."+mode)
+	}
+
+	return g, r
+}
+
+// end records the outcome of the generation and passes it through.
+func (r *run) end(list []Track, rep Report, err error) ([]Track, Report, error) {
+	if r == nil {
+		return list, rep, err
+	}
+
+	if r.span != nil {
+		r.span.SetAttributes("seeds", rep.Seeds, "recommendations", rep.Recommendations, "tracks", rep.Final)
+		if err != nil {
+			r.span.RecordError(err)
+		}
+		r.span.End()
+	}
+
+	if r.met == nil {
+		return list, rep, err
+	}
+
+	result := "ok"
+	if err != nil {
+		result = "error"
+	}
+	r.met.Inc(MetricGenerations, "mode", r.mode, "result", result)
+	r.met.Observe(MetricGenerationDuration, time.Since(r.start).Seconds(), "mode", r.mode, "result", result)
+	if err != nil {
+		return list, rep, err
+	}
+
+	r.met.Observe(MetricGenerationTracks, float64(len(list)), "mode", r.mode)
+	if rep.Recommendations > 0 {
+		r.met.Observe(MetricGenerationYield, float64(rep.Final)/float64(rep.Recommendations), "mode", r.mode)
+	}
+
+	return list, rep, err
+}
+
+// span calls f within a child span of the current generation when tracing
+// is enabled.
+func (g generator) span(name string, f func(ctx context.Context) error) error {
+	if g.tr == nil || g.ctx == nil {
+		return f(context.Background())
+	}
+
+	ctx, sp := g.tr.Start(g.ctx, name)
+	defer sp.End()
+
+	err := f(ctx)
+	if err != nil {
+		sp.RecordError(err)
+	}
+
+	return err
+}
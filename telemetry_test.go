@@ -0,0 +1,185 @@
+package refind
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+type spanKey struct{}
+
+type spanRecord struct {
+	name   string
+	parent string
+	err    bool
+	ended  bool
+}
+
+type recordingTracer struct {
+	spans *[]*spanRecord
+}
+
+func (r recordingTracer) Start(ctx context.Context, name string) (context.Context, Span) {
+	parent, _ := ctx.Value(spanKey{}).(string)
+	sp := &spanRecord{name: name, parent: parent}
+	*r.spans = append(*r.spans, sp)
+	return context.WithValue(ctx, spanKey{}, name), sp
+}
+
+func (s *spanRecord) SetAttributes(...interface{}) {}
+func (s *spanRecord) RecordError(error)            { s.err = true }
+func (s *spanRecord) End()                         { s.ended = true }
+
+// contextRecommender records the span it is bound to when recommending.
+type contextRecommender struct {
+	fakeRecommender
+	parent *string
+	ctx    context.Context
+}
+
+func (c contextRecommender) WithContext(ctx context.Context) Recommender {
+	c.ctx = ctx
+	return c
+}
+
+func (c contextRecommender) Recommendations(n int, sds []Seed) ([]Track, error) {
+	if c.ctx != nil {
+		*c.parent, _ = c.ctx.Value(spanKey{}).(string)
+	}
+
+	return c.fakeRecommender.Recommendations(n, sds)
+}
+
+type recordingMetrics struct {
+	counts map[string]int
+	values map[string][]float64
+}
+
+func (r recordingMetrics) Inc(name string, labels ...string) {
+	r.counts[name]++
+}
+
+func (r recordingMetrics) Observe(name string, v float64, labels ...string) {
+	r.values[name] = append(r.values[name], v)
+}
+
+func TestGenerator_Tracing(t *testing.T) {
+	serv := fakeMusicService{
+		artists: []Artist{testArtistA},
+		tracks:  []Track{testTrackA},
+	}
+
+	tests := []struct {
+		name       string
+		serv       MusicService
+		wantSpans  []spanRecord
+		wantParent string
+	}{
+		{
+			name: "Successful generation",
+			serv: serv,
+			wantSpans: []spanRecord{
+				{name: "refind.Tracklist", ended: true},
+				{name: "RecentTracks", parent: "refind.Tracklist", ended: true},
+				{name: "Recommendations", parent: "refind.Tracklist", ended: true},
+				{name: "TopArtists", parent: "refind.Tracklist", ended: true},
+			},
+			wantParent: "Recommendations",
+		},
+		{
+			name: "Failed call",
+			serv: fakeMusicService{trackErr: testErrFetchTracks},
+			wantSpans: []spanRecord{
+				{name: "refind.Tracklist", err: true, ended: true},
+				{name: "RecentTracks", parent: "refind.Tracklist", err: true, ended: true},
+			},
+			wantParent: "",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var spans []*spanRecord
+			var parent string
+			rec := contextRecommender{fakeRecommender: fakeRecommender{tracks: []Track{testTrackC}}, parent: &parent}
+
+			g, err := New(test.serv, rec, WithTracer(recordingTracer{spans: &spans}))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			g.Tracklist(testTotal)
+
+			var got []spanRecord
+			for _, sp := range spans {
+				got = append(got, *sp)
+			}
+
+			if !reflect.DeepEqual(got, test.wantSpans) {
+				t.Errorf("got: <%v>, want: <%v>", got, test.wantSpans)
+			}
+
+			if parent != test.wantParent {
+				t.Errorf("got: <%v>, want: <%v>", parent, test.wantParent)
+			}
+		})
+	}
+}
+
+func TestGenerator_Metrics(t *testing.T) {
+	met := recordingMetrics{counts: make(map[string]int), values: make(map[string][]float64)}
+	serv := fakeMusicService{
+		artists: []Artist{testArtistA},
+		tracks:  []Track{testTrackA},
+	}
+
+	// One of the two recommendations is by a known artist.
+	known := Track{ID: "x", Artist: testArtistA}
+	g, err := New(serv, fakeRecommender{tracks: []Track{known, testTrackC}}, WithMetrics(met))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := g.Tracklist(testTotal); err != nil {
+		t.Fatal(err)
+	}
+
+	if met.counts[MetricGenerations] != 1 {
+		t.Errorf("got: <%v>, want: <%v>", met.counts[MetricGenerations], 1)
+	}
+
+	if got := met.values[MetricGenerationTracks]; !reflect.DeepEqual(got, []float64{1}) {
+		t.Errorf("got: <%v>, want: <%v>", got, []float64{1})
+	}
+
+	if got := met.values[MetricGenerationYield]; !reflect.DeepEqual(got, []float64{0.5}) {
+		t.Errorf("got: <%v>, want: <%v>", got, []float64{0.5})
+	}
+
+	if len(met.values[MetricGenerationDuration]) != 1 {
+		t.Errorf("got: <%v>, want one duration", met.values[MetricGenerationDuration])
+	}
+}
+
+func TestGenerator_MetricsFailure(t *testing.T) {
+	met := recordingMetrics{counts: make(map[string]int), values: make(map[string][]float64)}
+	g, err := New(fakeMusicService{trackErr: testErrFetchTracks}, fakeRecommender{}, WithMetrics(met))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := g.Tracklist(testTotal); err == nil {
+		t.Fatalf("got: <%v>, want: <%v>", err, testErrFetchTracks)
+	}
+
+	if met.counts[MetricGenerations] != 1 {
+		t.Errorf("got: <%v>, want: <%v>", met.counts[MetricGenerations], 1)
+	}
+
+	if len(met.values[MetricGenerationDuration]) != 1 {
+		t.Errorf("got: <%v>, want one duration", met.values[MetricGenerationDuration])
+	}
+
+	if len(met.values[MetricGenerationTracks]) != 0 {
+		t.Errorf("got: <%v>, want: <%v>", met.values[MetricGenerationTracks], "no tracks observed")
+	}
+}
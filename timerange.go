@@ -0,0 +1,22 @@
+package refind
+
+type TimeRange int
+
+const (
+	ShortTerm TimeRange = iota
+	MediumTerm
+	LongTerm
+)
+
+// Ranking records an artist's position in one time range of a user's top list.
+type Ranking struct {
+	Range TimeRange
+	Rank  int
+}
+
+type RangeWeights map[TimeRange]float64
+
+var (
+	CurrentFavorites  = RangeWeights{ShortTerm: 3, MediumTerm: 2, LongTerm: 1}
+	LongTermFavorites = RangeWeights{ShortTerm: 1, MediumTerm: 2, LongTerm: 3}
+)
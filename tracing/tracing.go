@@ -0,0 +1,77 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"github.com/Henry-Sarabia/refind"
+	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var errNilTracer = errors.New("cannot initialize tracing using nil tracer")
+
+// tracer adapts an OpenTelemetry tracer to a refind.Tracer.
+type tracer struct {
+	tr trace.Tracer
+}
+
+// New returns a refind.Tracer that emits spans to tr, such as
+// otel.Tracer("refind").
+func New(tr trace.Tracer) (*tracer, error) {
+	if tr == nil {
+		return nil, errNilTracer
+	}
+
+	return &tracer{tr: tr}, nil
+}
+
+func (t *tracer) Start(ctx context.Context, name string) (context.Context, refind.Span) {
+	ctx, sp := t.tr.Start(ctx, name)
+	return ctx, span{sp: sp}
+}
+
+type span struct {
+	sp trace.Span
+}
+
+func (s span) SetAttributes(kv ...interface{}) {
+	s.sp.SetAttributes(attributes(kv)...)
+}
+
+// RecordError records err with any credentials in its message redacted.
+func (s span) RecordError(err error) {
+	msg := refind.Redact(err.Error())
+	s.sp.RecordError(errors.New(msg))
+	s.sp.SetStatus(codes.Error, msg)
+}
+
+func (s span) End() {
+	s.sp.End()
+}
+
+func attributes(kv []interface{}) []attribute.KeyValue {
+	attrs := make([]attribute.KeyValue, 0, len(kv)/2)
+	for i := 0; i+1 < len(kv); i += 2 {
+		key := fmt.Sprint(kv[i])
+		switch v := kv[i+1].(type) {
+		case string:
+			attrs = append(attrs, attribute.String(key, v))
+		case int:
+			attrs = append(attrs, attribute.Int(key, v))
+		case int64:
+			attrs = append(attrs, attribute.Int64(key, v))
+		case float64:
+			attrs = append(attrs, attribute.Float64(key, v))
+		case bool:
+			attrs = append(attrs, attribute.Bool(key, v))
+		case []string:
+			attrs = append(attrs, attribute.StringSlice(key, v))
+		default:
+			attrs = append(attrs, attribute.String(key, fmt.Sprint(v)))
+		}
+	}
+
+	return attrs
+}
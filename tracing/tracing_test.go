@@ -0,0 +1,80 @@
+package tracing
+
+import (
+	"context"
+	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+	"reflect"
+	"testing"
+)
+
+type recordingSpan struct {
+	noop.Span
+	attrs  []attribute.KeyValue
+	errs   []string
+	status string
+	ended  bool
+}
+
+func (r *recordingSpan) SetAttributes(kv ...attribute.KeyValue) { r.attrs = append(r.attrs, kv...) }
+func (r *recordingSpan) RecordError(err error, _ ...trace.EventOption) {
+	r.errs = append(r.errs, err.Error())
+}
+func (r *recordingSpan) SetStatus(_ codes.Code, msg string) { r.status = msg }
+func (r *recordingSpan) End(...trace.SpanEndOption)         { r.ended = true }
+
+type recordingTracer struct {
+	noop.Tracer
+	names []string
+	span  *recordingSpan
+}
+
+func (r *recordingTracer) Start(ctx context.Context, name string, _ ...trace.SpanStartOption) (context.Context, trace.Span) {
+	r.names = append(r.names, name)
+	return ctx, r.span
+}
+
+func TestNew(t *testing.T) {
+	if _, err := New(nil); err != errNilTracer {
+		t.Errorf("got: <%v>, want: <%v>", err, errNilTracer)
+	}
+}
+
+func TestTracer(t *testing.T) {
+	rec := &recordingTracer{span: &recordingSpan{}}
+	tr, err := New(rec)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, sp := tr.Start(context.Background(), "refind.Tracklist")
+	sp.SetAttributes("tracks", 30, "yield", 0.5, "mode", "full", "ids", []string{"a"}, "odd")
+	sp.RecordError(errors.New("cannot refresh token: access_token=abc123"))
+	sp.End()
+
+	if want := []string{"refind.Tracklist"}; !reflect.DeepEqual(rec.names, want) {
+		t.Errorf("got: <%v>, want: <%v>", rec.names, want)
+	}
+
+	wantAttrs := []attribute.KeyValue{
+		attribute.Int("tracks", 30),
+		attribute.Float64("yield", 0.5),
+		attribute.String("mode", "full"),
+		attribute.StringSlice("ids", []string{"a"}),
+	}
+	if !reflect.DeepEqual(rec.span.attrs, wantAttrs) {
+		t.Errorf("got: <%v>, want: <%v>", rec.span.attrs, wantAttrs)
+	}
+
+	wantErr := "cannot refresh token: access_token=[REDACTED]"
+	if !reflect.DeepEqual(rec.span.errs, []string{wantErr}) || rec.span.status != wantErr {
+		t.Errorf("got: <%v, %v>, want: <%v>", rec.span.errs, rec.span.status, wantErr)
+	}
+
+	if !rec.span.ended {
+		t.Errorf("got: <%v>, want: <%v>", rec.span.ended, true)
+	}
+}
@@ -3,14 +3,32 @@ package refind
 import (
 	"github.com/Henry-Sarabia/blank"
 	"github.com/pkg/errors"
+	"time"
 )
 
 var errTrackSeed = errors.New("cannot create track seed with missing id")
 
 type Track struct {
-	ID     string
-	Name   string
-	Artist Artist
+	ID         string
+	Name       string
+	Artist     Artist
+	Album      Album
+	Duration   time.Duration
+	Explicit   bool
+	Popularity int
+	ISRC       string
+	URI        string
+	URLs       map[string]string
+	Provenance *Provenance
+}
+
+// CatalogService is implemented by music services that can look up the full
+// catalog data of tracks and artists. Recommendations and recently played
+// tracks usually come without an album, ISRC, popularity or artist genres,
+// which diversity constraints, matching and the radar depend on.
+type CatalogService interface {
+	Tracks(ids []string) ([]Track, error)
+	Artists(ids []string) ([]Artist, error)
 }
 
 func (t Track) Seed() (Seed, error) {
@@ -0,0 +1,52 @@
+package user
+
+import (
+	"sync"
+	"time"
+)
+
+// historyMax is the number of entries kept per user by the memory history.
+const historyMax int = 100
+
+// Entry is a single tracklist generated for a user.
+type Entry struct {
+	Time   time.Time `json:"time"`
+	Tracks []string  `json:"tracks"`
+}
+
+// History stores the tracklists generated for each user, oldest first.
+type History interface {
+	Add(user string, e Entry) error
+	Entries(user string) ([]Entry, error)
+}
+
+type memoryHistory struct {
+	mu      sync.Mutex
+	entries map[string][]Entry
+}
+
+// NewMemoryHistory returns a History that keeps the latest entries of each
+// user in memory.
+func NewMemoryHistory() *memoryHistory {
+	return &memoryHistory{entries: make(map[string][]Entry)}
+}
+
+func (m *memoryHistory) Add(user string, e Entry) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	es := append(m.entries[user], e)
+	if len(es) > historyMax {
+		es = es[len(es)-historyMax:]
+	}
+	m.entries[user] = es
+
+	return nil
+}
+
+func (m *memoryHistory) Entries(user string) ([]Entry, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return append([]Entry(nil), m.entries[user]...), nil
+}
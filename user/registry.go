@@ -0,0 +1,337 @@
+package user
+
+import (
+	"github.com/Henry-Sarabia/blank"
+	"github.com/Henry-Sarabia/refind"
+	"github.com/Henry-Sarabia/refind/buffer"
+	"github.com/pkg/errors"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrQuotaExceeded is returned by Tracklist and Generate once a user
+	// has used up their quota.
+	ErrQuotaExceeded = errors.New("generation quota exceeded, try again later")
+	errNilHistory    = errors.New("cannot initialize registry using nil history")
+	errNilService    = errors.New("cannot register user using nil interface")
+	errNilPresets    = errors.New("cannot use nil presets")
+	errNilOption     = errors.New("cannot use nil option")
+	errNilMetrics    = errors.New("cannot use nil metrics")
+	errQuotaInvalid  = errors.New("quota must allow at least one generation per positive window")
+	errTTLInvalid    = errors.New("buffer TTL must not be negative")
+	errUserID        = errors.New("user ID is missing or blank")
+	errUserUnknown   = errors.New("no user is registered with that ID")
+)
+
+// Quota limits how many tracklists a user may generate within a sliding
+// window. A zero Quota is unlimited.
+type Quota struct {
+	Max    int
+	Window time.Duration
+}
+
+type cache interface {
+	refind.MusicService
+	Reset()
+}
+
+// user is the state of a single registered user. mu serializes the user's
+// generations so that the buffer is never used concurrently.
+type user struct {
+	mu       sync.Mutex
+	buf      cache
+	filled   time.Time
+	rec      refind.Recommender
+	settings Settings
+	used     []time.Time
+}
+
+type registry struct {
+	quota    Quota
+	hist     History
+	now      func() time.Time
+	ttl      time.Duration
+	presets  map[string]refind.Tuning
+	defaults Settings
+	opts     []refind.Option
+	met      refind.Metrics
+
+	mu    sync.Mutex
+	users map[string]*user
+}
+
+// New returns a registry of users that each generate tracklists from their
+// own music service, buffer and settings, limited by the quota and recorded
+// in the history.
+func New(q Quota, hist History) (*registry, error) {
+	if hist == nil {
+		return nil, errNilHistory
+	}
+
+	if q.Max < 0 || (q.Max > 0 && q.Window <= 0) {
+		return nil, errQuotaInvalid
+	}
+
+	return &registry{
+		quota:    q,
+		hist:     hist,
+		now:      time.Now,
+		presets:  refind.Presets,
+		defaults: DefaultSettings,
+		users:    make(map[string]*user),
+	}, nil
+}
+
+// SetBufferTTL empties a user's buffer before their next tracklist once it
+// is older than ttl, so that tracklists follow recent listening. Buffers are
+// otherwise kept until they are emptied with Refresh, as with a zero ttl.
+func (r *registry) SetBufferTTL(ttl time.Duration) error {
+	if ttl < 0 {
+		return errTTLInvalid
+	}
+
+	r.ttl = ttl
+	return nil
+}
+
+// SetPresets replaces the presets that settings may name.
+func (r *registry) SetPresets(presets map[string]refind.Tuning) error {
+	if presets == nil {
+		return errNilPresets
+	}
+
+	r.presets = presets
+	return nil
+}
+
+// SetDefaults replaces the settings that new users start with. The settings
+// are validated against the current presets.
+func (r *registry) SetDefaults(s Settings) error {
+	if err := s.validate(r.presets); err != nil {
+		return err
+	}
+
+	r.defaults = s
+	return nil
+}
+
+// SetOptions configures the generator of every tracklist, such as with
+// refind.WithLogger.
+func (r *registry) SetOptions(opts ...refind.Option) error {
+	for _, o := range opts {
+		if o == nil {
+			return errNilOption
+		}
+	}
+
+	r.opts = opts
+	return nil
+}
+
+// SetMetrics makes the buffers of users registered from now on count their
+// hits and misses.
+func (r *registry) SetMetrics(m refind.Metrics) error {
+	if m == nil {
+		return errNilMetrics
+	}
+
+	r.met = m
+	return nil
+}
+
+// Register adds the user with the given ID, or replaces the music service of
+// a user that is already registered while keeping their settings and quota.
+func (r *registry) Register(id string, serv refind.MusicService, rec refind.Recommender) error {
+	if blank.Is(id) {
+		return errUserID
+	}
+
+	if serv == nil || rec == nil {
+		return errNilService
+	}
+
+	buf, err := buffer.New(serv)
+	if err != nil {
+		return err
+	}
+
+	if r.met != nil {
+		if err := buf.SetMetrics(r.met); err != nil {
+			return err
+		}
+	}
+
+	now := r.now()
+	r.mu.Lock()
+	u, ok := r.users[id]
+	if !ok {
+		r.users[id] = &user{buf: buf, filled: now, rec: rec, settings: r.defaults}
+	}
+	r.mu.Unlock()
+
+	if ok {
+		u.mu.Lock()
+		u.buf, u.filled, u.rec = buf, now, rec
+		u.mu.Unlock()
+	}
+
+	return nil
+}
+
+// Remove forgets the user with the given ID. Their history is kept.
+func (r *registry) Remove(id string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.users, id)
+}
+
+func (r *registry) user(id string) (*user, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	u, ok := r.users[id]
+	if !ok {
+		return nil, errUserUnknown
+	}
+
+	return u, nil
+}
+
+func (r *registry) Settings(id string) (Settings, error) {
+	u, err := r.user(id)
+	if err != nil {
+		return Settings{}, err
+	}
+
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	return u.settings, nil
+}
+
+func (r *registry) SetSettings(id string, s Settings) error {
+	if err := s.validate(r.presets); err != nil {
+		return err
+	}
+
+	u, err := r.user(id)
+	if err != nil {
+		return err
+	}
+
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	u.settings = s
+	return nil
+}
+
+// Refresh empties the user's buffer so that their next tracklist is based on
+// fresh listening data.
+func (r *registry) Refresh(id string) error {
+	u, err := r.user(id)
+	if err != nil {
+		return err
+	}
+
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	u.buf.Reset()
+	u.filled = r.now()
+	return nil
+}
+
+func (r *registry) History(id string) ([]Entry, error) {
+	if _, err := r.user(id); err != nil {
+		return nil, err
+	}
+
+	return r.hist.Entries(id)
+}
+
+// Tracklist generates a tracklist for the user with their settings. Every
+// attempt counts towards the quota, including those that fail, since they
+// still spend the music service's rate limit.
+func (r *registry) Tracklist(id string) ([]refind.Track, refind.Report, error) {
+	u, err := r.user(id)
+	if err != nil {
+		return nil, refind.Report{}, err
+	}
+
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	return r.generate(id, u, u.settings)
+}
+
+// Generate is like Tracklist but uses the given settings instead of the
+// user's own, such as for a single request that overrides some of them.
+func (r *registry) Generate(id string, s Settings) ([]refind.Track, refind.Report, error) {
+	if err := s.validate(r.presets); err != nil {
+		return nil, refind.Report{}, err
+	}
+
+	u, err := r.user(id)
+	if err != nil {
+		return nil, refind.Report{}, err
+	}
+
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	return r.generate(id, u, s)
+}
+
+// generate checks and spends the quota, generates the tracklist and records
+// it. The caller must hold u.mu.
+func (r *registry) generate(id string, u *user, s Settings) ([]refind.Track, refind.Report, error) {
+	now := r.now()
+	if !r.allow(u, now) {
+		return nil, refind.Report{}, ErrQuotaExceeded
+	}
+
+	if r.quota.Max > 0 {
+		u.used = append(u.used, now)
+	}
+
+	if r.ttl > 0 && now.Sub(u.filled) >= r.ttl {
+		u.buf.Reset()
+		u.filled = now
+	}
+
+	list, rep, err := s.tracklist(u.buf, u.rec, r.presets, r.opts)
+	if err != nil {
+		return nil, rep, err
+	}
+
+	e := Entry{Time: now}
+	for _, t := range list {
+		e.Tracks = append(e.Tracks, t.ID)
+	}
+
+	if err := r.hist.Add(id, e); err != nil {
+		return nil, rep, errors.Wrap(err, "cannot record history")
+	}
+
+	return list, rep, nil
+}
+
+// allow drops generations that fell out of the quota window and reports
+// whether the user may generate another tracklist. The caller must hold u.mu.
+func (r *registry) allow(u *user, now time.Time) bool {
+	if r.quota.Max == 0 {
+		return true
+	}
+
+	start := now.Add(-r.quota.Window)
+	i := 0
+	for i < len(u.used) && !u.used[i].After(start) {
+		i++
+	}
+	u.used = u.used[i:]
+
+	return len(u.used) < r.quota.Max
+}
@@ -0,0 +1,487 @@
+package user
+
+import (
+	"github.com/Henry-Sarabia/refind"
+	"github.com/pkg/errors"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+var testNow = time.Date(2020, 6, 15, 12, 0, 0, 0, time.UTC)
+
+type fakeMusicService struct {
+	artists []refind.Artist
+	calls   *int
+}
+
+func (f fakeMusicService) TopArtists() ([]refind.Artist, error) {
+	if f.calls != nil {
+		*f.calls++
+	}
+	return f.artists, nil
+}
+
+func (f fakeMusicService) TopTracks() ([]refind.Track, error) {
+	return nil, nil
+}
+
+func (f fakeMusicService) RecentTracks() ([]refind.Track, error) {
+	return nil, nil
+}
+
+type fakeRecommender struct {
+	tracks []refind.Track
+}
+
+func (f fakeRecommender) Recommendations(int, []refind.Seed) ([]refind.Track, error) {
+	return f.tracks, nil
+}
+
+var (
+	testArtistA = refind.Artist{ID: "a", Name: "alpha"}
+	testArtistB = refind.Artist{ID: "b", Name: "bravo"}
+	testArtistC = refind.Artist{ID: "c", Name: "charlie"}
+)
+
+var testRecs = fakeRecommender{
+	tracks: []refind.Track{
+		{ID: "1", Artist: testArtistA},
+		{ID: "2", Artist: testArtistB},
+		{ID: "3", Artist: testArtistC},
+	},
+}
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name    string
+		quota   Quota
+		hist    History
+		wantErr error
+	}{
+		{"Unlimited", Quota{}, NewMemoryHistory(), nil},
+		{"Limited", Quota{Max: 5, Window: time.Hour}, NewMemoryHistory(), nil},
+		{"Nil History", Quota{}, nil, errNilHistory},
+		{"Negative maximum", Quota{Max: -1}, NewMemoryHistory(), errQuotaInvalid},
+		{"Missing window", Quota{Max: 5}, NewMemoryHistory(), errQuotaInvalid},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := New(test.quota, test.hist)
+			if err != test.wantErr {
+				t.Errorf("got: <%v>, want: <%v>", err, test.wantErr)
+			}
+		})
+	}
+}
+
+func TestRegistry_Register(t *testing.T) {
+	tests := []struct {
+		name    string
+		id      string
+		serv    refind.MusicService
+		rec     refind.Recommender
+		wantErr error
+	}{
+		{"Valid user", "foo", fakeMusicService{}, fakeRecommender{}, nil},
+		{"Blank ID", " ", fakeMusicService{}, fakeRecommender{}, errUserID},
+		{"Nil MusicService", "foo", nil, fakeRecommender{}, errNilService},
+		{"Nil Recommender", "foo", fakeMusicService{}, nil, errNilService},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			r, err := New(Quota{}, NewMemoryHistory())
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			err = r.Register(test.id, test.serv, test.rec)
+			if err != test.wantErr {
+				t.Errorf("got: <%v>, want: <%v>", err, test.wantErr)
+			}
+		})
+	}
+}
+
+func TestRegistry_Isolation(t *testing.T) {
+	r, err := New(Quota{}, NewMemoryHistory())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	users := map[string]refind.Artist{"foo": testArtistA, "bar": testArtistB}
+	want := map[string][]string{"foo": {"2", "3"}, "bar": {"1", "3"}}
+	for id, a := range users {
+		if err := r.Register(id, fakeMusicService{artists: []refind.Artist{a}}, testRecs); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := r.SetSettings(id, Settings{Mode: "limited", Total: 5}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 20)
+	for i := 0; i < 10; i++ {
+		for id := range users {
+			wg.Add(1)
+			go func(id string) {
+				defer wg.Done()
+
+				list, _, err := r.Tracklist(id)
+				if err != nil {
+					errs <- err
+					return
+				}
+
+				var got []string
+				for _, t := range list {
+					got = append(got, t.ID)
+				}
+
+				if !reflect.DeepEqual(got, want[id]) {
+					errs <- errors.Errorf("%s got: <%v>, want: <%v>", id, got, want[id])
+				}
+			}(id)
+		}
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		t.Error(err)
+	}
+}
+
+func TestRegistry_Quota(t *testing.T) {
+	var calls int
+	r, err := New(Quota{Max: 2, Window: time.Hour}, NewMemoryHistory())
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.now = func() time.Time { return testNow }
+
+	if err := r.Register("foo", fakeMusicService{artists: []refind.Artist{testArtistA}, calls: &calls}, testRecs); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := r.SetSettings("foo", Settings{Mode: "limited", Total: 5}); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		now     time.Time
+		wantErr error
+	}{
+		{"First generation", testNow, nil},
+		{"Second generation", testNow.Add(10 * time.Minute), nil},
+		{"Over quota", testNow.Add(30 * time.Minute), ErrQuotaExceeded},
+		{"First generation expired", testNow.Add(61 * time.Minute), nil},
+		{"Over quota again", testNow.Add(61 * time.Minute), ErrQuotaExceeded},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			r.now = func() time.Time { return test.now }
+
+			_, _, err := r.Tracklist("foo")
+			if err != test.wantErr {
+				t.Errorf("got: <%v>, want: <%v>", err, test.wantErr)
+			}
+		})
+	}
+
+	if calls != 1 {
+		t.Errorf("got: <%v>, want: <%v>", calls, 1)
+	}
+
+	hist, err := r.History("foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(hist) != 3 {
+		t.Errorf("got: <%v>, want: <%v>", len(hist), 3)
+	}
+
+	if !reflect.DeepEqual(hist[0], Entry{Time: testNow, Tracks: []string{"2", "3"}}) {
+		t.Errorf("got: <%v>, want: <%v>", hist[0], Entry{Time: testNow, Tracks: []string{"2", "3"}})
+	}
+}
+
+func TestRegistry_QuotaFailures(t *testing.T) {
+	r, err := New(Quota{Max: 2, Window: time.Hour}, NewMemoryHistory())
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.now = func() time.Time { return testNow }
+
+	if err := r.Register("foo", fakeMusicService{}, testRecs); err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 2; i++ {
+		_, _, err := r.Tracklist("foo")
+		if err == nil || err == ErrQuotaExceeded {
+			t.Fatalf("got: <%v>, want: <%v>", err, "a failed generation")
+		}
+	}
+
+	if _, _, err := r.Tracklist("foo"); err != ErrQuotaExceeded {
+		t.Errorf("got: <%v>, want: <%v>", err, ErrQuotaExceeded)
+	}
+}
+
+func TestRegistry_Unknown(t *testing.T) {
+	r, err := New(Quota{}, NewMemoryHistory())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, _, err := r.Tracklist("foo"); err != errUserUnknown {
+		t.Errorf("got: <%v>, want: <%v>", err, errUserUnknown)
+	}
+
+	if err := r.Refresh("foo"); err != errUserUnknown {
+		t.Errorf("got: <%v>, want: <%v>", err, errUserUnknown)
+	}
+
+	if _, err := r.Settings("foo"); err != errUserUnknown {
+		t.Errorf("got: <%v>, want: <%v>", err, errUserUnknown)
+	}
+}
+
+func TestSettings_Validate(t *testing.T) {
+	level := 0.8
+	invalid := 1.5
+
+	tests := []struct {
+		name     string
+		settings Settings
+		wantErr  bool
+	}{
+		{"Defaults", DefaultSettings, false},
+		{"Preset and novelty", Settings{Mode: "top", Total: 10, Preset: "chill", Novelty: &level}, false},
+		{"Unknown mode", Settings{Mode: "radar", Total: 10}, true},
+		{"Zero total", Settings{Mode: "full"}, true},
+		{"Total too large", Settings{Mode: "full", Total: 101}, true},
+		{"Unknown preset", Settings{Mode: "full", Total: 10, Preset: "sleep"}, true},
+		{"Novelty out of range", Settings{Mode: "full", Total: 10, Novelty: &invalid}, true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := test.settings.Validate()
+			if (err != nil) != test.wantErr {
+				t.Errorf("got: <%v>, want error: <%v>", err, test.wantErr)
+			}
+		})
+	}
+}
+
+func TestRegistry_BufferTTL(t *testing.T) {
+	tests := []struct {
+		name      string
+		ttl       time.Duration
+		wantCalls int
+	}{
+		{"No TTL", 0, 1},
+		{"Buffer outlives TTL", time.Hour, 2},
+		{"Buffer within TTL", 3 * time.Hour, 1},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var calls int
+			r, err := New(Quota{}, NewMemoryHistory())
+			if err != nil {
+				t.Fatal(err)
+			}
+			r.now = func() time.Time { return testNow }
+
+			if err := r.SetBufferTTL(test.ttl); err != nil {
+				t.Fatal(err)
+			}
+
+			if err := r.Register("foo", fakeMusicService{artists: []refind.Artist{testArtistA}, calls: &calls}, testRecs); err != nil {
+				t.Fatal(err)
+			}
+
+			if err := r.SetSettings("foo", Settings{Mode: "limited", Total: 5}); err != nil {
+				t.Fatal(err)
+			}
+
+			for _, d := range []time.Duration{0, 90 * time.Minute} {
+				r.now = func() time.Time { return testNow.Add(d) }
+				if _, _, err := r.Tracklist("foo"); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if calls != test.wantCalls {
+				t.Errorf("got: <%v>, want: <%v>", calls, test.wantCalls)
+			}
+		})
+	}
+}
+
+func TestRegistry_Generate(t *testing.T) {
+	r, err := New(Quota{}, NewMemoryHistory())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := r.SetPresets(map[string]refind.Tuning{"sleep": {Genres: []string{"ambient"}}}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := r.Register("foo", fakeMusicService{artists: []refind.Artist{testArtistA}}, testRecs); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		settings Settings
+		wantErr  error
+	}{
+		{"Overridden settings", Settings{Mode: "limited", Total: 5}, nil},
+		// The preset is accepted, but the fake recommender cannot be tuned.
+		{"Custom preset", Settings{Mode: "limited", Total: 5, Preset: "sleep"}, refind.ErrNoTuning},
+		{"Built in preset", Settings{Mode: "limited", Total: 5, Preset: "focus"}, ErrSettingsInvalid},
+		{"Invalid settings", Settings{Mode: "radar", Total: 5}, ErrSettingsInvalid},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, _, err := r.Generate("foo", test.settings)
+			if !errors.Is(err, test.wantErr) {
+				t.Errorf("got: <%v>, want: <%v>", err, test.wantErr)
+			}
+		})
+	}
+
+	got, err := r.Settings("foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(got, DefaultSettings) {
+		t.Errorf("got: <%v>, want: <%v>", got, DefaultSettings)
+	}
+}
+
+func TestRegistry_SetDefaults(t *testing.T) {
+	tests := []struct {
+		name     string
+		settings Settings
+		wantErr  error
+	}{
+		{"Valid settings", Settings{Mode: "top", Total: 10, Preset: "focus"}, nil},
+		{"Invalid settings", Settings{Mode: "radar", Total: 10}, ErrSettingsInvalid},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			r, err := New(Quota{}, NewMemoryHistory())
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			err = r.SetDefaults(test.settings)
+			if !errors.Is(err, test.wantErr) {
+				t.Fatalf("got: <%v>, want: <%v>", err, test.wantErr)
+			}
+
+			if err := r.Register("foo", fakeMusicService{}, fakeRecommender{}); err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := r.Settings("foo")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			want := test.settings
+			if test.wantErr != nil {
+				want = DefaultSettings
+			}
+
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("got: <%v>, want: <%v>", got, want)
+			}
+		})
+	}
+}
+
+type countingLogger struct {
+	infos *int
+}
+
+func (c countingLogger) Debug(string, ...interface{}) {}
+func (c countingLogger) Info(string, ...interface{})  { *c.infos++ }
+func (c countingLogger) Warn(string, ...interface{})  {}
+func (c countingLogger) Error(string, ...interface{}) {}
+
+func TestRegistry_SetOptions(t *testing.T) {
+	r, err := New(Quota{}, NewMemoryHistory())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := r.SetOptions(nil); err != errNilOption {
+		t.Errorf("got: <%v>, want: <%v>", err, errNilOption)
+	}
+
+	var infos int
+	if err := r.SetOptions(refind.WithLogger(countingLogger{infos: &infos})); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := r.Register("foo", fakeMusicService{artists: []refind.Artist{testArtistA}}, testRecs); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, _, err := r.Generate("foo", Settings{Mode: "limited", Total: 5}); err != nil {
+		t.Fatal(err)
+	}
+
+	if infos == 0 {
+		t.Errorf("got: <%v>, want: <%v>", infos, "logged stages")
+	}
+}
+
+type countingMetrics struct {
+	counts map[string]int
+}
+
+func (c countingMetrics) Inc(name string, labels ...string) {
+	c.counts[name]++
+}
+
+func (c countingMetrics) Observe(string, float64, ...string) {}
+
+func TestRegistry_SetMetrics(t *testing.T) {
+	r, err := New(Quota{}, NewMemoryHistory())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := r.SetMetrics(nil); err != errNilMetrics {
+		t.Errorf("got: <%v>, want: <%v>", err, errNilMetrics)
+	}
+
+	met := countingMetrics{counts: make(map[string]int)}
+	if err := r.SetMetrics(met); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := r.Register("foo", fakeMusicService{artists: []refind.Artist{testArtistA}}, testRecs); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, _, err := r.Generate("foo", Settings{Mode: "limited", Total: 5}); err != nil {
+		t.Fatal(err)
+	}
+
+	if met.counts[refind.MetricBufferRequests] == 0 {
+		t.Errorf("got: <%v>, want: <%v>", met.counts[refind.MetricBufferRequests], "counted buffer requests")
+	}
+}
@@ -0,0 +1,131 @@
+package user
+
+import (
+	"github.com/Henry-Sarabia/refind"
+	"github.com/pkg/errors"
+)
+
+const maxTotal int = 100
+
+var (
+	// ErrSettingsInvalid is matched with errors.Is by every error that
+	// Validate returns.
+	ErrSettingsInvalid = errors.New("invalid settings")
+	errModeInvalid     = errors.New("mode must be one of full, limited or top")
+	errTotalInvalid    = errors.New("total must be between 1 and 100")
+)
+
+// settingsError marks err as a reason the settings are invalid.
+type settingsError struct {
+	err error
+}
+
+func (e settingsError) Error() string {
+	return e.err.Error()
+}
+
+func (e settingsError) Is(target error) bool {
+	return target == ErrSettingsInvalid
+}
+
+func (e settingsError) Unwrap() error {
+	return e.err
+}
+
+// Settings are a user's generation preferences. A nil Novelty keeps the
+// generator's default popularity bounds.
+type Settings struct {
+	Mode    string   `json:"mode"`
+	Total   int      `json:"n"`
+	Preset  string   `json:"preset,omitempty"`
+	Novelty *float64 `json:"novelty,omitempty"`
+}
+
+// DefaultSettings matches the defaults of the refind command.
+var DefaultSettings = Settings{Mode: "full", Total: 30}
+
+// Validate reports whether the settings can be used with the built in
+// presets.
+func (s Settings) Validate() error {
+	return s.validate(refind.Presets)
+}
+
+func (s Settings) validate(presets map[string]refind.Tuning) error {
+	switch s.Mode {
+	case "full", "limited", "top":
+	default:
+		return settingsError{errModeInvalid}
+	}
+
+	if s.Total <= 0 || s.Total > maxTotal {
+		return settingsError{errTotalInvalid}
+	}
+
+	if s.Preset != "" {
+		if _, err := refind.Preset(presets, s.Preset); err != nil {
+			return settingsError{err}
+		}
+	}
+
+	if s.Novelty != nil {
+		if _, err := refind.Dial(*s.Novelty); err != nil {
+			return settingsError{err}
+		}
+	}
+
+	return nil
+}
+
+// Tracklist generates a tracklist from serv and rec with the settings. The
+// options configure the generator before the settings are applied, such as
+// refind.WithLogger to log its stages.
+func (s Settings) Tracklist(serv refind.MusicService, rec refind.Recommender, opts ...refind.Option) ([]refind.Track, refind.Report, error) {
+	return s.tracklist(serv, rec, refind.Presets, opts)
+}
+
+func (s Settings) tracklist(serv refind.MusicService, rec refind.Recommender, presets map[string]refind.Tuning, opts []refind.Option) ([]refind.Track, refind.Report, error) {
+	gen, err := refind.New(serv, rec, opts...)
+	if err != nil {
+		return nil, refind.Report{}, err
+	}
+
+	if err := s.apply(gen, presets); err != nil {
+		return nil, refind.Report{}, err
+	}
+
+	switch s.Mode {
+	case "limited":
+		return gen.LimitedTracklistReport(s.Total)
+	case "top":
+		return gen.TopTracklistReport(s.Total)
+	default:
+		return gen.TracklistReport(s.Total)
+	}
+}
+
+type generator interface {
+	SetTuning(refind.Tuning) error
+	SetNovelty(float64) error
+}
+
+// apply configures gen with the preset and novelty of the settings.
+func (s Settings) apply(gen generator, presets map[string]refind.Tuning) error {
+	if s.Preset != "" {
+		t, err := refind.Preset(presets, s.Preset)
+		if err != nil {
+			return err
+		}
+
+		if err := gen.SetTuning(t); err != nil {
+			return err
+		}
+	}
+
+	if s.Novelty != nil {
+		if err := gen.SetNovelty(*s.Novelty); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}